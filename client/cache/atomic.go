@@ -0,0 +1,61 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// AtomicCache is an optional extension of Cache for counters and atomic replacement.
+// The memory, sharded memory, file, redis, memcache and ssdb adapters implement it.
+type AtomicCache interface {
+	Cache
+	// IncrBy adds delta to the integer value of key and returns the new value.
+	// If the key does not exist, it is created with value delta.
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
+	// GetAndSet sets the value of key and returns the old value.
+	// The old value is nil if the key did not exist.
+	GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error)
+}
+
+// CASCache is an optional extension of Cache for optimistic updates.
+// The memory, sharded memory, file, redis and memcache adapters implement it.
+//
+//	for {
+//		val, ver, err := c.GetWithVersion(ctx, key)
+//		...
+//		if ok, err := c.CompareAndSwap(ctx, key, ver, update(val), time.Minute); ok || err != nil {
+//			break
+//		}
+//	}
+type CASCache interface {
+	Cache
+	// GetWithVersion returns the value of key and its version.
+	// The version is opaque and only meaningful to the same adapter.
+	GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error)
+	// CompareAndSwap sets the value of key only if its version is still version.
+	// It returns false if the key was changed or deleted.
+	CompareAndSwap(ctx context.Context, key string, version uint64, val interface{}, timeout time.Duration) (bool, error)
+}
+
+var (
+	_ AtomicCache = (*MemoryCache)(nil)
+	_ CASCache    = (*MemoryCache)(nil)
+	_ AtomicCache = (*ShardedMemoryCache)(nil)
+	_ CASCache    = (*ShardedMemoryCache)(nil)
+	_ AtomicCache = (*FileCache)(nil)
+	_ CASCache    = (*FileCache)(nil)
+)
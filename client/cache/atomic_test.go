@@ -0,0 +1,141 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryAtomicCache(t *testing.T) {
+	testAtomicCache(t, NewMemoryCache())
+	testCASCache(t, NewMemoryCache())
+}
+
+func TestShardedMemoryAtomicCache(t *testing.T) {
+	testAtomicCache(t, NewShardedMemoryCache(WithShardCount(4)))
+	testCASCache(t, NewShardedMemoryCache(WithShardCount(4)))
+}
+
+func TestFileAtomicCache(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "test_atomic_cache")
+	defer os.RemoveAll(dir)
+	fc := NewFileCache().(*FileCache)
+	fc.CachePath = dir
+	fc.FileSuffix = ".bin"
+	fc.DirectoryLevel = 2
+	assert.Nil(t, fc.Init())
+	testAtomicCache(t, fc)
+	testCASCache(t, fc)
+}
+
+func testAtomicCache(t *testing.T, c Cache) {
+	ctx := context.Background()
+	ac, ok := c.(AtomicCache)
+	assert.True(t, ok)
+
+	// missing counter is created with delta
+	val, err := ac.IncrBy(ctx, "counter", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), val)
+	val, err = ac.IncrBy(ctx, "counter", -7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), val)
+
+	assert.Nil(t, ac.Put(ctx, "uint", uint32(1), time.Minute))
+	_, err = ac.IncrBy(ctx, "uint", -2)
+	assert.ErrorIs(t, err, ErrDecrementOverflow)
+	_, err = ac.IncrBy(ctx, "uint", math.MaxUint32)
+	assert.ErrorIs(t, err, ErrIncrementOverflow)
+	val, err = ac.IncrBy(ctx, "uint", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), val)
+
+	assert.Nil(t, ac.Put(ctx, "string", "abc", time.Minute))
+	_, err = ac.IncrBy(ctx, "string", 1)
+	assert.ErrorIs(t, err, ErrNotIntegerType)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = ac.IncrBy(ctx, "concurrent", 1)
+		}()
+	}
+	wg.Wait()
+	val, err = ac.IncrBy(ctx, "concurrent", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), val)
+
+	old, err := ac.GetAndSet(ctx, "key", "v1", time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, old)
+	old, err = ac.GetAndSet(ctx, "key", "v2", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", old)
+	cur, err := ac.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", cur)
+}
+
+func testCASCache(t *testing.T, c Cache) {
+	ctx := context.Background()
+	cc, ok := c.(CASCache)
+	assert.True(t, ok)
+
+	_, _, err := cc.GetWithVersion(ctx, "cas")
+	assert.NotNil(t, err)
+	ok, err = cc.CompareAndSwap(ctx, "cas", 0, "v1", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, cc.Put(ctx, "cas", "v1", time.Minute))
+	val, ver, err := cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", val)
+
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v2", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	// the version changes after the swap
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v3", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	val, ver, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", val)
+	assert.Nil(t, cc.Put(ctx, "cas", "v4", time.Minute))
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v5", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	// the swap fails after the value is changed and changed back
+	val, ver, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	assert.Equal(t, "v4", val)
+	assert.Nil(t, cc.Put(ctx, "cas", "v5", time.Minute))
+	assert.Nil(t, cc.Put(ctx, "cas", "v4", time.Minute))
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v6", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
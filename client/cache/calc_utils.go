@@ -93,3 +93,81 @@ func decr(originVal interface{}) (interface{}, error) {
 		return nil, ErrNotIntegerType
 	}
 }
+
+// incrBy adds delta to originVal, keeps its type and checks the overflow
+func incrBy(originVal interface{}, delta int64) (interface{}, error) {
+	switch val := originVal.(type) {
+	case int:
+		res, err := addInt64(int64(val), delta, math.MinInt, math.MaxInt)
+		return int(res), err
+	case int32:
+		res, err := addInt64(int64(val), delta, math.MinInt32, math.MaxInt32)
+		return int32(res), err
+	case int64:
+		return addInt64(val, delta, math.MinInt64, math.MaxInt64)
+	case uint:
+		res, err := addUint64(uint64(val), delta, math.MaxUint)
+		return uint(res), err
+	case uint32:
+		res, err := addUint64(uint64(val), delta, math.MaxUint32)
+		return uint32(res), err
+	case uint64:
+		return addUint64(val, delta, math.MaxUint64)
+	default:
+		return nil, ErrNotIntegerType
+	}
+}
+
+func addInt64(val, delta, min, max int64) (int64, error) {
+	if delta > 0 && val > max-delta {
+		return 0, ErrIncrementOverflow
+	}
+	if delta < 0 && val < min-delta {
+		return 0, ErrDecrementOverflow
+	}
+	return val + delta, nil
+}
+
+func addUint64(val uint64, delta int64, max uint64) (uint64, error) {
+	if delta >= 0 {
+		// max-uint64(delta) underflows if delta is greater than max
+		if uint64(delta) > max || val > max-uint64(delta) {
+			return 0, ErrIncrementOverflow
+		}
+		return val + uint64(delta), nil
+	}
+	// -delta overflows when delta is MinInt64, but its uint64 conversion is still correct
+	d := uint64(-delta)
+	if val < d {
+		return 0, ErrDecrementOverflow
+	}
+	return val - d, nil
+}
+
+// toInt64 converts the result of incrBy to int64,
+// it returns ErrIncrementOverflow if the unsigned val is greater than math.MaxInt64
+func toInt64(val interface{}) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return uintToInt64(uint64(v))
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return uintToInt64(v)
+	default:
+		return 0, ErrNotIntegerType
+	}
+}
+
+func uintToInt64(v uint64) (int64, error) {
+	if v > math.MaxInt64 {
+		return 0, ErrIncrementOverflow
+	}
+	return int64(v), nil
+}
@@ -138,3 +138,18 @@ func TestDecr(t *testing.T) {
 	_, err = decr("string")
 	assert.Equal(t, ErrNotIntegerType, err)
 }
+
+func TestIncrByOverflow(t *testing.T) {
+	// the delta is greater than the max of uint32
+	_, err := incrBy(uint32(1), 1<<33)
+	assert.Equal(t, ErrIncrementOverflow, err)
+	val, err := incrBy(uint32(1), math.MaxUint32-1)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(math.MaxUint32), val)
+
+	res, err := toInt64(uint64(math.MaxInt64))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(math.MaxInt64), res)
+	_, err = toInt64(uint64(math.MaxInt64 + 1))
+	assert.Equal(t, ErrIncrementOverflow, err)
+}
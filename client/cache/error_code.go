@@ -142,6 +142,28 @@ Failed to execute the StoreFunc.
 Please check the log to make sure the StoreFunc works for the specific key and value.
 `)
 
+var CodecFailed = berror.DefineCode(4002027, moduleName, "CodecFailed", `
+TypedCache could not encode or decode the value with its Codec.
+Please check that the value type is supported by the codec, 
+and that the key is only written through TypedCache with the same codec.
+`)
+
+var LockNotAcquired = berror.DefineCode(4002028, moduleName, "LockNotAcquired", `
+The lock is held by another owner. Usually it is a normal case, you could retry later,
+or use Locker.Lock, which waits until the lock is released or the context is done.
+`)
+
+var LockNotHeld = berror.DefineCode(4002029, moduleName, "LockNotHeld", `
+The lock is no longer held by you. Usually it means that the lease expired before it was renewed,
+for example, the process was paused for a long time, or the connection to cache server was broken.
+The work protected by the lock may be performed by others, please check the fencing token before writing.
+`)
+
+var InvalidFileCacheGCCfg = berror.DefineCode(4002030, moduleName, "InvalidFileCacheGCCfg", `
+You pass invalid GCInterval or MaxBytes parameter when you try to StartAndGC file cache instance.
+These parameters must be integers, and please check your input.
+`)
+
 var DeleteFileCacheItemFailed = berror.DefineCode(5002001, moduleName, "DeleteFileCacheItemFailed", `
 Beego try to delete file cache item failed. 
 Please check whether Beego generated file correctly. 
@@ -21,12 +21,16 @@ import (
 	"encoding/gob"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jialequ/android-sdk/core/berror"
@@ -38,6 +42,9 @@ type FileCacheItem struct {
 	Data       interface{}
 	Lastaccess time.Time
 	Expired    time.Time
+	Key        string
+	Tags       []string
+	Version    uint64
 }
 
 // FileCache Config
@@ -46,14 +53,45 @@ var (
 	FileCacheFileSuffix     = ".bin"      // cache file suffix
 	FileCacheDirectoryLevel = 2           // cache file deep level if auto generated cache files.
 	FileCacheEmbedExpiry    time.Duration // cache expire time, default is no expire forever.
+	FileCacheGCInterval     = time.Minute // interval of removing expired files, 0 disables the GC.
+	FileCacheMaxBytes       int64         // size quota of cached files, default is no limit.
 )
 
+// fileCacheTempTTL is how long an unfinished temp file is kept before the GC removes it
+const fileCacheTempTTL = time.Hour
+
+// fileCacheLowWatermark is the ratio of MaxBytes that eviction shrinks the cache to,
+// so that the eviction is not triggered again by the next write
+const fileCacheLowWatermark = 0.9
+
 // FileCache is cache adapter for file storage.
+// The writes of the same FileCache instance are serialized,
+// so IncrBy, GetAndSet and CompareAndSwap are atomic within the process.
+// Files are written to a temp file and renamed, so readers never see partial files.
+//
+// The GC started by StartAndGC removes expired files every GCInterval seconds.
+// If MaxBytes is positive, it also evicts the least recently used files until the
+// cached files fit in the quota. Get updates the mtime of files for this purpose.
+// The quota is checked by the GC, which is triggered early when a write exceeds it,
+// so the cached files may exceed MaxBytes for a short time.
 type FileCache struct {
-	CachePath      string
-	FileSuffix     string
+	CachePath string
+	// FileSuffix is the suffix of cached files
+	FileSuffix string
+	// DirectoryLevel is the depth of directories, each level is named by 2 hex digits of md5(key).
+	// It is at most 16.
 	DirectoryLevel int
 	EmbedExpiry    int
+	// GCInterval is the interval of GC in seconds, 0 disables the GC
+	GCInterval int
+	// MaxBytes is the size quota of cached files, 0 means no limit
+	MaxBytes int64
+
+	mutex       sync.Mutex
+	lastVersion uint64
+	usedBytes   int64
+	gcStarted   bool
+	gcRunning   int32
 }
 
 // NewFileCache creates a new file cache with no config.
@@ -76,6 +114,8 @@ func (fc *FileCache) StartAndGC(config string) error {
 	const fsKey = "FileSuffix"
 	const dlKey = "DirectoryLevel"
 	const eeKey = "EmbedExpiry"
+	const giKey = "GCInterval"
+	const mbKey = "MaxBytes"
 
 	if _, ok := cfg[cpKey]; !ok {
 		cfg[cpKey] = FileCachePath
@@ -92,6 +132,14 @@ func (fc *FileCache) StartAndGC(config string) error {
 	if _, ok := cfg[eeKey]; !ok {
 		cfg[eeKey] = strconv.FormatInt(int64(FileCacheEmbedExpiry.Seconds()), 10)
 	}
+
+	if _, ok := cfg[giKey]; !ok {
+		cfg[giKey] = strconv.FormatInt(int64(FileCacheGCInterval.Seconds()), 10)
+	}
+
+	if _, ok := cfg[mbKey]; !ok {
+		cfg[mbKey] = strconv.FormatInt(FileCacheMaxBytes, 10)
+	}
 	fc.CachePath = cfg[cpKey]
 	fc.FileSuffix = cfg[fsKey]
 	fc.DirectoryLevel, err = strconv.Atoi(cfg[dlKey])
@@ -104,7 +152,33 @@ func (fc *FileCache) StartAndGC(config string) error {
 		return berror.Wrapf(err, InvalidFileCacheEmbedExpiryCfg,
 			"invalid embed expiry config, please check your input, it must be integer: %s", cfg[eeKey])
 	}
-	return fc.Init()
+	gcInterval, err := strconv.Atoi(cfg[giKey])
+	if err != nil {
+		return berror.Wrapf(err, InvalidFileCacheGCCfg,
+			"invalid GC interval config, please check your input, it must be integer: %s", cfg[giKey])
+	}
+	maxBytes, err := strconv.ParseInt(cfg[mbKey], 10, 64)
+	if err != nil {
+		return berror.Wrapf(err, InvalidFileCacheGCCfg,
+			"invalid max bytes config, please check your input, it must be integer: %s", cfg[mbKey])
+	}
+	if err = fc.Init(); err != nil {
+		return err
+	}
+
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.GCInterval = gcInterval
+	fc.MaxBytes = maxBytes
+	if !fc.gcStarted && gcInterval > 0 {
+		fc.gcStarted = true
+		go fc.vacuum()
+	}
+	if maxBytes > 0 {
+		// count the existing files
+		fc.triggerGC()
+	}
+	return nil
 }
 
 // Init makes new a dir for file cache if it does not already exist
@@ -127,11 +201,8 @@ func (fc *FileCache) getCacheFileName(key string) (string, error) {
 	_, _ = io.WriteString(m, key)
 	keyMd5 := hex.EncodeToString(m.Sum(nil))
 	cachePath := fc.CachePath
-	switch fc.DirectoryLevel {
-	case 2:
-		cachePath = filepath.Join(cachePath, keyMd5[0:2], keyMd5[2:4])
-	case 1:
-		cachePath = filepath.Join(cachePath, keyMd5[0:2])
+	for i := 0; i < fc.DirectoryLevel && i < len(keyMd5)/2; i++ {
+		cachePath = filepath.Join(cachePath, keyMd5[2*i:2*i+2])
 	}
 	ok, err := exists(cachePath)
 	if err != nil {
@@ -151,6 +222,22 @@ func (fc *FileCache) getCacheFileName(key string) (string, error) {
 // Get value from file cache.
 // if nonexistent or expired return an empty string.
 func (fc *FileCache) Get(ctx context.Context, key string) (interface{}, error) {
+	to, err := fc.getItem(key)
+	if err != nil {
+		return nil, err
+	}
+	if fc.quota() > 0 {
+		// the mtime is the access time of LRU eviction
+		if fn, er := fc.getCacheFileName(key); er == nil {
+			now := time.Now()
+			_ = os.Chtimes(fn, now, now)
+		}
+	}
+	return to.Data, nil
+}
+
+// getItem reads the item of key, and returns ErrKeyExpired if it is expired.
+func (fc *FileCache) getItem(key string) (*FileCacheItem, error) {
 	fn, err := fc.getCacheFileName(key)
 	if err != nil {
 		return nil, err
@@ -169,7 +256,7 @@ func (fc *FileCache) Get(ctx context.Context, key string) (interface{}, error) {
 	if to.Expired.Before(time.Now()) {
 		return nil, ErrKeyExpired
 	}
-	return to.Data, nil
+	return &to, nil
 }
 
 // GetMulti gets values from file cache.
@@ -197,9 +284,23 @@ func (fc *FileCache) GetMulti(ctx context.Context, keys []string) ([]interface{}
 // timeout: how long this file should be kept in ms
 // if timeout equals fc.EmbedExpiry(default is 0), cache this item forever.
 func (fc *FileCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return fc.PutWithTags(ctx, key, val, timeout)
+}
+
+// PutWithTags puts value into file cache and associates it with tags,
+// so that it can be deleted by InvalidateTag.
+func (fc *FileCache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.putItem(key, val, timeout, tags)
+}
+
+// putItem writes the cache file of key.
+// The caller must hold the mutex.
+func (fc *FileCache) putItem(key string, val interface{}, timeout time.Duration, tags []string) error {
 	gob.Register(val)
 
-	item := FileCacheItem{Data: val}
+	item := FileCacheItem{Data: val, Key: key, Tags: tags, Version: fc.nextVersion()}
 	if timeout == time.Duration(fc.EmbedExpiry) {
 		item.Expired = time.Now().Add((86400 * 365 * 10) * time.Second) // ten years
 	} else {
@@ -215,54 +316,216 @@ func (fc *FileCache) Put(ctx context.Context, key string, val interface{}, timeo
 	if err != nil {
 		return err
 	}
-	return FilePutContents(fn, data)
+	oldSize := fileSize(fn)
+	if err = FilePutContents(fn, data); err != nil {
+		return err
+	}
+	fc.usedBytes += int64(len(data)) - oldSize
+	if fc.MaxBytes > 0 && fc.usedBytes > fc.MaxBytes {
+		fc.triggerGC()
+	}
+	return nil
+}
+
+// nextVersion returns an increasing version based on the clock,
+// so that the versions stay increasing after restart.
+// The caller must hold the mutex.
+func (fc *FileCache) nextVersion() uint64 {
+	v := uint64(time.Now().UnixNano())
+	if v <= fc.lastVersion {
+		v = fc.lastVersion + 1
+	}
+	fc.lastVersion = v
+	return v
 }
 
 // Delete file cache value.
 func (fc *FileCache) Delete(ctx context.Context, key string) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
 	filename, err := fc.getCacheFileName(key)
 	if err != nil {
 		return err
 	}
 	if ok, _ := exists(filename); ok {
+		size := fileSize(filename)
 		err = os.Remove(filename)
 		if err != nil {
 			return berror.Wrapf(err, DeleteFileCacheItemFailed,
 				"can not delete this file cache key-value, key is %s and file name is %s", key, filename)
 		}
+		fc.usedBytes -= size
 	}
 	return nil
 }
 
+// InvalidateTag deletes all cached files associated with any of tags.
+// It reads every cached file, so it is slow when there are many files.
+func (fc *FileCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	return fc.deleteIf(func(item *FileCacheItem) bool {
+		for _, t := range item.Tags {
+			for _, tag := range tags {
+				if t == tag {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// DeleteByPrefix deletes all cached files whose key starts with prefix.
+// It reads every cached file, so it is slow when there are many files.
+func (fc *FileCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	return fc.deleteIf(func(item *FileCacheItem) bool {
+		return strings.HasPrefix(item.Key, prefix)
+	})
+}
+
+// deleteIf removes the cached files whose item matches fn.
+// The files written before keys are stored in items are skipped.
+func (fc *FileCache) deleteIf(fn func(item *FileCacheItem) bool) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return filepath.Walk(fc.CachePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, fc.FileSuffix) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// deleted by others
+			return nil
+		}
+		var item FileCacheItem
+		if GobDecode(data, &item) != nil || !fn(&item) {
+			return nil
+		}
+		if err = os.Remove(path); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return berror.Wrapf(err, DeleteFileCacheItemFailed,
+				"can not delete this file cache key-value, key is %s and file name is %s", item.Key, path)
+		}
+		fc.usedBytes -= info.Size()
+		return nil
+	})
+}
+
 // Incr increases cached int value.
 // fc value is saved forever unless deleted.
 func (fc *FileCache) Incr(ctx context.Context, key string) error {
-	data, err := fc.Get(context.Background(), key)
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	item, err := fc.getItem(key)
 	if err != nil {
 		return err
 	}
 
-	val, err := incr(data)
+	val, err := incr(item.Data)
 	if err != nil {
 		return err
 	}
 
-	return fc.Put(context.Background(), key, val, time.Duration(fc.EmbedExpiry))
+	return fc.putItem(key, val, time.Duration(fc.EmbedExpiry), item.Tags)
 }
 
 // Decr decreases cached int value.
 func (fc *FileCache) Decr(ctx context.Context, key string) error {
-	data, err := fc.Get(context.Background(), key)
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	item, err := fc.getItem(key)
 	if err != nil {
 		return err
 	}
 
-	val, err := decr(data)
+	val, err := decr(item.Data)
 	if err != nil {
 		return err
 	}
 
-	return fc.Put(context.Background(), key, val, time.Duration(fc.EmbedExpiry))
+	return fc.putItem(key, val, time.Duration(fc.EmbedExpiry), item.Tags)
+}
+
+// IncrBy adds delta to the cached int value and returns the new value.
+// If the key does not exist, it is created as int64.
+// fc value is saved forever unless deleted.
+func (fc *FileCache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	item, err := fc.getItem(key)
+	if err != nil {
+		if !isMissingItem(err) {
+			return 0, err
+		}
+		if err = fc.putItem(key, delta, time.Duration(fc.EmbedExpiry), nil); err != nil {
+			return 0, err
+		}
+		return delta, nil
+	}
+
+	val, err := incrBy(item.Data, delta)
+	if err != nil {
+		return 0, err
+	}
+	res, err := toInt64(val)
+	if err != nil {
+		return 0, err
+	}
+	return res, fc.putItem(key, val, time.Duration(fc.EmbedExpiry), item.Tags)
+}
+
+// GetAndSet puts value into file cache and returns the old value.
+func (fc *FileCache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	var old interface{}
+	item, err := fc.getItem(key)
+	if err == nil {
+		old = item.Data
+	} else if !isMissingItem(err) {
+		return nil, err
+	}
+	return old, fc.putItem(key, val, timeout, nil)
+}
+
+// GetWithVersion gets value from file cache and its version.
+func (fc *FileCache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	item, err := fc.getItem(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return item.Data, item.Version, nil
+}
+
+// CompareAndSwap puts value into file cache if the version of the cached value is version.
+func (fc *FileCache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	item, err := fc.getItem(key)
+	if err != nil {
+		if isMissingItem(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if item.Version != version {
+		return false, nil
+	}
+	return true, fc.putItem(key, val, timeout, item.Tags)
+}
+
+// isMissingItem reports whether err returned by getItem means that the key does not exist
+func isMissingItem(err error) bool {
+	return errors.Is(err, ErrKeyExpired) || errors.Is(err, os.ErrNotExist)
 }
 
 // IsExist checks if value exists.
@@ -274,11 +537,173 @@ func (fc *FileCache) IsExist(ctx context.Context, key string) (bool, error) {
 	return exists(fn)
 }
 
-// ClearAll cleans cached files (not implemented)
+// ClearAll deletes all cached files and unfinished temp files under CachePath.
+// The other files and the directories are kept.
 func (fc *FileCache) ClearAll(context.Context) error {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	err := filepath.Walk(fc.CachePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || (!strings.HasSuffix(path, fc.FileSuffix) && !isTempFile(path)) {
+			return nil
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return berror.Wrapf(err, DeleteFileCacheItemFailed,
+				"can not delete the cached file: %s", path)
+		}
+		return nil
+	})
+	fc.usedBytes = 0
+	return err
+}
+
+// quota returns MaxBytes
+func (fc *FileCache) quota() int64 {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	return fc.MaxBytes
+}
+
+// vacuum runs the GC every GCInterval seconds
+func (fc *FileCache) vacuum() {
+	for {
+		fc.mutex.Lock()
+		interval := fc.GCInterval
+		if interval < 1 {
+			fc.gcStarted = false
+			fc.mutex.Unlock()
+			return
+		}
+		fc.mutex.Unlock()
+		<-time.After(time.Duration(interval) * time.Second)
+		fc.triggerGC()
+	}
+}
+
+// triggerGC runs the GC in a new goroutine unless it is running
+func (fc *FileCache) triggerGC() {
+	if atomic.CompareAndSwapInt32(&fc.gcRunning, 0, 1) {
+		go func() {
+			defer atomic.StoreInt32(&fc.gcRunning, 0)
+			_ = fc.gc()
+		}()
+	}
+}
+
+// cachedFile is a cached file found by the GC
+type cachedFile struct {
+	path  string
+	size  int64
+	mtime time.Time
+}
+
+// gc removes the expired files and stale temp files,
+// and then evicts the least recently used files if the quota is exceeded.
+func (fc *FileCache) gc() error {
+	var (
+		files []cachedFile
+		total int64
+	)
+	now := time.Now()
+	err := filepath.Walk(fc.CachePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if isTempFile(path) {
+			if now.Sub(info.ModTime()) > fileCacheTempTTL {
+				_ = os.Remove(path)
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, fc.FileSuffix) {
+			return nil
+		}
+		if fc.removeExpired(path, now) {
+			return nil
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), mtime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fc.mutex.Lock()
+	maxBytes := fc.MaxBytes
+	fc.mutex.Unlock()
+	if maxBytes > 0 && total > maxBytes {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].mtime.Before(files[j].mtime)
+		})
+		target := int64(float64(maxBytes) * fileCacheLowWatermark)
+		for _, f := range files {
+			if total <= target {
+				break
+			}
+			if fc.removeIfUnchanged(f) {
+				total -= f.size
+			}
+		}
+	}
+
+	fc.mutex.Lock()
+	fc.usedBytes = total
+	fc.mutex.Unlock()
 	return nil
 }
 
+// removeExpired removes the file if the item in it is expired
+func (fc *FileCache) removeExpired(path string, now time.Time) bool {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	var item FileCacheItem
+	if GobDecode(data, &item) != nil || !item.Expired.Before(now) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// removeIfUnchanged evicts the file unless it was accessed or rewritten after the scan
+func (fc *FileCache) removeIfUnchanged(f cachedFile) bool {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil || !info.ModTime().Equal(f.mtime) {
+		return false
+	}
+	return os.Remove(f.path) == nil
+}
+
+// isTempFile reports whether path is a temp file created by FilePutContents
+func isTempFile(path string) bool {
+	return strings.Contains(filepath.Base(path), fileCacheTempMarker)
+}
+
+// fileSize returns the size of the file, or 0 if it does not exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
 // Check if a file exists
 func exists(path string) (bool, error) {
 	_, err := os.Stat(path)
@@ -303,10 +728,33 @@ func FileGetContents(filename string) ([]byte, error) {
 	return data, nil
 }
 
+// fileCacheTempMarker is in the name of temp files, followed by a random string
+const fileCacheTempMarker = ".tmp-"
+
 // FilePutContents puts bytes into a file.
 // if non-existent, create this file.
+// The content is written to a temp file in the same directory first and then renamed,
+// so that the readers see either the old content or the new content.
 func FilePutContents(filename string, content []byte) error {
-	return os.WriteFile(filename, content, os.ModePerm)
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+fileCacheTempMarker+"*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
 }
 
 // GobEncode Gob encodes a file cache item.
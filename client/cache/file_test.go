@@ -19,7 +19,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -103,6 +106,166 @@ func TestFileCacheDelete(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func newTestFileCache(t *testing.T, name string) *FileCache {
+	dir := filepath.Join(os.TempDir(), name)
+	_ = os.RemoveAll(dir)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(dir)
+	})
+	fc := NewFileCache().(*FileCache)
+	assert.Nil(t, fc.StartAndGC(fmt.Sprintf(`{"CachePath":"%s","DirectoryLevel":"3","GCInterval":"0"}`, dir)))
+	return fc
+}
+
+func countCacheFiles(t *testing.T, dir string) (cached int, temp int) {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if isTempFile(path) {
+			temp++
+		} else {
+			cached++
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	return
+}
+
+func TestFileCacheDirectoryLevel(t *testing.T) {
+	fc := newTestFileCache(t, "test_file_cache_level")
+	assert.Nil(t, fc.Put(context.Background(), "key", "value", time.Minute))
+	fn, err := fc.getCacheFileName("key")
+	assert.Nil(t, err)
+	rel, err := filepath.Rel(fc.CachePath, fn)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(strings.Split(rel, string(filepath.Separator))))
+}
+
+func TestFileCacheClearAll(t *testing.T) {
+	fc := newTestFileCache(t, "test_file_cache_clear")
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, fc.Put(ctx, fmt.Sprintf("key%d", i), i, time.Minute))
+	}
+	other := filepath.Join(fc.CachePath, "README")
+	assert.Nil(t, os.WriteFile(other, []byte("keep"), 0o644))
+
+	cached, temp := countCacheFiles(t, fc.CachePath)
+	assert.Equal(t, 11, cached)
+	assert.Equal(t, 0, temp)
+
+	assert.Nil(t, fc.ClearAll(ctx))
+	res, _ := fc.IsExist(ctx, "key1")
+	assert.False(t, res)
+	cached, _ = countCacheFiles(t, fc.CachePath)
+	assert.Equal(t, 1, cached)
+}
+
+func TestFileCacheGC(t *testing.T) {
+	fc := newTestFileCache(t, "test_file_cache_gc")
+	ctx := context.Background()
+	assert.Nil(t, fc.Put(ctx, "expired", "value", time.Millisecond))
+	assert.Nil(t, fc.Put(ctx, "alive", "value", time.Minute))
+	stale := filepath.Join(fc.CachePath, "stale"+fileCacheTempMarker+"1")
+	assert.Nil(t, os.WriteFile(stale, []byte("partial"), 0o644))
+	old := time.Now().Add(-2 * fileCacheTempTTL)
+	assert.Nil(t, os.Chtimes(stale, old, old))
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Nil(t, fc.gc())
+	cached, temp := countCacheFiles(t, fc.CachePath)
+	assert.Equal(t, 1, cached)
+	assert.Equal(t, 0, temp)
+	res, _ := fc.IsExist(ctx, "alive")
+	assert.True(t, res)
+}
+
+func TestFileCacheMaxBytes(t *testing.T) {
+	fc := newTestFileCache(t, "test_file_cache_quota")
+	ctx := context.Background()
+	value := strings.Repeat("a", 1000)
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, fc.Put(ctx, fmt.Sprintf("key%d", i), value, time.Minute))
+		// make the mtime distinct
+		fn, _ := fc.getCacheFileName(fmt.Sprintf("key%d", i))
+		mtime := time.Now().Add(time.Duration(i-10) * time.Second)
+		assert.Nil(t, os.Chtimes(fn, mtime, mtime))
+	}
+	size := fileSize(mustCacheFileName(t, fc, "key0"))
+
+	fc.mutex.Lock()
+	// the eviction stops at 90% of MaxBytes
+	fc.MaxBytes = 4*size - 1
+	fc.mutex.Unlock()
+	// key0 is accessed recently, so key1 and key2 are evicted
+	_, err := fc.Get(ctx, "key0")
+	assert.Nil(t, err)
+	assert.Nil(t, fc.gc())
+
+	for i, exist := range []bool{true, false, false, true, true} {
+		res, _ := fc.IsExist(ctx, fmt.Sprintf("key%d", i))
+		assert.Equal(t, exist, res, "key%d", i)
+	}
+	fc.mutex.Lock()
+	assert.Equal(t, 3*size, fc.usedBytes)
+	fc.mutex.Unlock()
+}
+
+func TestFileCacheDeleteIfUsedBytes(t *testing.T) {
+	fc := newTestFileCache(t, "test_file_cache_delete_if")
+	ctx := context.Background()
+	assert.Nil(t, fc.PutWithTags(ctx, "user:1", "a", time.Minute, "user"))
+	assert.Nil(t, fc.PutWithTags(ctx, "user:2", "b", time.Minute, "user"))
+	assert.Nil(t, fc.Put(ctx, "order:1", "c", time.Minute))
+	size := fileSize(mustCacheFileName(t, fc, "order:1"))
+
+	assert.Nil(t, fc.InvalidateTag(ctx, "user"))
+	fc.mutex.Lock()
+	assert.Equal(t, size, fc.usedBytes)
+	fc.mutex.Unlock()
+
+	assert.Nil(t, fc.DeleteByPrefix(ctx, "order:"))
+	fc.mutex.Lock()
+	assert.Equal(t, int64(0), fc.usedBytes)
+	fc.mutex.Unlock()
+}
+
+func mustCacheFileName(t *testing.T, fc *FileCache, key string) string {
+	fn, err := fc.getCacheFileName(key)
+	assert.Nil(t, err)
+	return fn
+}
+
+func TestFilePutContentsConcurrently(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "test_file_put_contents")
+	assert.Nil(t, os.MkdirAll(dir, os.ModePerm))
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "file.bin")
+	small, large := []byte("small"), []byte(strings.Repeat("large", 10000))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, FilePutContents(fn, small))
+			assert.Nil(t, FilePutContents(fn, large))
+		}()
+		go func() {
+			defer wg.Done()
+			data, err := os.ReadFile(fn)
+			if err == nil {
+				assert.True(t, string(data) == string(small) || string(data) == string(large))
+			}
+		}()
+	}
+	wg.Wait()
+	_, temp := countCacheFiles(t, dir)
+	assert.Equal(t, 0, temp)
+}
+
 func getTestCacheFilePath() string {
 	return filepath.Join(os.TempDir(), "test", "file.txt")
 }
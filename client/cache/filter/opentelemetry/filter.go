@@ -0,0 +1,294 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jialequ/android-sdk/client/cache"
+)
+
+type CustomSpanFunc func(span trace.Span, ctx context.Context, method string, keys []string, err error)
+
+// OtelFilterChainBuilder wraps a cache.Cache and starts a span for each operation.
+// The span is tagged with the adapter name and the key prefix, the part before the first ':'.
+// The prefix of multiple keys is the one shared by them, or "*" if they differ.
+// cache.ErrKeyNotExist is taken as a miss but not an error.
+// The optional interfaces of the wrapped cache, such as cache.TaggedCache, are kept.
+//
+// Usage:
+//
+//	bm, _ := cache.NewCache("redis", `{"conn":"127.0.0.1:6379"}`)
+//	bm = opentelemetry.NewOpenTelemetryFilter("redis", nil).FilterChain(bm)
+type OtelFilterChainBuilder struct {
+	adapter string
+	// CustomSpanFunc users are able to custom their span
+	customSpanFunc CustomSpanFunc
+}
+
+func NewOpenTelemetryFilter(adapter string, spanFunc CustomSpanFunc) *OtelFilterChainBuilder {
+	return &OtelFilterChainBuilder{
+		adapter:        adapter,
+		customSpanFunc: spanFunc,
+	}
+}
+
+func (builder *OtelFilterChainBuilder) FilterChain(next cache.Cache) cache.Cache {
+	return wrap(&filterCache{Cache: next, builder: builder}, next)
+}
+
+func (builder *OtelFilterChainBuilder) start(ctx context.Context, method string, keys ...string) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer("beego").Start(ctx, "cache#"+method)
+	span.SetAttributes(attribute.String("cache.adapter", builder.adapter))
+	span.SetAttributes(attribute.String("cache.method", method))
+	if len(keys) > 0 {
+		span.SetAttributes(attribute.String("cache.key_prefix", keysPrefix(keys)))
+	}
+	if len(keys) > 1 {
+		span.SetAttributes(attribute.Int("cache.key_count", len(keys)))
+	}
+	span.SetAttributes(attribute.String("span.kind", "client"))
+	span.SetAttributes(attribute.String("component", "beego"))
+	return spanCtx, span
+}
+
+func (builder *OtelFilterChainBuilder) end(ctx context.Context, span trace.Span, method string, keys []string, err error) {
+	if err != nil && !errors.Is(err, cache.ErrKeyNotExist) {
+		span.SetAttributes(attribute.Bool("error", true))
+		span.RecordError(err)
+	}
+	if builder.customSpanFunc != nil {
+		builder.customSpanFunc(span, ctx, method, keys, err)
+	}
+	span.End()
+}
+
+func keyPrefix(key string) string {
+	if idx := strings.IndexByte(key, ':'); idx >= 0 {
+		return key[:idx]
+	}
+	return ""
+}
+
+// keysPrefix returns the prefix shared by keys, or "*" if they differ
+func keysPrefix(keys []string) string {
+	prefix := keyPrefix(keys[0])
+	for _, key := range keys[1:] {
+		if keyPrefix(key) != prefix {
+			return "*"
+		}
+	}
+	return prefix
+}
+
+type filterCache struct {
+	cache.Cache
+	builder *OtelFilterChainBuilder
+}
+
+func (c *filterCache) Get(ctx context.Context, key string) (interface{}, error) {
+	spanCtx, span := c.builder.start(ctx, "Get", key)
+	val, err := c.Cache.Get(spanCtx, key)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil && val != nil))
+	c.builder.end(ctx, span, "Get", []string{key}, err)
+	return val, err
+}
+
+func (c *filterCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	spanCtx, span := c.builder.start(ctx, "GetMulti", keys...)
+	vals, err := c.Cache.GetMulti(spanCtx, keys)
+	hits := 0
+	for _, val := range vals {
+		if val != nil {
+			hits++
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.hits", hits))
+	c.builder.end(ctx, span, "GetMulti", keys, err)
+	return vals, err
+}
+
+func (c *filterCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	spanCtx, span := c.builder.start(ctx, "Put", key)
+	err := c.Cache.Put(spanCtx, key, val, timeout)
+	c.builder.end(ctx, span, "Put", []string{key}, err)
+	return err
+}
+
+func (c *filterCache) Delete(ctx context.Context, key string) error {
+	spanCtx, span := c.builder.start(ctx, "Delete", key)
+	err := c.Cache.Delete(spanCtx, key)
+	c.builder.end(ctx, span, "Delete", []string{key}, err)
+	return err
+}
+
+func (c *filterCache) Incr(ctx context.Context, key string) error {
+	spanCtx, span := c.builder.start(ctx, "Incr", key)
+	err := c.Cache.Incr(spanCtx, key)
+	c.builder.end(ctx, span, "Incr", []string{key}, err)
+	return err
+}
+
+func (c *filterCache) Decr(ctx context.Context, key string) error {
+	spanCtx, span := c.builder.start(ctx, "Decr", key)
+	err := c.Cache.Decr(spanCtx, key)
+	c.builder.end(ctx, span, "Decr", []string{key}, err)
+	return err
+}
+
+func (c *filterCache) IsExist(ctx context.Context, key string) (bool, error) {
+	spanCtx, span := c.builder.start(ctx, "IsExist", key)
+	res, err := c.Cache.IsExist(spanCtx, key)
+	c.builder.end(ctx, span, "IsExist", []string{key}, err)
+	return res, err
+}
+
+func (c *filterCache) ClearAll(ctx context.Context) error {
+	spanCtx, span := c.builder.start(ctx, "ClearAll")
+	err := c.Cache.ClearAll(spanCtx)
+	c.builder.end(ctx, span, "ClearAll", nil, err)
+	return err
+}
+
+// wrap returns c with the optional interfaces of next, such as cache.TaggedCache,
+// so that the type assertions on the filtered cache still work.
+func wrap(c *filterCache, next cache.Cache) cache.Cache {
+	tc, tagged := next.(cache.TaggedCache)
+	ac, atomic := next.(cache.AtomicCache)
+	cc, cas := next.(cache.CASCache)
+	t := &taggedCache{next: tc, builder: c.builder}
+	a := &atomicCache{next: ac, builder: c.builder}
+	v := &casCache{next: cc, builder: c.builder}
+	switch {
+	case tagged && atomic && cas:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*atomicCache
+			*casCache
+		}{c, t, a, v}
+	case tagged && atomic:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*atomicCache
+		}{c, t, a}
+	case tagged && cas:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*casCache
+		}{c, t, v}
+	case atomic && cas:
+		return &struct {
+			*filterCache
+			*atomicCache
+			*casCache
+		}{c, a, v}
+	case tagged:
+		return &struct {
+			*filterCache
+			*taggedCache
+		}{c, t}
+	case atomic:
+		return &struct {
+			*filterCache
+			*atomicCache
+		}{c, a}
+	case cas:
+		return &struct {
+			*filterCache
+			*casCache
+		}{c, v}
+	}
+	return c
+}
+
+// taggedCache traces the methods of cache.TaggedCache
+type taggedCache struct {
+	next    cache.TaggedCache
+	builder *OtelFilterChainBuilder
+}
+
+func (c *taggedCache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	spanCtx, span := c.builder.start(ctx, "PutWithTags", key)
+	err := c.next.PutWithTags(spanCtx, key, val, timeout, tags...)
+	c.builder.end(ctx, span, "PutWithTags", []string{key}, err)
+	return err
+}
+
+func (c *taggedCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	spanCtx, span := c.builder.start(ctx, "InvalidateTag")
+	err := c.next.InvalidateTag(spanCtx, tags...)
+	c.builder.end(ctx, span, "InvalidateTag", nil, err)
+	return err
+}
+
+func (c *taggedCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	spanCtx, span := c.builder.start(ctx, "DeleteByPrefix", prefix)
+	err := c.next.DeleteByPrefix(spanCtx, prefix)
+	c.builder.end(ctx, span, "DeleteByPrefix", []string{prefix}, err)
+	return err
+}
+
+// atomicCache traces the methods of cache.AtomicCache
+type atomicCache struct {
+	next    cache.AtomicCache
+	builder *OtelFilterChainBuilder
+}
+
+func (c *atomicCache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	spanCtx, span := c.builder.start(ctx, "IncrBy", key)
+	res, err := c.next.IncrBy(spanCtx, key, delta)
+	c.builder.end(ctx, span, "IncrBy", []string{key}, err)
+	return res, err
+}
+
+func (c *atomicCache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	spanCtx, span := c.builder.start(ctx, "GetAndSet", key)
+	old, err := c.next.GetAndSet(spanCtx, key, val, timeout)
+	c.builder.end(ctx, span, "GetAndSet", []string{key}, err)
+	return old, err
+}
+
+// casCache traces the methods of cache.CASCache
+type casCache struct {
+	next    cache.CASCache
+	builder *OtelFilterChainBuilder
+}
+
+func (c *casCache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	spanCtx, span := c.builder.start(ctx, "GetWithVersion", key)
+	val, version, err := c.next.GetWithVersion(spanCtx, key)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil && val != nil))
+	c.builder.end(ctx, span, "GetWithVersion", []string{key}, err)
+	return val, version, err
+}
+
+func (c *casCache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	spanCtx, span := c.builder.start(ctx, "CompareAndSwap", key)
+	ok, err := c.next.CompareAndSwap(spanCtx, key, version, val, timeout)
+	c.builder.end(ctx, span, "CompareAndSwap", []string{key}, err)
+	return ok, err
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jialequ/android-sdk/client/cache"
+)
+
+func TestFilterChainBuilderFilterChain(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	var methods []string
+	bm := NewOpenTelemetryFilter("memory", func(span trace.Span, ctx context.Context, method string, keys []string, err error) {
+		methods = append(methods, method)
+	}).FilterChain(cache.NewMemoryCache())
+
+	ctx := context.Background()
+	assert.Nil(t, bm.Put(ctx, "user:1", "Tom", time.Minute))
+	_, err := bm.Get(ctx, "user:2")
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"Put", "Get"}, methods)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 2, len(spans))
+	assert.Equal(t, "cache#Get", spans[1].Name())
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[1].Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "memory", attrs["cache.adapter"].AsString())
+	assert.Equal(t, "user", attrs["cache.key_prefix"].AsString())
+	assert.False(t, attrs["cache.hit"].AsBool())
+	// the missing key is not an error
+	_, ok := attrs["error"]
+	assert.False(t, ok)
+}
+
+func TestKeysPrefix(t *testing.T) {
+	assert.Equal(t, "user", keysPrefix([]string{"user:1", "user:2"}))
+	assert.Equal(t, "*", keysPrefix([]string{"user:1", "order:2"}))
+}
+
+func TestFilterChainBuilderOptionalInterfaces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	bm := NewOpenTelemetryFilter("memory", nil).FilterChain(cache.NewMemoryCache())
+	ctx := context.Background()
+
+	tc, ok := bm.(cache.TaggedCache)
+	assert.True(t, ok)
+	assert.Nil(t, tc.PutWithTags(ctx, "user:1", "Tom", time.Minute, "user"))
+
+	ac, ok := bm.(cache.AtomicCache)
+	assert.True(t, ok)
+	_, err := ac.IncrBy(ctx, "counter:1", 3)
+	assert.Nil(t, err)
+
+	cc, ok := bm.(cache.CASCache)
+	assert.True(t, ok)
+	_, _, err = cc.GetWithVersion(ctx, "user:1")
+	assert.Nil(t, err)
+
+	spans := recorder.Ended()
+	assert.Equal(t, 3, len(spans))
+	assert.Equal(t, "cache#PutWithTags", spans[0].Name())
+	assert.Equal(t, "cache#IncrBy", spans[1].Name())
+	assert.Equal(t, "cache#GetWithVersion", spans[2].Name())
+
+	// the plain cache keeps plain
+	bm = NewOpenTelemetryFilter("memory", nil).FilterChain(struct{ cache.Cache }{cache.NewMemoryCache()})
+	_, ok = bm.(cache.TaggedCache)
+	assert.False(t, ok)
+	_, ok = bm.(cache.AtomicCache)
+	assert.False(t, ok)
+	_, ok = bm.(cache.CASCache)
+	assert.False(t, ok)
+}
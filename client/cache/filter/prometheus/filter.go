@@ -0,0 +1,314 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/jialequ/android-sdk/client/cache"
+)
+
+// FilterChainBuilder wraps a cache.Cache and records
+// the latency of each operation and the hits and misses of Get and GetMulti.
+// The optional interfaces of the wrapped cache, such as cache.TaggedCache, are kept.
+//
+// Usage:
+//
+//	bm, _ := cache.NewCache("redis", `{"conn":"127.0.0.1:6379"}`)
+//	builder := &prometheus.FilterChainBuilder{AppName: "app", Adapter: "redis"}
+//	bm = builder.FilterChain(bm)
+type FilterChainBuilder struct {
+	AppName    string
+	ServerName string
+	RunMode    string
+	// Adapter is the label value of the wrapped adapter, for example "redis"
+	Adapter string
+	// KeyPrefixFunc extracts the label value from the key.
+	// By default, it is the part before the first ':'.
+	// Keep the number of prefixes small, or there will be too many metrics.
+	// The latency of GetMulti is labelled with the prefix shared by all keys, or "*" if they differ.
+	KeyPrefixFunc func(key string) string
+}
+
+var (
+	histogramVec prometheus.ObserverVec
+	lookupVec    *prometheus.CounterVec
+	initVec      sync.Once
+)
+
+func (builder *FilterChainBuilder) FilterChain(next cache.Cache) cache.Cache {
+	initVec.Do(func() {
+		constLabels := map[string]string{
+			"server":  builder.ServerName,
+			"env":     builder.RunMode,
+			"appname": builder.AppName,
+		}
+		histogramVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "beego",
+			Subsystem:   "cache_operation",
+			ConstLabels: constLabels,
+			Help:        "The latency of cache operations in milliseconds",
+			Buckets:     []float64{0.1, 0.5, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+		}, []string{"adapter", "method", "prefix", "isError"})
+		lookupVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "beego",
+			Subsystem:   "cache_lookup",
+			ConstLabels: constLabels,
+			Help:        "The hits and misses of cache lookups",
+		}, []string{"adapter", "prefix", "result"})
+		prometheus.MustRegister(histogramVec, lookupVec)
+	})
+	return wrap(&filterCache{Cache: next, builder: builder}, next)
+}
+
+// report records the latency of method, cache.ErrKeyNotExist is a miss but not an error
+func (builder *FilterChainBuilder) report(method, key string, startTime time.Time, err error) {
+	builder.reportPrefix(method, builder.keyPrefix(key), startTime, err)
+}
+
+func (builder *FilterChainBuilder) reportPrefix(method, prefix string, startTime time.Time, err error) {
+	dur := float64(time.Since(startTime)) / float64(time.Millisecond)
+	isError := err != nil && !errors.Is(err, cache.ErrKeyNotExist)
+	histogramVec.WithLabelValues(builder.Adapter, method, prefix,
+		strconv.FormatBool(isError)).Observe(dur)
+}
+
+func (builder *FilterChainBuilder) reportLookup(key string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	lookupVec.WithLabelValues(builder.Adapter, builder.keyPrefix(key), result).Inc()
+}
+
+func (builder *FilterChainBuilder) keyPrefix(key string) string {
+	if builder.KeyPrefixFunc != nil {
+		return builder.KeyPrefixFunc(key)
+	}
+	if idx := strings.IndexByte(key, ':'); idx >= 0 {
+		return key[:idx]
+	}
+	return ""
+}
+
+// keysPrefix returns the prefix shared by keys, or "*" if they differ
+func (builder *FilterChainBuilder) keysPrefix(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	prefix := builder.keyPrefix(keys[0])
+	for _, key := range keys[1:] {
+		if builder.keyPrefix(key) != prefix {
+			return "*"
+		}
+	}
+	return prefix
+}
+
+type filterCache struct {
+	cache.Cache
+	builder *FilterChainBuilder
+}
+
+func (c *filterCache) Get(ctx context.Context, key string) (interface{}, error) {
+	startTime := time.Now()
+	val, err := c.Cache.Get(ctx, key)
+	c.builder.report("Get", key, startTime, err)
+	c.builder.reportLookup(key, err == nil && val != nil)
+	return val, err
+}
+
+func (c *filterCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	startTime := time.Now()
+	vals, err := c.Cache.GetMulti(ctx, keys)
+	c.builder.reportPrefix("GetMulti", c.builder.keysPrefix(keys), startTime, err)
+	for i, key := range keys {
+		c.builder.reportLookup(key, i < len(vals) && vals[i] != nil)
+	}
+	return vals, err
+}
+
+func (c *filterCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	startTime := time.Now()
+	err := c.Cache.Put(ctx, key, val, timeout)
+	c.builder.report("Put", key, startTime, err)
+	return err
+}
+
+func (c *filterCache) Delete(ctx context.Context, key string) error {
+	startTime := time.Now()
+	err := c.Cache.Delete(ctx, key)
+	c.builder.report("Delete", key, startTime, err)
+	return err
+}
+
+func (c *filterCache) Incr(ctx context.Context, key string) error {
+	startTime := time.Now()
+	err := c.Cache.Incr(ctx, key)
+	c.builder.report("Incr", key, startTime, err)
+	return err
+}
+
+func (c *filterCache) Decr(ctx context.Context, key string) error {
+	startTime := time.Now()
+	err := c.Cache.Decr(ctx, key)
+	c.builder.report("Decr", key, startTime, err)
+	return err
+}
+
+func (c *filterCache) IsExist(ctx context.Context, key string) (bool, error) {
+	startTime := time.Now()
+	res, err := c.Cache.IsExist(ctx, key)
+	c.builder.report("IsExist", key, startTime, err)
+	return res, err
+}
+
+func (c *filterCache) ClearAll(ctx context.Context) error {
+	startTime := time.Now()
+	err := c.Cache.ClearAll(ctx)
+	c.builder.report("ClearAll", "", startTime, err)
+	return err
+}
+
+// wrap returns c with the optional interfaces of next, such as cache.TaggedCache,
+// so that the type assertions on the filtered cache still work.
+func wrap(c *filterCache, next cache.Cache) cache.Cache {
+	tc, tagged := next.(cache.TaggedCache)
+	ac, atomic := next.(cache.AtomicCache)
+	cc, cas := next.(cache.CASCache)
+	t := &taggedCache{next: tc, builder: c.builder}
+	a := &atomicCache{next: ac, builder: c.builder}
+	v := &casCache{next: cc, builder: c.builder}
+	switch {
+	case tagged && atomic && cas:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*atomicCache
+			*casCache
+		}{c, t, a, v}
+	case tagged && atomic:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*atomicCache
+		}{c, t, a}
+	case tagged && cas:
+		return &struct {
+			*filterCache
+			*taggedCache
+			*casCache
+		}{c, t, v}
+	case atomic && cas:
+		return &struct {
+			*filterCache
+			*atomicCache
+			*casCache
+		}{c, a, v}
+	case tagged:
+		return &struct {
+			*filterCache
+			*taggedCache
+		}{c, t}
+	case atomic:
+		return &struct {
+			*filterCache
+			*atomicCache
+		}{c, a}
+	case cas:
+		return &struct {
+			*filterCache
+			*casCache
+		}{c, v}
+	}
+	return c
+}
+
+// taggedCache records the methods of cache.TaggedCache
+type taggedCache struct {
+	next    cache.TaggedCache
+	builder *FilterChainBuilder
+}
+
+func (c *taggedCache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	startTime := time.Now()
+	err := c.next.PutWithTags(ctx, key, val, timeout, tags...)
+	c.builder.report("PutWithTags", key, startTime, err)
+	return err
+}
+
+func (c *taggedCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	startTime := time.Now()
+	err := c.next.InvalidateTag(ctx, tags...)
+	c.builder.report("InvalidateTag", "", startTime, err)
+	return err
+}
+
+func (c *taggedCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	startTime := time.Now()
+	err := c.next.DeleteByPrefix(ctx, prefix)
+	c.builder.report("DeleteByPrefix", prefix, startTime, err)
+	return err
+}
+
+// atomicCache records the methods of cache.AtomicCache
+type atomicCache struct {
+	next    cache.AtomicCache
+	builder *FilterChainBuilder
+}
+
+func (c *atomicCache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	startTime := time.Now()
+	res, err := c.next.IncrBy(ctx, key, delta)
+	c.builder.report("IncrBy", key, startTime, err)
+	return res, err
+}
+
+func (c *atomicCache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	startTime := time.Now()
+	old, err := c.next.GetAndSet(ctx, key, val, timeout)
+	c.builder.report("GetAndSet", key, startTime, err)
+	return old, err
+}
+
+// casCache records the methods of cache.CASCache
+type casCache struct {
+	next    cache.CASCache
+	builder *FilterChainBuilder
+}
+
+func (c *casCache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	startTime := time.Now()
+	val, version, err := c.next.GetWithVersion(ctx, key)
+	c.builder.report("GetWithVersion", key, startTime, err)
+	c.builder.reportLookup(key, err == nil && val != nil)
+	return val, version, err
+}
+
+func (c *casCache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	startTime := time.Now()
+	ok, err := c.next.CompareAndSwap(ctx, key, version, val, timeout)
+	c.builder.report("CompareAndSwap", key, startTime, err)
+	return ok, err
+}
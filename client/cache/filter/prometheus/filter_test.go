@@ -0,0 +1,98 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/cache"
+)
+
+func TestFilterChainBuilderFilterChain(t *testing.T) {
+	builder := &FilterChainBuilder{Adapter: "memory"}
+	bm := builder.FilterChain(cache.NewMemoryCache())
+	assert.NotNil(t, histogramVec)
+	assert.NotNil(t, lookupVec)
+
+	ctx := context.Background()
+	assert.Nil(t, bm.Put(ctx, "user:1", "Tom", time.Minute))
+	val, err := bm.Get(ctx, "user:1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Tom", val)
+	_, err = bm.Get(ctx, "user:2")
+	assert.NotNil(t, err)
+	_, _ = bm.GetMulti(ctx, []string{"user:1", "user:3"})
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(lookupVec.WithLabelValues("memory", "user", "hit")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(lookupVec.WithLabelValues("memory", "user", "miss")))
+	// the missing key is a miss but not an error, so the two Get are in one series
+	assert.Equal(t, 3, testutil.CollectAndCount(histogramVec.(*prometheus.HistogramVec)))
+	assert.False(t, histogramVec.(*prometheus.HistogramVec).DeleteLabelValues("memory", "Get", "user", "true"))
+}
+
+func TestFilterChainBuilderKeyPrefix(t *testing.T) {
+	builder := &FilterChainBuilder{}
+	assert.Equal(t, "user", builder.keyPrefix("user:1:profile"))
+	assert.Equal(t, "", builder.keyPrefix("user"))
+	builder.KeyPrefixFunc = func(key string) string {
+		return "all"
+	}
+	assert.Equal(t, "all", builder.keyPrefix("user:1"))
+
+	builder.KeyPrefixFunc = nil
+	assert.Equal(t, "user", builder.keysPrefix([]string{"user:1", "user:2"}))
+	assert.Equal(t, "*", builder.keysPrefix([]string{"user:1", "order:2"}))
+}
+
+func TestFilterChainBuilderOptionalInterfaces(t *testing.T) {
+	builder := &FilterChainBuilder{Adapter: "memory"}
+	bm := builder.FilterChain(cache.NewMemoryCache())
+	ctx := context.Background()
+
+	tc, ok := bm.(cache.TaggedCache)
+	assert.True(t, ok)
+	assert.Nil(t, tc.PutWithTags(ctx, "user:1", "Tom", time.Minute, "user"))
+	assert.Nil(t, tc.InvalidateTag(ctx, "user"))
+
+	ac, ok := bm.(cache.AtomicCache)
+	assert.True(t, ok)
+	val, err := ac.IncrBy(ctx, "counter:1", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), val)
+
+	cc, ok := bm.(cache.CASCache)
+	assert.True(t, ok)
+	assert.Nil(t, cc.Put(ctx, "user:2", "Jerry", time.Minute))
+	_, ver, err := cc.GetWithVersion(ctx, "user:2")
+	assert.Nil(t, err)
+	swapped, err := cc.CompareAndSwap(ctx, "user:2", ver, "Tom", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, swapped)
+
+	// the plain cache keeps plain
+	bm = builder.FilterChain(struct{ cache.Cache }{cache.NewMemoryCache()})
+	_, ok = bm.(cache.TaggedCache)
+	assert.False(t, ok)
+	_, ok = bm.(cache.AtomicCache)
+	assert.False(t, ok)
+	_, ok = bm.(cache.CASCache)
+	assert.False(t, ok)
+}
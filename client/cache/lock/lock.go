@@ -0,0 +1,259 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lock provides lease based distributed locks on top of the cache adapters.
+//
+// A lock is held for a TTL and renewed in background until it is unlocked,
+// so a crashed owner never blocks the others longer than one TTL.
+// Each acquisition gets a fencing token which increases monotonically per key,
+// and the protected resource should reject writes carrying an older token.
+// The memcache backend can not keep the tokens monotonic, and its tokens are always 0.
+//
+// Usage:
+//
+//	bm, _ := cache.NewCache("redis", `{"conn":"127.0.0.1:6379"}`)
+//	locker := lock.NewLocker(redis.NewLockBackend(bm.(*redis.Cache)), lock.WithTTL(10*time.Second))
+//	l, err := locker.Lock(ctx, "payment:42")
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Unlock(context.Background())
+//	// do the work with l.Token() as fencing token, and stop when <-l.Done()
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// DefaultTTL is the lease of a lock when WithTTL is not used
+const DefaultTTL = 30 * time.Second
+
+// DefaultRetryInterval is the interval between two attempts of Locker.Lock
+const DefaultRetryInterval = 100 * time.Millisecond
+
+var (
+	ErrNotAcquired = berror.Error(cache.LockNotAcquired, "the lock is held by another owner")
+	ErrNotHeld     = berror.Error(cache.LockNotHeld, "the lock is not held by the owner")
+)
+
+// Backend stores the leases.
+// All methods must be atomic, and the owner must be checked by the backend itself.
+type Backend interface {
+	// Acquire takes the lock of key for ttl if nobody holds it.
+	// It returns the fencing token and true if the lock is acquired.
+	// The token is 0 if the backend can not keep it monotonic.
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (uint64, bool, error)
+	// Renew extends the lease of key to ttl if owner still holds it.
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release deletes the lock of key if owner still holds it.
+	Release(ctx context.Context, key, owner string) (bool, error)
+}
+
+// Locker creates locks on a Backend
+type Locker struct {
+	backend       Backend
+	ttl           time.Duration
+	retryInterval time.Duration
+	autoRenew     bool
+}
+
+// Option configures a Locker created by NewLocker
+type Option func(*Locker)
+
+// WithTTL sets the lease of locks
+func WithTTL(ttl time.Duration) Option {
+	return func(l *Locker) {
+		if ttl > 0 {
+			l.ttl = ttl
+		}
+	}
+}
+
+// WithRetryInterval sets the interval between two attempts of Locker.Lock
+func WithRetryInterval(interval time.Duration) Option {
+	return func(l *Locker) {
+		if interval > 0 {
+			l.retryInterval = interval
+		}
+	}
+}
+
+// WithAutoRenew enables or disables renewing the lease every TTL/3 in background.
+// It is enabled by default. If disabled, the lock is lost after TTL unless Lock.Refresh is called.
+func WithAutoRenew(enabled bool) Option {
+	return func(l *Locker) {
+		l.autoRenew = enabled
+	}
+}
+
+// NewLocker creates a Locker
+func NewLocker(backend Backend, opts ...Option) *Locker {
+	l := &Locker{
+		backend:       backend,
+		ttl:           DefaultTTL,
+		retryInterval: DefaultRetryInterval,
+		autoRenew:     true,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// TryLock acquires the lock of key, or returns ErrNotAcquired immediately if it is held by others.
+// ctx is only used to acquire the lock. With auto-renewal enabled,
+// the lease is renewed as long as the lock lives, until Unlock is called or the lock is lost.
+func (l *Locker) TryLock(ctx context.Context, key string) (*Lock, error) {
+	owner := uuid.NewString()
+	token, ok, err := l.backend.Acquire(ctx, key, owner, l.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+	res := &Lock{
+		key:     key,
+		owner:   owner,
+		token:   token,
+		ttl:     l.ttl,
+		backend: l.backend,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if l.autoRenew {
+		go res.renew()
+	}
+	return res, nil
+}
+
+// Lock acquires the lock of key, waiting until it is released by others or ctx is done.
+// The returned error is ctx.Err() if ctx is done before the lock is acquired.
+func (l *Locker) Lock(ctx context.Context, key string) (*Lock, error) {
+	for {
+		res, err := l.TryLock(ctx, key)
+		if err != ErrNotAcquired {
+			return res, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(l.retryInterval):
+		}
+	}
+}
+
+// Lock is an acquired lock
+type Lock struct {
+	key     string
+	owner   string
+	token   uint64
+	ttl     time.Duration
+	backend Backend
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	doneOnce sync.Once
+	done     chan struct{}
+}
+
+// Key returns the locked key
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token returns the fencing token of this acquisition.
+// The token of a later acquisition of the same key is greater,
+// or it's always 0 if the Backend does not support fencing tokens, such as memcache.
+func (l *Lock) Token() uint64 {
+	return l.token
+}
+
+// Done returns a channel which is closed when the lock is unlocked or lost.
+func (l *Lock) Done() <-chan struct{} {
+	return l.done
+}
+
+// Refresh extends the lease to the TTL of Locker.
+// It returns ErrNotHeld if the lock was lost.
+func (l *Lock) Refresh(ctx context.Context) error {
+	ok, err := l.backend.Renew(ctx, l.key, l.owner, l.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		l.markDone()
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Unlock stops the renewal and releases the lock.
+// It returns ErrNotHeld if the lock was lost before.
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	defer l.markDone()
+	ok, err := l.backend.Release(ctx, l.key, l.owner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) markDone() {
+	l.doneOnce.Do(func() {
+		close(l.done)
+	})
+}
+
+// renew extends the lease every TTL/3 until the lock is unlocked.
+// It does not use the ctx of acquisition, which may end before the lock.
+// Failed renewals are retried until the lease is surely expired.
+func (l *Lock) renew() {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			ok, err := l.backend.Renew(ctx, l.key, l.owner, l.ttl)
+			cancel()
+			if err == nil && !ok {
+				l.markDone()
+				return
+			}
+			if err == nil {
+				renewed = time.Now()
+			} else if time.Since(renewed) >= l.ttl {
+				l.markDone()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockerTryLock(t *testing.T) {
+	ctx := context.Background()
+	locker := NewLocker(NewMemoryBackend(), WithTTL(time.Second))
+
+	l1, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.Equal(t, "job", l1.Key())
+	_, err = locker.TryLock(ctx, "job")
+	assert.Equal(t, ErrNotAcquired, err)
+
+	other, err := locker.TryLock(ctx, "other")
+	assert.Nil(t, err)
+	assert.Nil(t, other.Unlock(ctx))
+
+	assert.Nil(t, l1.Unlock(ctx))
+	<-l1.Done()
+	assert.Equal(t, ErrNotHeld, l1.Unlock(ctx))
+
+	// the fencing token increases with each acquisition
+	l2, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.Greater(t, l2.Token(), l1.Token())
+	assert.Nil(t, l2.Unlock(ctx))
+}
+
+func TestLockerLeaseExpired(t *testing.T) {
+	ctx := context.Background()
+	locker := NewLocker(NewMemoryBackend(), WithTTL(100*time.Millisecond), WithAutoRenew(false))
+
+	l1, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.Nil(t, l1.Refresh(ctx))
+	time.Sleep(150 * time.Millisecond)
+
+	l2, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.Greater(t, l2.Token(), l1.Token())
+	assert.Equal(t, ErrNotHeld, l1.Refresh(ctx))
+	<-l1.Done()
+	assert.Equal(t, ErrNotHeld, l1.Unlock(ctx))
+	assert.Nil(t, l2.Unlock(ctx))
+}
+
+func TestLockerAutoRenew(t *testing.T) {
+	ctx := context.Background()
+	locker := NewLocker(NewMemoryBackend(), WithTTL(90*time.Millisecond))
+
+	l, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	time.Sleep(300 * time.Millisecond)
+	_, err = locker.TryLock(ctx, "job")
+	assert.Equal(t, ErrNotAcquired, err)
+	select {
+	case <-l.Done():
+		t.Error("the lock should be renewed")
+	default:
+	}
+	assert.Nil(t, l.Unlock(ctx))
+}
+
+func TestLockerLock(t *testing.T) {
+	locker := NewLocker(NewMemoryBackend(), WithTTL(time.Second), WithRetryInterval(10*time.Millisecond))
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holding int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l, err := locker.Lock(ctx, "job")
+			assert.Nil(t, err)
+			mu.Lock()
+			holding++
+			assert.Equal(t, 1, holding)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			holding--
+			mu.Unlock()
+			assert.Nil(t, l.Unlock(ctx))
+		}()
+	}
+	wg.Wait()
+
+	l, err := locker.Lock(ctx, "job")
+	assert.Nil(t, err)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = locker.Lock(timeoutCtx, "job")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, l.Unlock(ctx))
+}
+
+func TestLockerContextCancel(t *testing.T) {
+	locker := NewLocker(NewMemoryBackend(), WithTTL(90*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the lock outlives the ctx of acquisition
+	l, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	cancel()
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case <-l.Done():
+		t.Fatal("the lock should be renewed")
+	default:
+	}
+	_, err = locker.TryLock(context.Background(), "job")
+	assert.Equal(t, ErrNotAcquired, err)
+
+	assert.Nil(t, l.Unlock(context.Background()))
+	l2, err := locker.TryLock(context.Background(), "job")
+	assert.Nil(t, err)
+	assert.Nil(t, l2.Unlock(context.Background()))
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryLease struct {
+	owner   string
+	expired time.Time
+}
+
+// MemoryBackend is a Backend in process memory.
+// It is useful in tests and single instance deployments.
+type MemoryBackend struct {
+	mu     sync.Mutex
+	leases map[string]memoryLease
+	fences map[string]uint64
+}
+
+var _ Backend = (*MemoryBackend)(nil)
+
+// NewMemoryBackend creates a MemoryBackend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		leases: make(map[string]memoryLease),
+		fences: make(map[string]uint64),
+	}
+}
+
+// Acquire takes the lock of key if it is not held or the lease is expired
+func (b *MemoryBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (uint64, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if lease, ok := b.leases[key]; ok && time.Now().Before(lease.expired) {
+		return 0, false, nil
+	}
+	b.leases[key] = memoryLease{owner: owner, expired: time.Now().Add(ttl)}
+	b.fences[key]++
+	return b.fences[key], true, nil
+}
+
+// Renew extends the lease of key if owner holds it
+func (b *MemoryBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.held(key, owner) {
+		return false, nil
+	}
+	b.leases[key] = memoryLease{owner: owner, expired: time.Now().Add(ttl)}
+	return true, nil
+}
+
+// Release deletes the lock of key if owner holds it
+func (b *MemoryBackend) Release(ctx context.Context, key, owner string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.held(key, owner) {
+		return false, nil
+	}
+	delete(b.leases, key)
+	return true, nil
+}
+
+func (b *MemoryBackend) held(key, owner string) bool {
+	lease, ok := b.leases[key]
+	return ok && lease.owner == owner && time.Now().Before(lease.expired)
+}
@@ -0,0 +1,111 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+
+	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/client/cache/lock"
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// LockBackend is a lock.Backend based on memcache.
+//
+// The lease is taken by ADD and the TTL is rounded up to seconds.
+// Memcache can evict any key under memory pressure, so it can not keep a monotonic counter,
+// and the fencing tokens are not supported: the Token of the locks is always 0.
+type LockBackend struct {
+	rc *Cache
+}
+
+var _ lock.Backend = (*LockBackend)(nil)
+
+// NewLockBackend creates a LockBackend sharing the client of rc.
+// rc must be started by StartAndGC.
+func NewLockBackend(rc *Cache) *LockBackend {
+	return &LockBackend{rc: rc}
+}
+
+// Acquire takes the lock of key if it does not exist, the fencing token is always 0
+func (b *LockBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (uint64, bool, error) {
+	err := b.rc.conn.Add(&memcache.Item{
+		Key:        leaseKey(key),
+		Value:      []byte(owner),
+		Expiration: ttlSeconds(ttl),
+	})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not acquire the lock, key: %s", key)
+	}
+	return 0, true, nil
+}
+
+// Renew extends the lease of key if owner holds it
+func (b *LockBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	return b.swap(key, owner, ttlSeconds(ttl))
+}
+
+// Release deletes the lock of key if owner holds it.
+// Memcache cannot delete by CAS, so the lease is swapped to an expired one.
+func (b *LockBackend) Release(ctx context.Context, key, owner string) (bool, error) {
+	return b.swap(key, owner, -1)
+}
+
+// swap sets the expiration of the lease if owner holds it
+func (b *LockBackend) swap(key, owner string, expiration int32) (bool, error) {
+	item, err := b.rc.conn.Get(leaseKey(key))
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not read the lock, key: %s", key)
+	}
+	if string(item.Value) != owner {
+		return false, nil
+	}
+	item.Expiration = expiration
+	err = b.rc.conn.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) ||
+		errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not update the lock, key: %s", key)
+	}
+	return true, nil
+}
+
+func leaseKey(key string) string {
+	return "__lock__:" + key
+}
+
+// ttlSeconds rounds ttl up to seconds, as memcache does not support smaller expiration
+func ttlSeconds(ttl time.Duration) int32 {
+	sec := int32((ttl + time.Second - 1) / time.Second)
+	if sec < 1 {
+		sec = 1
+	}
+	return sec
+}
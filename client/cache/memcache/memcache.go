@@ -32,7 +32,9 @@ package memcache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -48,6 +50,11 @@ type Cache struct {
 	conninfo []string
 }
 
+var (
+	_ cache.AtomicCache = (*Cache)(nil)
+	_ cache.CASCache    = (*Cache)(nil)
+)
+
 // NewMemCache creates a new memcache adapter.
 func NewMemCache() cache.Cache {
 	return &Cache{}
@@ -93,17 +100,25 @@ func (rc *Cache) GetMulti(ctx context.Context, keys []string) ([]interface{}, er
 
 // Put puts a value into memcache.
 func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	item, err := newItem(key, val, timeout)
+	if err != nil {
+		return err
+	}
+	return berror.Wrapf(rc.conn.Set(item), cache.MemCacheCurdFailed,
+		"could not put key-value to memcache, key: %s", key)
+}
+
+func newItem(key string, val interface{}, timeout time.Duration) (*memcache.Item, error) {
 	item := memcache.Item{Key: key, Expiration: int32(timeout / time.Second)}
 	if v, ok := val.([]byte); ok {
 		item.Value = v
 	} else if str, ok := val.(string); ok {
 		item.Value = []byte(str)
 	} else {
-		return berror.Errorf(cache.InvalidMemCacheValue,
+		return nil, berror.Errorf(cache.InvalidMemCacheValue,
 			"the value must be string or byte[]. key: %s, value:%v", key, val)
 	}
-	return berror.Wrapf(rc.conn.Set(&item), cache.MemCacheCurdFailed,
-		"could not put key-value to memcache, key: %s", key)
+	return &item, nil
 }
 
 // Delete deletes a value in memcache.
@@ -126,6 +141,112 @@ func (rc *Cache) Decr(ctx context.Context, key string) error {
 		"could not decrease value for key: %s", key)
 }
 
+// IncrBy adds delta to the counter and returns the new value.
+// Memcache counters are unsigned: decrements stop at 0,
+// and a missing key is created with delta, or 0 if delta is negative.
+func (rc *Cache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	for {
+		var (
+			val uint64
+			err error
+		)
+		if delta >= 0 {
+			val, err = rc.conn.Increment(key, uint64(delta))
+		} else {
+			val, err = rc.conn.Decrement(key, uint64(-delta))
+		}
+		if err == nil {
+			return int64(val), nil
+		}
+		if !errors.Is(err, memcache.ErrCacheMiss) {
+			return 0, berror.Wrapf(err, cache.MemCacheCurdFailed,
+				"could not increase value for key: %s", key)
+		}
+		initial := delta
+		if initial < 0 {
+			initial = 0
+		}
+		err = rc.conn.Add(&memcache.Item{Key: key, Value: []byte(strconv.FormatInt(initial, 10))})
+		if err == nil {
+			return initial, nil
+		}
+		// created by others, increase it again
+		if !errors.Is(err, memcache.ErrNotStored) {
+			return 0, berror.Wrapf(err, cache.MemCacheCurdFailed,
+				"could not create counter for key: %s", key)
+		}
+	}
+}
+
+// GetAndSet sets the value of key and returns the old value.
+// It retries with CAS until no one else changes the key in between.
+func (rc *Cache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	newIt, err := newItem(key, val, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		item, err := rc.conn.Get(key)
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			err = rc.conn.Add(newIt)
+			if err == nil {
+				return nil, nil
+			}
+			if errors.Is(err, memcache.ErrNotStored) {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, berror.Wrapf(err, cache.MemCacheCurdFailed,
+				"could not get and set key-value, key: %s", key)
+		}
+		old := item.Value
+		item.Value = newIt.Value
+		item.Expiration = newIt.Expiration
+		err = rc.conn.CompareAndSwap(item)
+		if err == nil {
+			return old, nil
+		}
+		if !errors.Is(err, memcache.ErrCASConflict) && !errors.Is(err, memcache.ErrNotStored) {
+			return nil, berror.Wrapf(err, cache.MemCacheCurdFailed,
+				"could not get and set key-value, key: %s", key)
+		}
+	}
+}
+
+// GetWithVersion gets value from memcache and its version,
+// which is the CAS id of memcache and changes on every update of key.
+func (rc *Cache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	item, err := rc.conn.Get(key)
+	if err != nil {
+		return nil, 0, berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not read data from memcache, key: %s", key)
+	}
+	return item.Value, item.CasID, nil
+}
+
+// CompareAndSwap sets the value of key if its version is still version.
+// The version is sent as the CAS id, so that memcache checks it and swaps atomically.
+func (rc *Cache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	item, err := newItem(key, val, timeout)
+	if err != nil {
+		return false, err
+	}
+	item.CasID = version
+	err = rc.conn.CompareAndSwap(item)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) ||
+		errors.Is(err, memcache.ErrCacheMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, berror.Wrapf(err, cache.MemCacheCurdFailed,
+			"could not compare and swap, key: %s", key)
+	}
+	return true, nil
+}
+
 // IsExist checks if a value exists in memcache.
 func (rc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
 	_, err := rc.Get(ctx, key)
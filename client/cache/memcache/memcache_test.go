@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/client/cache/lock"
 	"github.com/jialequ/android-sdk/core/berror"
 )
 
@@ -114,6 +115,88 @@ func TestMemcacheCache(t *testing.T) {
 	// test clear all
 }
 
+func TestAtomicCache(t *testing.T) {
+	addr := os.Getenv("MEMCACHE_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:11211"
+	}
+
+	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ac, ok := bm.(cache.AtomicCache)
+	assert.True(t, ok)
+	cc, ok := bm.(cache.CASCache)
+	assert.True(t, ok)
+	ctx := context.Background()
+	timeoutDuration := 10 * time.Second
+
+	val, err := ac.IncrBy(ctx, "counter", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), val)
+	// memcache counters are unsigned and stop at 0
+	val, err = ac.IncrBy(ctx, "counter", -7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), val)
+
+	old, err := ac.GetAndSet(ctx, "key", "v1", timeoutDuration)
+	assert.Nil(t, err)
+	assert.Nil(t, old)
+	old, err = ac.GetAndSet(ctx, "key", "v2", timeoutDuration)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("v1"), old)
+
+	assert.Nil(t, cc.Put(ctx, "cas", "v1", timeoutDuration))
+	_, ver, err := cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v2", timeoutDuration)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v3", timeoutDuration)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// A -> B -> A changes the version too
+	_, ver, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	assert.Nil(t, cc.Put(ctx, "cas", "v1", timeoutDuration))
+	assert.Nil(t, cc.Put(ctx, "cas", "v2", timeoutDuration))
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v3", timeoutDuration)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	v, _ := bm.Get(ctx, "cas")
+	assert.Equal(t, []byte("v2"), v)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestLockBackend(t *testing.T) {
+	addr := os.Getenv("MEMCACHE_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:11211"
+	}
+
+	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	locker := lock.NewLocker(NewLockBackend(bm.(*Cache)), lock.WithTTL(2*time.Second))
+
+	l1, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	_, err = locker.TryLock(ctx, "job")
+	assert.Equal(t, lock.ErrNotAcquired, err)
+	assert.Nil(t, l1.Refresh(ctx))
+	assert.Nil(t, l1.Unlock(ctx))
+	assert.Equal(t, lock.ErrNotHeld, l1.Unlock(ctx))
+
+	l2, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	// memcache does not support fencing tokens
+	assert.Equal(t, uint64(0), l2.Token())
+	assert.Nil(t, l2.Unlock(ctx))
+
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
 func TestReadThroughCacheMemcacheGet(t *testing.T) {
 	bm, err := cache.NewCache("memcache", fmt.Sprintf(`{"conn": "%s"}`, "127.0.0.1:11211"))
 	assert.Nil(t, err)
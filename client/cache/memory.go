@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jialequ/android-sdk/core/berror"
@@ -33,6 +34,9 @@ type MemoryItem struct {
 	val         interface{}
 	createdTime time.Time
 	lifespan    time.Duration
+	size        int64
+	tags        []string
+	version     uint64
 }
 
 func (mi *MemoryItem) isExpire() bool {
@@ -45,16 +49,88 @@ func (mi *MemoryItem) isExpire() bool {
 
 // MemoryCache is a memory cache adapter.
 // Contains a RW locker for safe map storage.
+// If MaxEntries or MaxBytes is set, items are evicted by the EvictionPolicy
+// once the cache exceeds the limit.
 type MemoryCache struct {
 	sync.RWMutex
 	dur   time.Duration
 	items map[string]*MemoryItem
 	Every int // run an expiration check Every clock time
+
+	MaxEntries int   // max number of items, 0 means no limit
+	MaxBytes   int64 // max estimated size of items, 0 means no limit
+
+	// policyLock serializes the calls of policy because Get only holds the read lock
+	policyLock sync.Mutex
+	policy     EvictionPolicy
+	sizer      func(key string, val interface{}) int64
+	bytes      int64
+	evictions  uint64
+
+	// tagIndex maps a tag to the keys put with it
+	tagIndex map[string]map[string]struct{}
+	// versionSeq is the last version assigned to the items, see CompareAndSwap
+	versionSeq uint64
+}
+
+// MemoryCacheOption configures a MemoryCache created by NewMemoryCacheWithOptions
+type MemoryCacheOption func(*MemoryCache)
+
+// WithMemoryMaxEntries limits the number of items of the memory cache
+func WithMemoryMaxEntries(n int) MemoryCacheOption {
+	return func(bc *MemoryCache) {
+		bc.MaxEntries = n
+	}
+}
+
+// WithMemoryMaxBytes limits the estimated size of the items of the memory cache.
+// The size of an item is computed by the sizer, see WithMemorySizer
+func WithMemoryMaxBytes(n int64) MemoryCacheOption {
+	return func(bc *MemoryCache) {
+		bc.MaxBytes = n
+	}
+}
+
+// WithMemoryEvictionPolicy sets the policy used to pick the items to evict.
+// The default policy is LRU.
+func WithMemoryEvictionPolicy(p EvictionPolicy) MemoryCacheOption {
+	return func(bc *MemoryCache) {
+		bc.policy = p
+	}
+}
+
+// WithMemorySizer sets the function which estimates the size of an item in bytes
+func WithMemorySizer(fn func(key string, val interface{}) int64) MemoryCacheOption {
+	return func(bc *MemoryCache) {
+		bc.sizer = fn
+	}
+}
+
+// MemoryCacheStats is a snapshot of the usage of a MemoryCache
+type MemoryCacheStats struct {
+	Entries   int
+	Bytes     int64
+	Evictions uint64
 }
 
 // NewMemoryCache returns a new MemoryCache.
 func NewMemoryCache() Cache {
-	cache := MemoryCache{items: make(map[string]*MemoryItem)}
+	return NewMemoryCacheWithOptions()
+}
+
+// NewMemoryCacheWithOptions returns a new MemoryCache configured by opts,
+// like the max entries or bytes and the eviction policy.
+func NewMemoryCacheWithOptions(opts ...MemoryCacheOption) Cache {
+	cache := MemoryCache{
+		items: make(map[string]*MemoryItem),
+		sizer: defaultMemorySizer,
+	}
+	for _, opt := range opts {
+		opt(&cache)
+	}
+	if cache.policy == nil && cache.bounded() {
+		cache.policy = NewLRUPolicy()
+	}
 	return &cache
 }
 
@@ -67,6 +143,11 @@ func (bc *MemoryCache) Get(ctx context.Context, key string) (interface{}, error)
 		if itm.isExpire() {
 			return nil, ErrKeyExpired
 		}
+		if bc.policy != nil {
+			bc.policyLock.Lock()
+			bc.policy.Access(key)
+			bc.policyLock.Unlock()
+		}
 		return itm.val, nil
 	}
 	return nil, ErrKeyNotExist
@@ -98,10 +179,48 @@ func (bc *MemoryCache) GetMulti(ctx context.Context, keys []string) ([]interface
 func (bc *MemoryCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
 	bc.Lock()
 	defer bc.Unlock()
-	bc.items[key] = &MemoryItem{
+	bc.setItem(key, &MemoryItem{
+		val:         val,
+		createdTime: time.Now(),
+		lifespan:    timeout,
+	})
+	return nil
+}
+
+// PutWithTags puts cache into memory and associates it with tags,
+// so that it can be deleted by InvalidateTag.
+func (bc *MemoryCache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	bc.setItem(key, &MemoryItem{
 		val:         val,
 		createdTime: time.Now(),
 		lifespan:    timeout,
+		tags:        tags,
+	})
+	return nil
+}
+
+// InvalidateTag deletes all caches associated with any of tags.
+func (bc *MemoryCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	for _, tag := range tags {
+		for key := range bc.tagIndex[tag] {
+			bc.removeItem(key)
+		}
+	}
+	return nil
+}
+
+// DeleteByPrefix deletes all caches whose key starts with prefix.
+func (bc *MemoryCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	bc.Lock()
+	defer bc.Unlock()
+	for key := range bc.items {
+		if strings.HasPrefix(key, prefix) {
+			bc.removeItem(key)
+		}
 	}
 	return nil
 }
@@ -111,7 +230,7 @@ func (bc *MemoryCache) Put(ctx context.Context, key string, val interface{}, tim
 func (bc *MemoryCache) Delete(ctx context.Context, key string) error {
 	bc.Lock()
 	defer bc.Unlock()
-	delete(bc.items, key)
+	bc.removeItem(key)
 	return nil
 }
 
@@ -129,7 +248,7 @@ func (bc *MemoryCache) Incr(ctx context.Context, key string) error {
 	if err != nil {
 		return err
 	}
-	itm.val = val
+	bc.updateVal(key, itm, val)
 	return nil
 }
 
@@ -146,10 +265,85 @@ func (bc *MemoryCache) Decr(ctx context.Context, key string) error {
 	if err != nil {
 		return err
 	}
-	itm.val = val
+	bc.updateVal(key, itm, val)
 	return nil
 }
 
+// IncrBy adds delta to the counter in memory and returns the new value.
+// If the key does not exist or is expired, it is created as int64 without expiration.
+func (bc *MemoryCache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	itm, ok := bc.items[key]
+	if !ok || itm.isExpire() {
+		bc.setItem(key, &MemoryItem{
+			val:         delta,
+			createdTime: time.Now(),
+		})
+		return delta, nil
+	}
+
+	val, err := incrBy(itm.val, delta)
+	if err != nil {
+		return 0, err
+	}
+	res, err := toInt64(val)
+	if err != nil {
+		return 0, err
+	}
+	bc.updateVal(key, itm, val)
+	return res, nil
+}
+
+// GetAndSet puts cache into memory and returns the old value.
+func (bc *MemoryCache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	var old interface{}
+	if itm, ok := bc.items[key]; ok && !itm.isExpire() {
+		old = itm.val
+	}
+	bc.setItem(key, &MemoryItem{
+		val:         val,
+		createdTime: time.Now(),
+		lifespan:    timeout,
+	})
+	return old, nil
+}
+
+// GetWithVersion returns cache from memory and its version.
+func (bc *MemoryCache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	bc.RLock()
+	defer bc.RUnlock()
+	itm, ok := bc.items[key]
+	if !ok {
+		return nil, 0, ErrKeyNotExist
+	}
+	if itm.isExpire() {
+		return nil, 0, ErrKeyExpired
+	}
+	return itm.val, itm.version, nil
+}
+
+// CompareAndSwap puts cache into memory if the version of the existing item is version.
+func (bc *MemoryCache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	bc.Lock()
+	defer bc.Unlock()
+	itm, ok := bc.items[key]
+	if !ok || itm.isExpire() || itm.version != version {
+		return false, nil
+	}
+	bc.setItem(key, &MemoryItem{
+		val:         val,
+		createdTime: time.Now(),
+		lifespan:    timeout,
+		tags:        itm.tags,
+	})
+	return true, nil
+}
+
 // IsExist checks if cache exists in memory.
 func (bc *MemoryCache) IsExist(ctx context.Context, key string) (bool, error) {
 	bc.RLock()
@@ -164,27 +358,215 @@ func (bc *MemoryCache) IsExist(ctx context.Context, key string) (bool, error) {
 func (bc *MemoryCache) ClearAll(context.Context) error {
 	bc.Lock()
 	defer bc.Unlock()
+	if bc.policy != nil {
+		bc.policyLock.Lock()
+		for key := range bc.items {
+			bc.policy.Remove(key)
+		}
+		bc.policyLock.Unlock()
+	}
 	bc.items = make(map[string]*MemoryItem)
+	bc.tagIndex = nil
+	bc.bytes = 0
 	return nil
 }
 
+// Stats returns the current usage and the number of evicted items
+func (bc *MemoryCache) Stats() MemoryCacheStats {
+	bc.RLock()
+	defer bc.RUnlock()
+	return MemoryCacheStats{
+		Entries:   len(bc.items),
+		Bytes:     bc.bytes,
+		Evictions: atomic.LoadUint64(&bc.evictions),
+	}
+}
+
 // StartAndGC starts memory cache. Checks expiration in every clock time.
+// config must be in the format {"interval":60,"maxEntries":10000,"maxBytes":0,"evictionPolicy":"lru"},
+// evictionPolicy is one of lru, lfu and tinylfu.
 func (bc *MemoryCache) StartAndGC(config string) error {
-	var cf map[string]int
+	var cf struct {
+		Interval       *int   `json:"interval"`
+		MaxEntries     int    `json:"maxEntries"`
+		MaxBytes       int64  `json:"maxBytes"`
+		EvictionPolicy string `json:"evictionPolicy"`
+	}
 	if err := json.Unmarshal([]byte(config), &cf); err != nil {
 		return berror.Wrapf(err, InvalidMemoryCacheCfg, "invalid config, please check your input: %s", config)
 	}
-	if _, ok := cf["interval"]; !ok {
-		cf = make(map[string]int)
-		cf["interval"] = DefaultEvery
+	interval := DefaultEvery
+	if cf.Interval != nil {
+		interval = *cf.Interval
+	}
+
+	bc.Lock()
+	if cf.MaxEntries > 0 {
+		bc.MaxEntries = cf.MaxEntries
+	}
+	if cf.MaxBytes > 0 {
+		bc.MaxBytes = cf.MaxBytes
 	}
-	dur := time.Duration(cf["interval"]) * time.Second
-	bc.Every = cf["interval"]
+	if cf.EvictionPolicy != "" {
+		newPolicy, ok := evictionPolicies[cf.EvictionPolicy]
+		if !ok {
+			bc.Unlock()
+			return berror.Errorf(InvalidMemoryCacheCfg, "unknown eviction policy: %s", cf.EvictionPolicy)
+		}
+		bc.setPolicy(newPolicy(bc.MaxEntries))
+	} else if bc.policy == nil && bc.bounded() {
+		bc.setPolicy(NewLRUPolicy())
+	}
+	bc.evict()
+	bc.Unlock()
+
+	dur := time.Duration(interval) * time.Second
+	bc.Every = interval
 	bc.dur = dur
 	go bc.vacuum()
 	return nil
 }
 
+func (bc *MemoryCache) bounded() bool {
+	return bc.MaxEntries > 0 || bc.MaxBytes > 0
+}
+
+// setPolicy replaces the eviction policy and registers the existing keys to it
+func (bc *MemoryCache) setPolicy(p EvictionPolicy) {
+	bc.policyLock.Lock()
+	defer bc.policyLock.Unlock()
+	bc.policy = p
+	for key := range bc.items {
+		p.Add(key)
+	}
+}
+
+// setItem stores itm and evicts items if the cache exceeds its limits.
+// The caller must hold the write lock.
+func (bc *MemoryCache) setItem(key string, itm *MemoryItem) {
+	bc.versionSeq++
+	itm.version = bc.versionSeq
+	if bc.sizer != nil {
+		itm.size = bc.sizer(key, itm.val)
+	}
+	if old, ok := bc.items[key]; ok {
+		bc.bytes -= old.size
+		bc.untag(key, old)
+	}
+	bc.items[key] = itm
+	bc.bytes += itm.size
+	bc.tag(key, itm)
+	if bc.policy == nil {
+		return
+	}
+	bc.policyLock.Lock()
+	bc.policy.Add(key)
+	bc.policyLock.Unlock()
+	bc.evict()
+}
+
+// updateVal replaces the value of an existing item, e.g. after Incr.
+// The caller must hold the write lock.
+func (bc *MemoryCache) updateVal(key string, itm *MemoryItem, val interface{}) {
+	itm.val = val
+	bc.versionSeq++
+	itm.version = bc.versionSeq
+	if bc.sizer == nil {
+		return
+	}
+	size := bc.sizer(key, val)
+	bc.bytes += size - itm.size
+	itm.size = size
+	bc.evict()
+}
+
+// removeItem deletes the item of key.
+// The caller must hold the write lock.
+func (bc *MemoryCache) removeItem(key string) {
+	itm, ok := bc.items[key]
+	if !ok {
+		return
+	}
+	delete(bc.items, key)
+	bc.bytes -= itm.size
+	bc.untag(key, itm)
+	if bc.policy != nil {
+		bc.policyLock.Lock()
+		bc.policy.Remove(key)
+		bc.policyLock.Unlock()
+	}
+}
+
+// evict removes items chosen by the policy until the cache fits into its limits.
+// The caller must hold the write lock.
+func (bc *MemoryCache) evict() {
+	if bc.policy == nil {
+		return
+	}
+	bc.policyLock.Lock()
+	defer bc.policyLock.Unlock()
+	for (bc.MaxEntries > 0 && len(bc.items) > bc.MaxEntries) ||
+		(bc.MaxBytes > 0 && bc.bytes > bc.MaxBytes) {
+		key, ok := bc.policy.Victim()
+		if !ok {
+			return
+		}
+		if itm, ok := bc.items[key]; ok {
+			delete(bc.items, key)
+			bc.bytes -= itm.size
+			bc.untag(key, itm)
+			atomic.AddUint64(&bc.evictions, 1)
+		}
+	}
+}
+
+// tag adds key to the index of its tags.
+// The caller must hold the write lock.
+func (bc *MemoryCache) tag(key string, itm *MemoryItem) {
+	if len(itm.tags) == 0 {
+		return
+	}
+	if bc.tagIndex == nil {
+		bc.tagIndex = make(map[string]map[string]struct{})
+	}
+	for _, tag := range itm.tags {
+		keys, ok := bc.tagIndex[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			bc.tagIndex[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// untag removes key from the index of its tags.
+// The caller must hold the write lock.
+func (bc *MemoryCache) untag(key string, itm *MemoryItem) {
+	for _, tag := range itm.tags {
+		keys := bc.tagIndex[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(bc.tagIndex, tag)
+		}
+	}
+}
+
+// defaultMemorySizer estimates the size of strings and byte slices by their length,
+// and counts other values as 8 bytes.
+// Use WithMemorySizer if the cache stores large structures and MaxBytes is set.
+func defaultMemorySizer(key string, val interface{}) int64 {
+	size := int64(len(key))
+	switch v := val.(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	default:
+		size += 8
+	}
+	return size
+}
+
 // check expiration.
 func (bc *MemoryCache) vacuum() {
 	bc.RLock()
@@ -225,7 +607,7 @@ func (bc *MemoryCache) clearItems(keys []string) {
 	bc.Lock()
 	defer bc.Unlock()
 	for _, key := range keys {
-		delete(bc.items, key)
+		bc.removeItem(key)
 	}
 }
 
@@ -0,0 +1,412 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/heap"
+	"container/list"
+	"hash/fnv"
+)
+
+// EvictionPolicy decides which key should be dropped when a bounded MemoryCache
+// exceeds its entry or byte budget.
+// Implementations do not need to be goroutine safe, MemoryCache serializes all calls.
+type EvictionPolicy interface {
+	// Add records a key which has just been inserted into the cache.
+	Add(key string)
+	// Access records a hit on an existing key.
+	Access(key string)
+	// Remove forgets a key which has been deleted or expired.
+	Remove(key string)
+	// Victim chooses a key to evict and forgets it.
+	// It returns false if the policy tracks no key.
+	Victim() (string, bool)
+}
+
+// eviction policy names accepted by the "evictionPolicy" config of memory cache
+const (
+	EvictionLRU     = "lru"
+	EvictionLFU     = "lfu"
+	EvictionTinyLFU = "tinylfu"
+)
+
+var evictionPolicies = map[string]func(capacity int) EvictionPolicy{
+	EvictionLRU:     func(int) EvictionPolicy { return NewLRUPolicy() },
+	EvictionLFU:     func(int) EvictionPolicy { return NewLFUPolicy() },
+	EvictionTinyLFU: NewTinyLFUPolicy,
+}
+
+// lruPolicy evicts the least recently used key.
+type lruPolicy struct {
+	ll    *list.List
+	elems map[string]*list.Element
+}
+
+// NewLRUPolicy returns a least-recently-used EvictionPolicy.
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{
+		ll:    list.New(),
+		elems: make(map[string]*list.Element),
+	}
+}
+
+func (p *lruPolicy) Add(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.ll.MoveToFront(e)
+		return
+	}
+	p.elems[key] = p.ll.PushFront(key)
+}
+
+func (p *lruPolicy) Access(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.ll.MoveToFront(e)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	if e, ok := p.elems[key]; ok {
+		p.ll.Remove(e)
+		delete(p.elems, key)
+	}
+}
+
+func (p *lruPolicy) Victim() (string, bool) {
+	e := p.ll.Back()
+	if e == nil {
+		return "", false
+	}
+	key := p.ll.Remove(e).(string)
+	delete(p.elems, key)
+	return key, true
+}
+
+// lfuEntry is a heap node of lfuPolicy.
+type lfuEntry struct {
+	key   string
+	freq  uint64
+	tick  uint64
+	index int
+}
+
+type lfuHeap []*lfuEntry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].tick < h[j].tick
+	}
+	return h[i].freq < h[j].freq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x interface{}) {
+	e := x.(*lfuEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
+
+// lfuPolicy evicts the least frequently used key,
+// ties are broken by evicting the least recently used one.
+type lfuPolicy struct {
+	h       lfuHeap
+	entries map[string]*lfuEntry
+	tick    uint64
+}
+
+// NewLFUPolicy returns a least-frequently-used EvictionPolicy.
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{entries: make(map[string]*lfuEntry)}
+}
+
+func (p *lfuPolicy) Add(key string) {
+	if _, ok := p.entries[key]; ok {
+		p.Access(key)
+		return
+	}
+	p.tick++
+	e := &lfuEntry{key: key, freq: 1, tick: p.tick}
+	heap.Push(&p.h, e)
+	p.entries[key] = e
+}
+
+func (p *lfuPolicy) Access(key string) {
+	e, ok := p.entries[key]
+	if !ok {
+		return
+	}
+	p.tick++
+	e.freq++
+	e.tick = p.tick
+	heap.Fix(&p.h, e.index)
+}
+
+func (p *lfuPolicy) Remove(key string) {
+	if e, ok := p.entries[key]; ok {
+		heap.Remove(&p.h, e.index)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) Victim() (string, bool) {
+	if p.h.Len() == 0 {
+		return "", false
+	}
+	e := heap.Pop(&p.h).(*lfuEntry)
+	delete(p.entries, e.key)
+	return e.key, true
+}
+
+// DefaultTinyLFUCapacity is the capacity used by NewTinyLFUPolicy
+// when the cache is only bounded by bytes.
+var DefaultTinyLFUCapacity = 10000
+
+const (
+	segmentWindow = iota
+	segmentProbation
+	segmentProtected
+)
+
+type tinyLFUEntry struct {
+	key     string
+	segment int
+}
+
+// tinyLFUPolicy implements W-TinyLFU:
+// new keys enter a small LRU window, and a key leaving the window is only admitted
+// into the main segmented LRU if it is used more frequently than the key it would replace.
+type tinyLFUPolicy struct {
+	sketch       *countMinSketch
+	elems        map[string]*list.Element
+	window       *list.List
+	probation    *list.List
+	protected    *list.List
+	windowCap    int
+	mainCap      int
+	protectedCap int
+}
+
+// NewTinyLFUPolicy returns a W-TinyLFU EvictionPolicy sized for capacity entries.
+// If capacity is not positive, DefaultTinyLFUCapacity is used.
+func NewTinyLFUPolicy(capacity int) EvictionPolicy {
+	if capacity <= 0 {
+		capacity = DefaultTinyLFUCapacity
+	}
+	windowCap := capacity / 100
+	if windowCap < 1 {
+		windowCap = 1
+	}
+	mainCap := capacity - windowCap
+	if mainCap < 1 {
+		mainCap = 1
+	}
+	protectedCap := mainCap * 8 / 10
+	return &tinyLFUPolicy{
+		sketch:       newCountMinSketch(capacity),
+		elems:        make(map[string]*list.Element),
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: protectedCap,
+	}
+}
+
+func (p *tinyLFUPolicy) Add(key string) {
+	if _, ok := p.elems[key]; ok {
+		p.Access(key)
+		return
+	}
+	p.sketch.increment(key)
+	p.elems[key] = p.window.PushFront(&tinyLFUEntry{key: key, segment: segmentWindow})
+	// while the main segment still has room, keys leaving the window are admitted directly
+	for p.window.Len() > p.windowCap && p.probation.Len()+p.protected.Len() < p.mainCap {
+		e := p.window.Back()
+		p.window.Remove(e)
+		p.pushProbation(e.Value.(*tinyLFUEntry))
+	}
+}
+
+func (p *tinyLFUPolicy) Access(key string) {
+	e, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	p.sketch.increment(key)
+	entry := e.Value.(*tinyLFUEntry)
+	switch entry.segment {
+	case segmentWindow:
+		p.window.MoveToFront(e)
+	case segmentProbation:
+		p.probation.Remove(e)
+		entry.segment = segmentProtected
+		p.elems[key] = p.protected.PushFront(entry)
+		if p.protected.Len() > p.protectedCap {
+			demoted := p.protected.Back()
+			p.protected.Remove(demoted)
+			p.pushProbation(demoted.Value.(*tinyLFUEntry))
+		}
+	case segmentProtected:
+		p.protected.MoveToFront(e)
+	}
+}
+
+func (p *tinyLFUPolicy) Remove(key string) {
+	e, ok := p.elems[key]
+	if !ok {
+		return
+	}
+	p.listOf(e.Value.(*tinyLFUEntry).segment).Remove(e)
+	delete(p.elems, key)
+}
+
+func (p *tinyLFUPolicy) Victim() (string, bool) {
+	mainVictim := p.probation.Back()
+	if mainVictim == nil {
+		mainVictim = p.protected.Back()
+	}
+	candidate := p.window.Back()
+	if candidate == nil || (p.window.Len() <= p.windowCap && mainVictim != nil) {
+		if mainVictim == nil {
+			return "", false
+		}
+		return p.evict(mainVictim), true
+	}
+	if mainVictim == nil {
+		return p.evict(candidate), true
+	}
+	// the window is full, so its oldest key competes with the main segment's victim
+	candidateKey := candidate.Value.(*tinyLFUEntry).key
+	victimKey := mainVictim.Value.(*tinyLFUEntry).key
+	if p.sketch.estimate(candidateKey) > p.sketch.estimate(victimKey) {
+		p.window.Remove(candidate)
+		p.pushProbation(candidate.Value.(*tinyLFUEntry))
+		return p.evict(mainVictim), true
+	}
+	return p.evict(candidate), true
+}
+
+func (p *tinyLFUPolicy) evict(e *list.Element) string {
+	entry := e.Value.(*tinyLFUEntry)
+	p.listOf(entry.segment).Remove(e)
+	delete(p.elems, entry.key)
+	return entry.key
+}
+
+func (p *tinyLFUPolicy) pushProbation(entry *tinyLFUEntry) {
+	entry.segment = segmentProbation
+	p.elems[entry.key] = p.probation.PushFront(entry)
+}
+
+func (p *tinyLFUPolicy) listOf(segment int) *list.List {
+	switch segment {
+	case segmentProbation:
+		return p.probation
+	case segmentProtected:
+		return p.protected
+	default:
+		return p.window
+	}
+}
+
+const sketchDepth = 4
+
+// countMinSketch estimates access frequencies with 4-bit counters.
+// All counters are halved once the number of increments reaches the sample size,
+// so that old popularity fades away.
+type countMinSketch struct {
+	counters   [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	// wider rows keep scans of one-hit keys from inflating the estimates
+	width := 64
+	for width < 4*capacity {
+		width <<= 1
+	}
+	s := &countMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: 10 * capacity,
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+var sketchSeeds = [sketchDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+func (s *countMinSketch) indexes(key string) [sketchDepth]uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	var idx [sketchDepth]uint64
+	for i, seed := range sketchSeeds {
+		x := (sum ^ seed) * 0x9e3779b97f4a7c15
+		x ^= x >> 32
+		idx[i] = x & s.mask
+	}
+	return idx
+}
+
+func (s *countMinSketch) increment(key string) {
+	for i, j := range s.indexes(key) {
+		if s.counters[i][j] < 15 {
+			s.counters[i][j]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	min := uint8(15)
+	for i, j := range s.indexes(key) {
+		if s.counters[i][j] < min {
+			min = s.counters[i][j]
+		}
+	}
+	return min
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUPolicy(t *testing.T) {
+	p := NewLRUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	p.Access("a")
+	p.Remove("b")
+
+	key, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "c", key)
+	key, ok = p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	_, ok = p.Victim()
+	assert.False(t, ok)
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	p.Access("a")
+	p.Access("a")
+	p.Access("c")
+
+	key, ok := p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "b", key)
+	key, ok = p.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "c", key)
+	p.Remove("a")
+	_, ok = p.Victim()
+	assert.False(t, ok)
+}
+
+func TestTinyLFUPolicy(t *testing.T) {
+	p := NewTinyLFUPolicy(10)
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("hot%d", i)
+		p.Add(key)
+		for j := 0; j < 5; j++ {
+			p.Access(key)
+		}
+	}
+	// a scan of keys seen only once should not flush the frequently used ones
+	hotEvicted := 0
+	for i := 0; i < 100; i++ {
+		p.Add(fmt.Sprintf("cold%d", i))
+		key, ok := p.Victim()
+		assert.True(t, ok)
+		if strings.HasPrefix(key, "hot") {
+			hotEvicted++
+		}
+	}
+	assert.LessOrEqual(t, hotEvicted, 1)
+
+	p.Remove("hot0")
+	cnt := 0
+	for _, ok := p.Victim(); ok; _, ok = p.Victim() {
+		cnt++
+	}
+	assert.Equal(t, 9, cnt)
+}
+
+func TestMemoryCacheMaxEntries(t *testing.T) {
+	ctx := context.Background()
+	bm := NewMemoryCacheWithOptions(WithMemoryMaxEntries(2)).(*MemoryCache)
+	assert.Nil(t, bm.Put(ctx, "a", 1, 0))
+	assert.Nil(t, bm.Put(ctx, "b", 2, 0))
+	_, err := bm.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Nil(t, bm.Put(ctx, "c", 3, 0))
+
+	_, err = bm.Get(ctx, "b")
+	assert.Equal(t, ErrKeyNotExist, err)
+	res, _ := bm.IsExist(ctx, "a")
+	assert.True(t, res)
+	res, _ = bm.IsExist(ctx, "c")
+	assert.True(t, res)
+
+	stats := bm.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
+
+func TestMemoryCacheMaxBytes(t *testing.T) {
+	ctx := context.Background()
+	bm := NewMemoryCacheWithOptions(WithMemoryMaxBytes(10),
+		WithMemorySizer(func(key string, val interface{}) int64 {
+			return int64(len(val.(string)))
+		})).(*MemoryCache)
+	assert.Nil(t, bm.Put(ctx, "a", "12345", 0))
+	assert.Nil(t, bm.Put(ctx, "b", "12345", 0))
+	assert.Equal(t, int64(10), bm.Stats().Bytes)
+
+	assert.Nil(t, bm.Put(ctx, "c", "123", 0))
+	res, _ := bm.IsExist(ctx, "a")
+	assert.False(t, res)
+	assert.Equal(t, int64(8), bm.Stats().Bytes)
+
+	assert.Nil(t, bm.Delete(ctx, "b"))
+	assert.Equal(t, int64(3), bm.Stats().Bytes)
+	assert.Nil(t, bm.ClearAll(ctx))
+	assert.Equal(t, MemoryCacheStats{Evictions: 1}, bm.Stats())
+}
+
+func TestMemoryCacheEvictionConfig(t *testing.T) {
+	ctx := context.Background()
+	bm, err := NewCache("memory", `{"interval":20,"maxEntries":2,"evictionPolicy":"lfu"}`)
+	assert.Nil(t, err)
+	assert.Nil(t, bm.Put(ctx, "a", 1, 0))
+	assert.Nil(t, bm.Put(ctx, "b", 2, 0))
+	_, err = bm.Get(ctx, "a")
+	assert.Nil(t, err)
+	assert.Nil(t, bm.Put(ctx, "c", 3, 0))
+	res, _ := bm.IsExist(ctx, "b")
+	assert.False(t, res)
+	assert.Equal(t, uint64(1), bm.(*MemoryCache).Stats().Evictions)
+
+	_, err = NewCache("memory", `{"maxEntries":2,"evictionPolicy":"unknown"}`)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,291 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// hash strategy names accepted by the "hash" config of sharded memory cache
+const (
+	ShardHashFNV     = "fnv"
+	ShardHashMapHash = "maphash"
+)
+
+// DefaultShardCount is the number of shards used when the shard count is not set
+var DefaultShardCount = 4 * runtime.GOMAXPROCS(0)
+
+// ShardedMemoryCache is a memory cache adapter which spreads the keys over
+// several MemoryCache shards, so that concurrent operations on different keys
+// rarely contend on the same lock.
+type ShardedMemoryCache struct {
+	shards  []*MemoryCache
+	hash    func(key string) uint64
+	memOpts []MemoryCacheOption
+}
+
+// ShardedMemoryCacheOption configures a ShardedMemoryCache created by NewShardedMemoryCache
+type ShardedMemoryCacheOption func(*ShardedMemoryCache)
+
+// WithShardCount sets the number of shards
+func WithShardCount(n int) ShardedMemoryCacheOption {
+	return func(c *ShardedMemoryCache) {
+		if n > 0 {
+			c.shards = make([]*MemoryCache, n)
+		}
+	}
+}
+
+// WithShardHash sets the function which maps a key to a shard
+func WithShardHash(fn func(key string) uint64) ShardedMemoryCacheOption {
+	return func(c *ShardedMemoryCache) {
+		c.hash = fn
+	}
+}
+
+// WithShardMemoryOptions sets the options of every shard.
+// Notice that the limits like WithMemoryMaxEntries apply to each shard.
+func WithShardMemoryOptions(opts ...MemoryCacheOption) ShardedMemoryCacheOption {
+	return func(c *ShardedMemoryCache) {
+		c.memOpts = opts
+	}
+}
+
+// NewShardedMemoryCache returns a new ShardedMemoryCache.
+// By default, it uses DefaultShardCount shards and FNV-1a hash.
+func NewShardedMemoryCache(opts ...ShardedMemoryCacheOption) Cache {
+	c := &ShardedMemoryCache{
+		shards: make([]*MemoryCache, DefaultShardCount),
+		hash:   FNVHash,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for i := range c.shards {
+		c.shards[i] = NewMemoryCacheWithOptions(c.memOpts...).(*MemoryCache)
+	}
+	return c
+}
+
+// FNVHash hashes key with 64-bit FNV-1a
+func FNVHash(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime64
+	}
+	return h
+}
+
+// NewMapHash returns a hash function backed by hash/maphash with a random seed
+func NewMapHash() func(key string) uint64 {
+	seed := maphash.MakeSeed()
+	return func(key string) uint64 {
+		return maphash.String(seed, key)
+	}
+}
+
+func (c *ShardedMemoryCache) shard(key string) *MemoryCache {
+	return c.shards[c.hash(key)%uint64(len(c.shards))]
+}
+
+// Get returns cache from memory.
+// If non-existent or expired, return nil.
+func (c *ShardedMemoryCache) Get(ctx context.Context, key string) (interface{}, error) {
+	return c.shard(key).Get(ctx, key)
+}
+
+// GetMulti gets caches from memory.
+// If non-existent or expired, return nil.
+func (c *ShardedMemoryCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	rc := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+
+	for i, ki := range keys {
+		val, err := c.Get(ctx, ki)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, err.Error()))
+			continue
+		}
+		rc[i] = val
+	}
+
+	if len(keysErr) == 0 {
+		return rc, nil
+	}
+	return rc, berror.Error(MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put puts cache into memory.
+func (c *ShardedMemoryCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return c.shard(key).Put(ctx, key, val, timeout)
+}
+
+// PutWithTags puts cache into memory and associates it with tags.
+func (c *ShardedMemoryCache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	return c.shard(key).PutWithTags(ctx, key, val, timeout, tags...)
+}
+
+// InvalidateTag deletes all caches associated with any of tags.
+func (c *ShardedMemoryCache) InvalidateTag(ctx context.Context, tags ...string) error {
+	for _, s := range c.shards {
+		if err := s.InvalidateTag(ctx, tags...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteByPrefix deletes all caches whose key starts with prefix.
+func (c *ShardedMemoryCache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	for _, s := range c.shards {
+		if err := s.DeleteByPrefix(ctx, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete cache in memory.
+func (c *ShardedMemoryCache) Delete(ctx context.Context, key string) error {
+	return c.shard(key).Delete(ctx, key)
+}
+
+// Incr increases cache counter in memory.
+func (c *ShardedMemoryCache) Incr(ctx context.Context, key string) error {
+	return c.shard(key).Incr(ctx, key)
+}
+
+// Decr decreases counter in memory.
+func (c *ShardedMemoryCache) Decr(ctx context.Context, key string) error {
+	return c.shard(key).Decr(ctx, key)
+}
+
+// IncrBy adds delta to the counter in memory and returns the new value.
+func (c *ShardedMemoryCache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.shard(key).IncrBy(ctx, key, delta)
+}
+
+// GetAndSet puts cache into memory and returns the old value.
+func (c *ShardedMemoryCache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	return c.shard(key).GetAndSet(ctx, key, val, timeout)
+}
+
+// GetWithVersion returns cache from memory and its version.
+func (c *ShardedMemoryCache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	return c.shard(key).GetWithVersion(ctx, key)
+}
+
+// CompareAndSwap puts cache into memory if the version of the existing item is version.
+func (c *ShardedMemoryCache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	return c.shard(key).CompareAndSwap(ctx, key, version, val, timeout)
+}
+
+// IsExist checks if cache exists in memory.
+func (c *ShardedMemoryCache) IsExist(ctx context.Context, key string) (bool, error) {
+	return c.shard(key).IsExist(ctx, key)
+}
+
+// ClearAll deletes all cache in memory.
+func (c *ShardedMemoryCache) ClearAll(ctx context.Context) error {
+	for _, s := range c.shards {
+		if err := s.ClearAll(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the usage summed over all shards
+func (c *ShardedMemoryCache) Stats() MemoryCacheStats {
+	var res MemoryCacheStats
+	for _, s := range c.shards {
+		st := s.Stats()
+		res.Entries += st.Entries
+		res.Bytes += st.Bytes
+		res.Evictions += st.Evictions
+	}
+	return res
+}
+
+// StartAndGC starts all shards.
+// config must be in the format {"interval":60,"shards":32,"hash":"fnv","maxEntries":10000}.
+// hash is one of fnv and maphash. The other fields are the same as memory cache,
+// and maxEntries and maxBytes are divided evenly among the shards.
+func (c *ShardedMemoryCache) StartAndGC(config string) error {
+	var cf map[string]interface{}
+	if err := json.Unmarshal([]byte(config), &cf); err != nil {
+		return berror.Wrapf(err, InvalidMemoryCacheCfg, "invalid config, please check your input: %s", config)
+	}
+	if cf == nil {
+		cf = make(map[string]interface{})
+	}
+	if n, ok := cf["shards"].(float64); ok && int(n) > 0 && int(n) != len(c.shards) {
+		c.shards = make([]*MemoryCache, int(n))
+		for i := range c.shards {
+			c.shards[i] = NewMemoryCacheWithOptions(c.memOpts...).(*MemoryCache)
+		}
+	}
+	switch cf["hash"] {
+	case nil:
+	case ShardHashFNV:
+		c.hash = FNVHash
+	case ShardHashMapHash:
+		c.hash = NewMapHash()
+	default:
+		return berror.Errorf(InvalidMemoryCacheCfg, "unknown shard hash: %v", cf["hash"])
+	}
+	delete(cf, "shards")
+	delete(cf, "hash")
+	for _, limit := range []string{"maxEntries", "maxBytes"} {
+		if v, ok := cf[limit].(float64); ok && v > 0 {
+			perShard := int64(v) / int64(len(c.shards))
+			if perShard < 1 {
+				perShard = 1
+			}
+			cf[limit] = perShard
+		}
+	}
+	shardCfg, err := json.Marshal(cf)
+	if err != nil {
+		return berror.Wrapf(err, InvalidMemoryCacheCfg, "invalid config, please check your input: %s", config)
+	}
+	for _, s := range c.shards {
+		if err = s.StartAndGC(string(shardCfg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register("sharded_memory", func() Cache {
+		return NewShardedMemoryCache()
+	})
+}
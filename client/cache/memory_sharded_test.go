@@ -0,0 +1,118 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShardedMemoryCache(t *testing.T) {
+	bm, err := NewCache("sharded_memory", `{"interval":1,"shards":8,"hash":"maphash"}`)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	timeoutDuration := 5 * time.Second
+
+	assert.Nil(t, bm.Put(ctx, "astaxie", 1, timeoutDuration))
+	res, _ := bm.IsExist(ctx, "astaxie")
+	assert.True(t, res)
+	v, _ := bm.Get(ctx, "astaxie")
+	assert.Equal(t, 1, v)
+
+	testMultiTypeIncrDecr(t, bm, timeoutDuration)
+	testIncrOverFlow(t, bm, timeoutDuration)
+	testDecrOverFlow(t, bm, timeoutDuration)
+
+	assert.Nil(t, bm.Put(ctx, "astaxie1", "author1", timeoutDuration))
+	vv, err := bm.GetMulti(ctx, []string{"astaxie", "astaxie1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, "author1"}, vv)
+
+	vv, err = bm.GetMulti(ctx, []string{"astaxie0", "astaxie1"})
+	assert.NotNil(t, err)
+	assert.Nil(t, vv[0])
+	assert.Equal(t, "author1", vv[1])
+
+	assert.Nil(t, bm.Delete(ctx, "astaxie"))
+	res, _ = bm.IsExist(ctx, "astaxie")
+	assert.False(t, res)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+	assert.Equal(t, 0, bm.(*ShardedMemoryCache).Stats().Entries)
+
+	_, err = NewCache("sharded_memory", `{"hash":"unknown"}`)
+	assert.NotNil(t, err)
+}
+
+func TestShardedMemoryCacheLimit(t *testing.T) {
+	ctx := context.Background()
+	bm := NewShardedMemoryCache(
+		WithShardCount(4),
+		WithShardHash(func(key string) uint64 {
+			n, _ := strconv.Atoi(key)
+			return uint64(n)
+		}),
+		WithShardMemoryOptions(WithMemoryMaxEntries(2)))
+	for i := 0; i < 12; i++ {
+		assert.Nil(t, bm.Put(ctx, strconv.Itoa(i), i, 0))
+	}
+	stats := bm.(*ShardedMemoryCache).Stats()
+	assert.Equal(t, 8, stats.Entries)
+	assert.Equal(t, uint64(4), stats.Evictions)
+}
+
+func benchmarkCacheParallel(b *testing.B, bm Cache, writeRatio int) {
+	ctx := context.Background()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		_ = bm.Put(ctx, keys[i], i, 0)
+	}
+	var seq uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(atomic.AddUint64(&seq, 1))
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if writeRatio > 0 && i%writeRatio == 0 {
+				_ = bm.Put(ctx, key, i, 0)
+			} else {
+				_, _ = bm.Get(ctx, key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMemoryCacheGet(b *testing.B) {
+	benchmarkCacheParallel(b, NewMemoryCache(), 0)
+}
+
+func BenchmarkShardedMemoryCacheGet(b *testing.B) {
+	benchmarkCacheParallel(b, NewShardedMemoryCache(), 0)
+}
+
+func BenchmarkMemoryCacheMixed(b *testing.B) {
+	benchmarkCacheParallel(b, NewMemoryCache(), 4)
+}
+
+func BenchmarkShardedMemoryCacheMixed(b *testing.B) {
+	benchmarkCacheParallel(b, NewShardedMemoryCache(), 4)
+}
@@ -16,22 +16,154 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jialequ/android-sdk/core/berror"
 )
 
+// ReadThroughOption configures the read-through decorators
+// created by NewReadThroughCache and NewSingleflightCache.
+type ReadThroughOption func(o *readThroughOptions)
+
+// WithSoftTTL enables stale-while-revalidate.
+// The expiration passed to the decorator is the hard TTL of the cached value.
+// Once a value is older than the soft TTL, Get still returns it,
+// and reloads it in background. Only one background refresh runs per key.
+// The ages of values are tracked by the decorator instance,
+// so a value loaded by other processes is considered fresh when it is read for the first time.
+func WithSoftTTL(ttl time.Duration) ReadThroughOption {
+	return func(o *readThroughOptions) {
+		o.softTTL = ttl
+	}
+}
+
+// WithNegativeCache caches the misses of the load function for ttl.
+// A miss is that the load function returns an error which wraps ErrKeyNotExist.
+// While the miss is cached, Get returns the error without calling the load function.
+func WithNegativeCache(ttl time.Duration) ReadThroughOption {
+	return func(o *readThroughOptions) {
+		o.missTTL = ttl
+	}
+}
+
+// readThroughOptions implements the options shared by the read-through decorators
+type readThroughOptions struct {
+	softTTL time.Duration
+	missTTL time.Duration
+	// meta records when the keys are loaded and the keys that the load function could not find,
+	// it is shared by all decorators, so its keys are prefixed by id
+	meta       Cache
+	id         string
+	refreshing sync.Map
+}
+
+var (
+	metaCache     Cache
+	metaCacheOnce sync.Once
+	metaCacheSeq  uint64
+)
+
+func newReadThroughOptions(opts []ReadThroughOption) *readThroughOptions {
+	o := &readThroughOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.softTTL > 0 || o.missTTL > 0 {
+		o.meta = sharedMetaCache()
+		o.id = strconv.FormatUint(atomic.AddUint64(&metaCacheSeq, 1), 10)
+	}
+	return o
+}
+
+// sharedMetaCache returns the memory cache of the metadata of all decorators,
+// so that there is only one goroutine of GC however many decorators are created
+func sharedMetaCache() Cache {
+	metaCacheOnce.Do(func() {
+		c := NewMemoryCache()
+		_ = c.StartAndGC(fmt.Sprintf(`{"interval":%d}`, DefaultEvery))
+		metaCache = c
+	})
+	return metaCache
+}
+
+// loadedAtKey is the key of meta recording when key is loaded
+func (o *readThroughOptions) loadedAtKey(key string) string {
+	return o.id + ":loaded:" + key
+}
+
+// missKey is the key of meta recording that the load function could not find key
+func (o *readThroughOptions) missKey(key string) string {
+	return o.id + ":miss:" + key
+}
+
+// stale reports whether the cached value of key is older than the soft TTL
+func (o *readThroughOptions) stale(ctx context.Context, key string, expiration time.Duration) bool {
+	if o.softTTL <= 0 {
+		return false
+	}
+	at, err := o.meta.Get(ctx, o.loadedAtKey(key))
+	if err != nil {
+		// loaded by others, start counting from now
+		_ = o.meta.Put(ctx, o.loadedAtKey(key), time.Now(), expiration)
+		return false
+	}
+	return time.Since(at.(time.Time)) > o.softTTL
+}
+
+// loaded records that key has just been loaded
+func (o *readThroughOptions) loaded(ctx context.Context, key string, expiration time.Duration) {
+	if o.softTTL > 0 {
+		_ = o.meta.Put(ctx, o.loadedAtKey(key), time.Now(), expiration)
+	}
+}
+
+// cachedMiss returns the error if key is a cached miss
+func (o *readThroughOptions) cachedMiss(ctx context.Context, key string) error {
+	if o.missTTL <= 0 {
+		return nil
+	}
+	if ok, _ := o.meta.IsExist(ctx, o.missKey(key)); ok {
+		return berror.Wrap(ErrKeyNotExist, LoadFuncFailed, "cache unable to load data")
+	}
+	return nil
+}
+
+// miss caches err if it means that the load function could not find key
+func (o *readThroughOptions) miss(ctx context.Context, key string, err error) {
+	if o.missTTL > 0 && errors.Is(err, ErrKeyNotExist) {
+		_ = o.meta.Put(ctx, o.missKey(key), struct{}{}, o.missTTL)
+	}
+}
+
+// refresh runs load in background unless a refresh of key is running
+func (o *readThroughOptions) refresh(key string, load func()) {
+	if _, running := o.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer o.refreshing.Delete(key)
+		load()
+	}()
+}
+
 // readThroughCache is a decorator
 // add the read through function to the original Cache function
 type readThroughCache struct {
 	Cache
 	expiration time.Duration
 	loadFunc   func(ctx context.Context, key string) (any, error)
+	opts       *readThroughOptions
 }
 
 // NewReadThroughCache create readThroughCache
 func NewReadThroughCache(cache Cache, expiration time.Duration,
 	loadFunc func(ctx context.Context, key string) (any, error),
+	opts ...ReadThroughOption,
 ) (Cache, error) {
 	if loadFunc == nil {
 		return nil, berror.Error(InvalidLoadFunc, "loadFunc cannot be nil")
@@ -40,22 +172,39 @@ func NewReadThroughCache(cache Cache, expiration time.Duration,
 		Cache:      cache,
 		expiration: expiration,
 		loadFunc:   loadFunc,
+		opts:       newReadThroughOptions(opts),
 	}, nil
 }
 
 // Get will try to call the LoadFunc to load data if the Cache returns value nil or non-nil error.
+// If the value is older than the soft TTL, it is returned and reloaded in background.
 func (c *readThroughCache) Get(ctx context.Context, key string) (any, error) {
 	val, err := c.Cache.Get(ctx, key)
 	if val == nil || err != nil {
-		val, err = c.loadFunc(ctx, key)
-		if err != nil {
-			return nil, berror.Wrap(
-				err, LoadFuncFailed, "cache unable to load data")
-		}
-		err = c.Cache.Put(ctx, key, val, c.expiration)
-		if err != nil {
-			return val, err
-		}
+		return c.load(ctx, key)
+	}
+	if c.opts.stale(ctx, key, c.expiration) {
+		c.opts.refresh(key, func() {
+			_, _ = c.load(context.Background(), key)
+		})
+	}
+	return val, nil
+}
+
+func (c *readThroughCache) load(ctx context.Context, key string) (any, error) {
+	if err := c.opts.cachedMiss(ctx, key); err != nil {
+		return nil, err
+	}
+	val, err := c.loadFunc(ctx, key)
+	if err != nil {
+		c.opts.miss(ctx, key, err)
+		return nil, berror.Wrap(
+			err, LoadFuncFailed, "cache unable to load data")
+	}
+	err = c.Cache.Put(ctx, key, val, c.expiration)
+	if err != nil {
+		return val, err
 	}
+	c.opts.loaded(ctx, key, c.expiration)
 	return val, nil
 }
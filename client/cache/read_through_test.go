@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -132,6 +133,96 @@ func testReadThroughCacheGet(t *testing.T, bm Cache) {
 	}
 }
 
+func TestReadThroughCacheSoftTTL(t *testing.T) {
+	testSoftTTL(t, func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error) {
+		return NewReadThroughCache(c, time.Minute, load, WithSoftTTL(100*time.Millisecond))
+	})
+}
+
+func TestReadThroughCacheNegativeCache(t *testing.T) {
+	testNegativeCache(t, func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error) {
+		return NewReadThroughCache(c, time.Minute, load, WithNegativeCache(200*time.Millisecond))
+	})
+}
+
+func TestReadThroughCacheSharedMeta(t *testing.T) {
+	load := func(ctx context.Context, key string) (any, error) {
+		return nil, berror.Wrap(ErrKeyNotExist, KeyNotExist, "no such row")
+	}
+	c1, err := NewReadThroughCache(NewMemoryCache(), time.Minute, load, WithNegativeCache(time.Minute))
+	assert.Nil(t, err)
+	c2, err := NewReadThroughCache(NewMemoryCache(), time.Minute, func(ctx context.Context, key string) (any, error) {
+		return "value", nil
+	}, WithSoftTTL(time.Minute))
+	assert.Nil(t, err)
+
+	// the decorators share one meta cache, but not the keys
+	o1, o2 := c1.(*readThroughCache).opts, c2.(*readThroughCache).opts
+	assert.True(t, o1.meta == o2.meta)
+	assert.NotEqual(t, o1.id, o2.id)
+
+	ctx := context.Background()
+	_, err = c1.Get(ctx, "key")
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	val, err := c2.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+}
+
+func testSoftTTL(t *testing.T, newCache func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error)) {
+	var loadCnt int32
+	release := make(chan struct{})
+	c, err := newCache(NewMemoryCache(), func(ctx context.Context, key string) (any, error) {
+		cnt := atomic.AddInt32(&loadCnt, 1)
+		if cnt > 1 {
+			<-release
+		}
+		return fmt.Sprintf("value%d", cnt), nil
+	})
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	val, err := c.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value1", val)
+
+	time.Sleep(150 * time.Millisecond)
+	// stale values are returned without waiting for the refresh
+	for i := 0; i < 10; i++ {
+		val, err = c.Get(ctx, "key")
+		assert.Nil(t, err)
+		assert.Equal(t, "value1", val)
+	}
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loadCnt))
+
+	val, err = c.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value2", val)
+}
+
+func testNegativeCache(t *testing.T, newCache func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error)) {
+	var loadCnt int32
+	c, err := newCache(NewMemoryCache(), func(ctx context.Context, key string) (any, error) {
+		atomic.AddInt32(&loadCnt, 1)
+		return nil, berror.Wrap(ErrKeyNotExist, KeyNotExist, "no such row")
+	})
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		_, err = c.Get(ctx, "missing")
+		assert.True(t, errors.Is(err, ErrKeyNotExist))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loadCnt))
+
+	time.Sleep(300 * time.Millisecond)
+	_, err = c.Get(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotExist))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loadCnt))
+}
+
 type MockOrm struct {
 	keysMap map[string]int
 	kvs     map[string]any
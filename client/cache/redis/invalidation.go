@@ -0,0 +1,144 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// DefaultInvalidationChannel is the pub/sub channel used by InvalidationBus
+const DefaultInvalidationChannel = "beecacheInvalidation"
+
+// resubscribeInterval is the delay before subscribing again after the connection is broken
+const resubscribeInterval = time.Second
+
+// InvalidationBus is a cache.InvalidationBus based on redis pub/sub.
+// It shares the connection pool of the redis cache adapter.
+type InvalidationBus struct {
+	rc      *Cache
+	channel string
+}
+
+var _ cache.InvalidationBus = (*InvalidationBus)(nil)
+
+// NewInvalidationBus creates an InvalidationBus publishing to channel.
+// rc must be started by StartAndGC. If channel is empty, DefaultInvalidationChannel is used.
+//
+// Usage:
+//
+//	l2, _ := cache.NewCache("redis", `{"conn":"127.0.0.1:6379"}`)
+//	bus := redis.NewInvalidationBus(l2.(*redis.Cache), "")
+//	c, _ := cache.NewTieredCache(cache.NewMemoryCache(), l2, cache.WithInvalidationBus(bus))
+func NewInvalidationBus(rc *Cache, channel string) *InvalidationBus {
+	if channel == "" {
+		channel = DefaultInvalidationChannel
+	}
+	return &InvalidationBus{rc: rc, channel: channel}
+}
+
+// Publish sends msg to the channel
+func (b *InvalidationBus) Publish(ctx context.Context, msg cache.Invalidation) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c := b.rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	if _, err = c.Do("PUBLISH", b.channel, data); err != nil {
+		return berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not publish to channel: %s", b.channel)
+	}
+	return nil
+}
+
+// Subscribe listens to the channel in a new goroutine until ctx is done.
+// If the connection is broken, it subscribes again.
+func (b *InvalidationBus) Subscribe(ctx context.Context, handler func(msg cache.Invalidation)) error {
+	psc, err := b.subscribe()
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			b.receive(ctx, psc, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			time.Sleep(resubscribeInterval)
+			if psc, err = b.subscribe(); err != nil {
+				psc = redis.PubSubConn{}
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *InvalidationBus) subscribe() (redis.PubSubConn, error) {
+	psc := redis.PubSubConn{Conn: b.rc.p.Get()}
+	if err := psc.Subscribe(b.channel); err != nil {
+		_ = psc.Close()
+		return redis.PubSubConn{}, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not subscribe to channel: %s", b.channel)
+	}
+	return psc, nil
+}
+
+// receive dispatches the messages until the connection fails or ctx is done
+func (b *InvalidationBus) receive(ctx context.Context, psc redis.PubSubConn, handler func(msg cache.Invalidation)) {
+	if psc.Conn == nil {
+		return
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			// unsubscribing ends Receive by the subscription count 0,
+			// Send is safe to call while the other goroutine is in Receive
+			_ = psc.Unsubscribe()
+		case <-done:
+		}
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+		_ = psc.Close()
+	}()
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			var msg cache.Invalidation
+			if json.Unmarshal(v.Data, &msg) == nil {
+				handler(msg)
+			}
+		case redis.Subscription:
+			if v.Count == 0 {
+				return
+			}
+		case error:
+			return
+		}
+	}
+}
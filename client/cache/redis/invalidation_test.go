@@ -0,0 +1,224 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/cache"
+)
+
+var errFakeConnClosed = errors.New("fake connection closed")
+
+// fakePubSub is an in-process redis server which only supports pub/sub
+type fakePubSub struct {
+	mux   sync.Mutex
+	conns map[*fakeConn]string
+}
+
+func newFakePubSub() *fakePubSub {
+	return &fakePubSub{conns: make(map[*fakeConn]string)}
+}
+
+func (ps *fakePubSub) dial() (redis.Conn, error) {
+	return &fakeConn{
+		ps:      ps,
+		replies: make(chan interface{}, 64),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+func (ps *fakePubSub) publish(channel string, data []byte) int64 {
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+	var n int64
+	for c, ch := range ps.conns {
+		if ch == channel {
+			c.replies <- []interface{}{[]byte("message"), []byte(channel), data}
+			n++
+		}
+	}
+	return n
+}
+
+// disconnect breaks all subscribed connections, like a restarted server
+func (ps *fakePubSub) disconnect() {
+	ps.mux.Lock()
+	conns := make([]*fakeConn, 0, len(ps.conns))
+	for c := range ps.conns {
+		conns = append(conns, c)
+	}
+	ps.mux.Unlock()
+	for _, c := range conns {
+		_ = c.Close()
+	}
+}
+
+func (ps *fakePubSub) subscribers() int {
+	ps.mux.Lock()
+	defer ps.mux.Unlock()
+	return len(ps.conns)
+}
+
+type fakeConn struct {
+	ps      *fakePubSub
+	replies chan interface{}
+	once    sync.Once
+	closed  chan struct{}
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+		c.ps.mux.Lock()
+		delete(c.ps.conns, c)
+		c.ps.mux.Unlock()
+	})
+	return nil
+}
+
+func (c *fakeConn) Err() error {
+	select {
+	case <-c.closed:
+		return errFakeConnClosed
+	default:
+		return nil
+	}
+}
+
+func (c *fakeConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	switch commandName {
+	case "":
+		return nil, c.Err()
+	case "PUBLISH":
+		return c.ps.publish(fmt.Sprint(args[0]), args[1].([]byte)), nil
+	}
+	return nil, fmt.Errorf("unsupported command: %s", commandName)
+}
+
+func (c *fakeConn) Send(commandName string, args ...interface{}) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	switch commandName {
+	case "SUBSCRIBE":
+		channel := fmt.Sprint(args[0])
+		c.ps.mux.Lock()
+		c.ps.conns[c] = channel
+		c.ps.mux.Unlock()
+		c.replies <- []interface{}{[]byte("subscribe"), []byte(channel), int64(1)}
+	case "UNSUBSCRIBE", "PUNSUBSCRIBE":
+		c.ps.mux.Lock()
+		channel := c.ps.conns[c]
+		delete(c.ps.conns, c)
+		c.ps.mux.Unlock()
+		c.replies <- []interface{}{[]byte(strings.ToLower(commandName)), []byte(channel), int64(0)}
+	case "ECHO":
+		c.replies <- args[0]
+	default:
+		return fmt.Errorf("unsupported command: %s", commandName)
+	}
+	return nil
+}
+
+func (c *fakeConn) Flush() error {
+	return c.Err()
+}
+
+func (c *fakeConn) Receive() (interface{}, error) {
+	select {
+	case reply := <-c.replies:
+		return reply, nil
+	case <-c.closed:
+		return nil, errFakeConnClosed
+	}
+}
+
+func newFakeCache(ps *fakePubSub) *Cache {
+	return &Cache{key: DefaultKey, p: &redis.Pool{Dial: ps.dial}}
+}
+
+func TestInvalidationBusEvict(t *testing.T) {
+	ctx := context.Background()
+	ps := newFakePubSub()
+	l2 := cache.NewMemoryCache()
+	l1a := cache.NewMemoryCache()
+	l1b := cache.NewMemoryCache()
+	a, err := cache.NewTieredCache(l1a, l2, cache.WithInvalidationBus(NewInvalidationBus(newFakeCache(ps), "")))
+	assert.Nil(t, err)
+	defer a.Close()
+	b, err := cache.NewTieredCache(l1b, l2, cache.WithInvalidationBus(NewInvalidationBus(newFakeCache(ps), "")))
+	assert.Nil(t, err)
+	defer b.Close()
+	assert.Equal(t, 2, ps.subscribers())
+
+	assert.Nil(t, a.Put(ctx, "key", "v1", time.Minute))
+	val, _ := b.Get(ctx, "key")
+	assert.Equal(t, "v1", val)
+
+	// the L1 copy of b is evicted by the message published by a
+	assert.Nil(t, a.Put(ctx, "key", "v2", time.Minute))
+	assert.Eventually(t, func() bool {
+		res, _ := l1b.IsExist(ctx, "key")
+		return !res
+	}, 3*time.Second, 10*time.Millisecond)
+	res, _ := l1a.IsExist(ctx, "key")
+	assert.True(t, res)
+	val, _ = b.Get(ctx, "key")
+	assert.Equal(t, "v2", val)
+}
+
+func TestInvalidationBusReconnect(t *testing.T) {
+	ps := newFakePubSub()
+	bus := NewInvalidationBus(newFakeCache(ps), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan cache.Invalidation, 1)
+	assert.Nil(t, bus.Subscribe(ctx, func(msg cache.Invalidation) {
+		received <- msg
+	}))
+	assert.Equal(t, 1, ps.subscribers())
+
+	// it subscribes again after the connection is broken
+	ps.disconnect()
+	assert.Eventually(t, func() bool {
+		return ps.subscribers() == 1
+	}, 3*resubscribeInterval, 10*time.Millisecond)
+
+	msg := cache.Invalidation{Source: "test", Keys: []string{"astaxie"}}
+	assert.Nil(t, bus.Publish(context.Background(), msg))
+	select {
+	case got := <-received:
+		assert.Equal(t, msg, got)
+	case <-time.After(3 * time.Second):
+		t.Error("invalidation not received")
+	}
+
+	// it unsubscribes when ctx is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		return ps.subscribers() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+}
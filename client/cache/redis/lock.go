@@ -0,0 +1,111 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/client/cache/lock"
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// acquireScript sets KEYS[1] to ARGV[1] for ARGV[2] milliseconds if it does not exist,
+// and increases the fencing counter KEYS[2]. It returns 0 if the lock is held.
+var acquireScript = redis.NewScript(2, `
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// renewScript extends the expiration of KEYS[1] to ARGV[2] milliseconds if its value is ARGV[1]
+var renewScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes KEYS[1] if its value is ARGV[1]
+var releaseScript = redis.NewScript(1, `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LockBackend is a lock.Backend based on redis.
+// The lease and the fencing counter are updated in one lua script,
+// so the fencing tokens of a key are strictly increasing.
+type LockBackend struct {
+	rc *Cache
+}
+
+var _ lock.Backend = (*LockBackend)(nil)
+
+// NewLockBackend creates a LockBackend sharing the connection pool of rc.
+// rc must be started by StartAndGC.
+func NewLockBackend(rc *Cache) *LockBackend {
+	return &LockBackend{rc: rc}
+}
+
+// Acquire takes the lock of key if it does not exist
+func (b *LockBackend) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (uint64, bool, error) {
+	c := b.rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	token, err := redis.Uint64(acquireScript.Do(c, b.leaseKey(key), b.fenceKey(key), owner, ttl.Milliseconds()))
+	if err != nil {
+		return 0, false, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not acquire the lock, key: %s", key)
+	}
+	return token, token > 0, nil
+}
+
+// Renew extends the lease of key if owner holds it
+func (b *LockBackend) Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	return b.run(renewScript, key, owner, ttl.Milliseconds())
+}
+
+// Release deletes the lock of key if owner holds it
+func (b *LockBackend) Release(ctx context.Context, key, owner string) (bool, error) {
+	return b.run(releaseScript, key, owner)
+}
+
+func (b *LockBackend) run(script *redis.Script, key string, args ...interface{}) (bool, error) {
+	c := b.rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	ok, err := redis.Bool(script.Do(c, append([]interface{}{b.leaseKey(key)}, args...)...))
+	if err != nil {
+		return false, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not update the lock, key: %s", key)
+	}
+	return ok, nil
+}
+
+func (b *LockBackend) leaseKey(key string) string {
+	return b.rc.associate("__lock__:" + key)
+}
+
+func (b *LockBackend) fenceKey(key string) string {
+	return b.rc.associate("__fence__:" + key)
+}
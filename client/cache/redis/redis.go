@@ -50,6 +50,13 @@ const (
 	defaultMaxIdle = 3
 	// defaultTimeout defines the default timeout .
 	defaultTimeout = time.Second * 180
+	// tagKeyPrefix is the prefix of the sets which hold the keys of tags.
+	tagKeyPrefix = "__tag__:"
+	// versionKeySuffix is the suffix of the hash which holds the CAS version of a key,
+	// the NUL byte keeps it apart from the keys used by the applications.
+	versionKeySuffix = "\x00__version__"
+	// versionTimeout is the expiration of the CAS version of a key which never expires.
+	versionTimeout = 24 * time.Hour
 )
 
 // Cache is Redis cache adapter.
@@ -72,6 +79,12 @@ type Cache struct {
 	timeout time.Duration
 }
 
+var (
+	_ cache.TaggedCache = (*Cache)(nil)
+	_ cache.AtomicCache = (*Cache)(nil)
+	_ cache.CASCache    = (*Cache)(nil)
+)
+
 // NewRedisCache creates a new redis cache with default collection name.
 func NewRedisCache() cache.Cache {
 	return &Cache{key: DefaultKey}
@@ -130,6 +143,115 @@ func (rc *Cache) Put(ctx context.Context, key string, val interface{}, timeout t
 	return err
 }
 
+// putWithTagsScript sets KEYS[1] to ARGV[1] with expiration ARGV[2] seconds,
+// and adds KEYS[1] to the tag sets KEYS[2:].
+// A tag set expires with its longest living member, so the expiration of a set is only ever extended.
+var putWithTagsScript = redis.NewScript(-1, `
+redis.call('SETEX', KEYS[1], ARGV[2], ARGV[1])
+local ttl = tonumber(ARGV[2])
+for i = 2, #KEYS do
+	local exists = redis.call('EXISTS', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	local cur = redis.call('TTL', KEYS[i])
+	if exists == 0 or (cur >= 0 and cur < ttl) then
+		redis.call('EXPIRE', KEYS[i], ttl)
+	end
+end
+return 'OK'
+`)
+
+// PutWithTags puts cache into redis and adds the key to the set of each tag.
+// The tag sets expire no earlier than their members, they are also removed by InvalidateTag.
+func (rc *Cache) PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	key = rc.associate(key)
+	args := make([]interface{}, 0, len(tags)+4)
+	args = append(args, len(tags)+1, key)
+	for _, tag := range tags {
+		args = append(args, rc.tagKey(tag))
+	}
+	args = append(args, val, int64(timeout/time.Second))
+	if _, err := putWithTagsScript.Do(c, args...); err != nil {
+		return berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not put the key %s with tags", key)
+	}
+	return nil
+}
+
+// InvalidateTag deletes all keys in the sets of tags, and the sets themselves.
+func (rc *Cache) InvalidateTag(ctx context.Context, tags ...string) error {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	for _, tag := range tags {
+		tagKey := rc.tagKey(tag)
+		keys, err := redis.Strings(c.Do("SMEMBERS", tagKey))
+		if err != nil {
+			return berror.Wrapf(err, cache.RedisCacheCurdFailed,
+				"could not read the keys of tag: %s", tag)
+		}
+		args := make([]interface{}, 0, len(keys)+1)
+		args = append(args, tagKey)
+		for _, k := range keys {
+			args = append(args, k)
+		}
+		if _, err = c.Do("DEL", args...); err != nil {
+			return berror.Wrapf(err, cache.RedisCacheCurdFailed,
+				"could not delete the keys of tag: %s", tag)
+		}
+	}
+	return nil
+}
+
+// DeleteByPrefix deletes all keys starting with prefix.
+// Like ClearAll, it scans the keys and then deletes them.
+func (rc *Cache) DeleteByPrefix(ctx context.Context, prefix string) error {
+	keys, err := rc.Scan(escapePattern(rc.associate(prefix)) + "*")
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	args := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	_, err = c.Do("DEL", args...)
+	return err
+}
+
+// versionKey returns the key of the hash which holds the CAS version of the associated key
+func versionKey(key string) string {
+	return key + versionKeySuffix
+}
+
+// tagKey returns the key of the set which holds the keys of tag
+func (rc *Cache) tagKey(tag string) string {
+	return rc.associate(tagKeyPrefix + tag)
+}
+
+// escapePattern escapes the special characters of the glob-style pattern used by SCAN
+func escapePattern(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\', '^', '-':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
 // Delete deletes a key's cache in redis.
 func (rc *Cache) Delete(ctx context.Context, key string) error {
 	_, err := rc.do("DEL", key)
@@ -157,6 +279,126 @@ func (rc *Cache) Decr(ctx context.Context, key string) error {
 	return err
 }
 
+// IncrBy adds delta to a key's counter in redis and returns the new value.
+func (rc *Cache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	return redis.Int64(rc.do("INCRBY", key, delta))
+}
+
+// GetAndSet sets the value of key and returns the old value in one transaction.
+// If timeout is 0, the key never expires.
+func (rc *Cache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	key = rc.associate(key)
+	_ = c.Send("MULTI")
+	_ = c.Send("GETSET", key, val)
+	if timeout > 0 {
+		_ = c.Send("EXPIRE", key, int64(timeout/time.Second))
+	}
+	res, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		return nil, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not execute GETSET, key: %s", key)
+	}
+	return res[0], nil
+}
+
+// versionScript gets KEYS[1] and its CAS version in the hash KEYS[2], which holds the version
+// and the digest of the value it's given to. A new version ARGV[1] is given if the key has none,
+// or the value is changed by other commands.
+var versionScript = redis.NewScript(2, `
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return false
+end
+local sum = redis.sha1hex(v)
+local rec = redis.call('HMGET', KEYS[2], 'ver', 'sum')
+local ver = rec[1]
+if not ver or rec[2] ~= sum then
+	ver = ARGV[1]
+	redis.call('HMSET', KEYS[2], 'ver', ver, 'sum', sum)
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[2], ttl)
+else
+	redis.call('EXPIRE', KEYS[2], ARGV[2])
+end
+return {v, ver}
+`)
+
+// GetWithVersion gets cache from redis and its version.
+// The versions are kept by GetWithVersion and CompareAndSwap only, so that the other commands
+// pay nothing for them. Every swap increases the version, and the version is renewed
+// if the value is changed by other commands, like Put, which is found by the digest of the value.
+// Writing the same value back by the other commands is not found.
+func (rc *Cache) GetWithVersion(ctx context.Context, key string) (interface{}, uint64, error) {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	key = rc.associate(key)
+	res, err := redis.Values(versionScript.Do(c, key, versionKey(key),
+		time.Now().UnixNano(), int64(versionTimeout/time.Second)))
+	if err == redis.ErrNil {
+		return nil, 0, cache.ErrKeyNotExist
+	}
+	if err != nil {
+		return nil, 0, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not get the version, key: %s", key)
+	}
+	ver, err := redis.Uint64(res[1], nil)
+	if err != nil {
+		return nil, 0, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not parse the version, key: %s", key)
+	}
+	return res[0], ver, nil
+}
+
+// casScript sets KEYS[1] to ARGV[2] with expiration ARGV[3] seconds if its version
+// in the hash KEYS[2] is ARGV[1] and its value is not changed, and then increases the version.
+var casScript = redis.NewScript(2, `
+local v = redis.call('GET', KEYS[1])
+if not v then
+	return 0
+end
+local rec = redis.call('HMGET', KEYS[2], 'ver', 'sum')
+if rec[1] ~= ARGV[1] or rec[2] ~= redis.sha1hex(v) then
+	return 0
+end
+redis.call('HINCRBY', KEYS[2], 'ver', 1)
+redis.call('HSET', KEYS[2], 'sum', redis.sha1hex(ARGV[2]))
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+	redis.call('EXPIRE', KEYS[2], ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[2])
+	redis.call('EXPIRE', KEYS[2], ARGV[4])
+end
+return 1
+`)
+
+// CompareAndSwap sets the value of key if its version is still version.
+// If timeout is 0, the key never expires.
+func (rc *Cache) CompareAndSwap(ctx context.Context, key string, version uint64,
+	val interface{}, timeout time.Duration,
+) (bool, error) {
+	c := rc.p.Get()
+	defer func() {
+		_ = c.Close()
+	}()
+	key = rc.associate(key)
+	ok, err := redis.Bool(casScript.Do(c, key, versionKey(key), strconv.FormatUint(version, 10),
+		val, int64(timeout/time.Second), int64(versionTimeout/time.Second)))
+	if err != nil {
+		return false, berror.Wrapf(err, cache.RedisCacheCurdFailed,
+			"could not compare and swap, key: %s", key)
+	}
+	return ok, nil
+}
+
 // ClearAll deletes all cache in the redis collection
 // Be careful about this method, because it scans all keys and the delete them one by one
 func (rc *Cache) ClearAll(context.Context) error {
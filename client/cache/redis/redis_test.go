@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/jialequ/android-sdk/client/cache"
+	"github.com/jialequ/android-sdk/client/cache/lock"
 	"github.com/jialequ/android-sdk/core/berror"
 )
 
@@ -136,6 +137,178 @@ func TestCacheScan(t *testing.T) {
 	assert.Equal(t, 0, len(keys))
 }
 
+func TestInvalidationBus(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = literal_6042
+	}
+
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	bus := NewInvalidationBus(bm.(*Cache), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan cache.Invalidation, 1)
+	assert.Nil(t, bus.Subscribe(ctx, func(msg cache.Invalidation) {
+		received <- msg
+	}))
+
+	msg := cache.Invalidation{Source: "test", Keys: []string{"astaxie"}}
+	assert.Nil(t, bus.Publish(context.Background(), msg))
+	select {
+	case got := <-received:
+		assert.Equal(t, msg, got)
+	case <-time.After(3 * time.Second):
+		t.Error("invalidation not received")
+	}
+}
+
+func TestTaggedCache(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = literal_6042
+	}
+
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	tc, ok := bm.(cache.TaggedCache)
+	assert.True(t, ok)
+	ctx := context.Background()
+	timeoutDuration := 10 * time.Second
+
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:profile", "profile", timeoutDuration, "user:42"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:43:profile", "profile", timeoutDuration, "user:43"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:43:avatar", "avatar", time.Second, "user:43"))
+	assert.Nil(t, tc.Put(ctx, "user*:1", "special", timeoutDuration))
+
+	rc := bm.(*Cache)
+	conn := rc.p.Get()
+	ttl, err := redis.Int(conn.Do("TTL", rc.tagKey("user:43")))
+	_ = conn.Close()
+	assert.Nil(t, err)
+	assert.True(t, ttl > 1 && ttl <= 10)
+
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:42"))
+	res, _ := tc.IsExist(ctx, "user:42:profile")
+	assert.False(t, res)
+	res, _ = tc.IsExist(ctx, "user:43:profile")
+	assert.True(t, res)
+
+	assert.Nil(t, tc.DeleteByPrefix(ctx, "user*"))
+	res, _ = tc.IsExist(ctx, "user*:1")
+	assert.False(t, res)
+	res, _ = tc.IsExist(ctx, "user:43:profile")
+	assert.True(t, res)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestEscapePattern(t *testing.T) {
+	assert.Equal(t, `abc:\*\?\[a\-z\]\\`, escapePattern(`abc:*?[a-z]\`))
+}
+
+func TestAtomicCache(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = literal_6042
+	}
+
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ac, ok := bm.(cache.AtomicCache)
+	assert.True(t, ok)
+	cc, ok := bm.(cache.CASCache)
+	assert.True(t, ok)
+	ctx := context.Background()
+	timeoutDuration := 10 * time.Second
+
+	val, err := ac.IncrBy(ctx, "counter", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), val)
+	val, err = ac.IncrBy(ctx, "counter", -7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), val)
+
+	old, err := ac.GetAndSet(ctx, "key", "v1", timeoutDuration)
+	assert.Nil(t, err)
+	assert.Nil(t, old)
+	old, err = ac.GetAndSet(ctx, "key", "v2", timeoutDuration)
+	assert.Nil(t, err)
+	v, _ := redis.String(old, err)
+	assert.Equal(t, "v1", v)
+
+	_, _, err = cc.GetWithVersion(ctx, "cas")
+	assert.Equal(t, cache.ErrKeyNotExist, err)
+	assert.Nil(t, cc.Put(ctx, "cas", "v1", timeoutDuration))
+	_, ver, err := cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v2", timeoutDuration)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v3", timeoutDuration)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// A -> B -> A changes the version too
+	_, ver, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	_, ver2, err := cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver2, "v1", timeoutDuration)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	_, ver2, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver2, "v2", timeoutDuration)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v3", timeoutDuration)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	v, _ = redis.String(bm.Get(ctx, "cas"))
+	assert.Equal(t, "v2", v)
+
+	// the value changed by Put is found without the version
+	_, ver, err = cc.GetWithVersion(ctx, "cas")
+	assert.Nil(t, err)
+	assert.Nil(t, cc.Put(ctx, "cas", "v4", timeoutDuration))
+	ok, err = cc.CompareAndSwap(ctx, "cas", ver, "v5", timeoutDuration)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	v, _ = redis.String(bm.Get(ctx, "cas"))
+	assert.Equal(t, "v4", v)
+
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
+func TestLockBackend(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = literal_6042
+	}
+
+	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, addr))
+	assert.Nil(t, err)
+	ctx := context.Background()
+	locker := lock.NewLocker(NewLockBackend(bm.(*Cache)), lock.WithTTL(2*time.Second))
+
+	l1, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	_, err = locker.TryLock(ctx, "job")
+	assert.Equal(t, lock.ErrNotAcquired, err)
+	assert.Nil(t, l1.Refresh(ctx))
+	assert.Nil(t, l1.Unlock(ctx))
+	assert.Equal(t, lock.ErrNotHeld, l1.Unlock(ctx))
+
+	l2, err := locker.TryLock(ctx, "job")
+	assert.Nil(t, err)
+	assert.Greater(t, l2.Token(), l1.Token())
+	assert.Nil(t, l2.Unlock(ctx))
+
+	assert.Nil(t, bm.ClearAll(ctx))
+}
+
 func TestReadThroughCacheredisGet(t *testing.T) {
 	bm, err := cache.NewCache("redis", fmt.Sprintf(`{"conn": "%s"}`, literal_6042))
 	assert.Nil(t, err)
@@ -30,11 +30,13 @@ type SingleflightCache struct {
 	group      *singleflight.Group
 	expiration time.Duration
 	loadFunc   func(ctx context.Context, key string) (any, error)
+	opts       *readThroughOptions
 }
 
 // NewSingleflightCache create SingleflightCache
 func NewSingleflightCache(c Cache, expiration time.Duration,
 	loadFunc func(ctx context.Context, key string) (any, error),
+	opts ...ReadThroughOption,
 ) (Cache, error) {
 	if loadFunc == nil {
 		return nil, berror.Error(InvalidLoadFunc, "loadFunc cannot be nil")
@@ -44,21 +46,40 @@ func NewSingleflightCache(c Cache, expiration time.Duration,
 		group:      &singleflight.Group{},
 		expiration: expiration,
 		loadFunc:   loadFunc,
+		opts:       newReadThroughOptions(opts),
 	}, nil
 }
 
 // Get In the Get method, single flight is used to load data and write back the cache.
+// If the value is older than the soft TTL, it is returned and reloaded in background.
 func (s *SingleflightCache) Get(ctx context.Context, key string) (any, error) {
 	val, err := s.Cache.Get(ctx, key)
 	if val == nil || err != nil {
-		val, err, _ = s.group.Do(key, func() (interface{}, error) {
-			v, er := s.loadFunc(ctx, key)
-			if er != nil {
-				return nil, berror.Wrap(er, LoadFuncFailed, "cache unable to load data")
-			}
-			er = s.Cache.Put(ctx, key, v, s.expiration)
-			return v, er
+		return s.load(ctx, key)
+	}
+	if s.opts.stale(ctx, key, s.expiration) {
+		s.opts.refresh(key, func() {
+			_, _ = s.load(context.Background(), key)
 		})
 	}
 	return val, err
 }
+
+func (s *SingleflightCache) load(ctx context.Context, key string) (any, error) {
+	if err := s.opts.cachedMiss(ctx, key); err != nil {
+		return nil, err
+	}
+	val, err, _ := s.group.Do(key, func() (interface{}, error) {
+		v, er := s.loadFunc(ctx, key)
+		if er != nil {
+			s.opts.miss(ctx, key, er)
+			return nil, berror.Wrap(er, LoadFuncFailed, "cache unable to load data")
+		}
+		er = s.Cache.Put(ctx, key, v, s.expiration)
+		if er == nil {
+			s.opts.loaded(ctx, key, s.expiration)
+		}
+		return v, er
+	})
+	return val, err
+}
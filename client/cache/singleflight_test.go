@@ -72,6 +72,18 @@ func testSingleflightCacheConcurrencyGet(t *testing.T, bm Cache) {
 	wg.Wait()
 }
 
+func TestSingleflightCacheSoftTTL(t *testing.T) {
+	testSoftTTL(t, func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error) {
+		return NewSingleflightCache(c, time.Minute, load, WithSoftTTL(100*time.Millisecond))
+	})
+}
+
+func TestSingleflightCacheNegativeCache(t *testing.T) {
+	testNegativeCache(t, func(c Cache, load func(ctx context.Context, key string) (any, error)) (Cache, error) {
+		return NewSingleflightCache(c, time.Minute, load, WithNegativeCache(200*time.Millisecond))
+	})
+}
+
 func ExampleNewSingleflightCache() {
 	c := NewMemoryCache()
 	c, err := NewSingleflightCache(c, time.Minute, func(ctx context.Context, key string) (any, error) {
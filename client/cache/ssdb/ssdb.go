@@ -20,6 +20,8 @@ type Cache struct {
 	conninfo []string
 }
 
+var _ cache.AtomicCache = (*Cache)(nil)
+
 // NewSsdbCache creates new ssdb adapter.
 func NewSsdbCache() cache.Cache {
 	return &Cache{}
@@ -114,6 +116,51 @@ func (rc *Cache) Decr(ctx context.Context, key string) error {
 	return berror.Wrapf(err, cache.SsdbCacheCurdFailed, "decrease failed: %s", key)
 }
 
+// IncrBy adds delta to a key's counter and returns the new value.
+func (rc *Cache) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	resp, err := rc.conn.Do("incr", key, delta)
+	if err != nil {
+		return 0, berror.Wrapf(err, cache.SsdbCacheCurdFailed, "incr failed: %s", key)
+	}
+	if len(resp) == 2 && resp[0] == "ok" {
+		val, err := strconv.ParseInt(resp[1], 10, 64)
+		if err != nil {
+			return 0, berror.Wrapf(err, cache.SsdbBadResponse, "the response from SSDB server is invalid: %v", resp)
+		}
+		return val, nil
+	}
+	return 0, berror.Errorf(cache.SsdbBadResponse, "the response from SSDB server is invalid: %v", resp)
+}
+
+// GetAndSet sets the value of key and returns the old value.
+// value: must be of type string.
+// SSDB has no transaction, the expiration is set by a following command if timeout is positive.
+// SSDB has no compare-and-swap either, so the adapter does not implement cache.CASCache.
+func (rc *Cache) GetAndSet(ctx context.Context, key string, val interface{}, timeout time.Duration) (interface{}, error) {
+	v, ok := val.(string)
+	if !ok {
+		return nil, berror.Errorf(cache.InvalidSsdbCacheValue, "value must be string: %v", val)
+	}
+	resp, err := rc.conn.Do("getset", key, v)
+	if err != nil {
+		return nil, berror.Wrapf(err, cache.SsdbCacheCurdFailed, "getset failed, key: %s", key)
+	}
+	var old interface{}
+	switch {
+	case len(resp) == 2 && resp[0] == "ok":
+		old = resp[1]
+	case len(resp) > 0 && resp[0] == "not_found":
+	default:
+		return nil, berror.Errorf(cache.SsdbBadResponse, "the response from SSDB server is invalid: %v", resp)
+	}
+	if ttl := int(timeout / time.Second); ttl > 0 {
+		if _, err = rc.conn.Do("expire", key, ttl); err != nil {
+			return old, berror.Wrapf(err, cache.SsdbCacheCurdFailed, "expire failed, key: %s", key)
+		}
+	}
+	return old, nil
+}
+
 // IsExist checks if a key exists in memcache.
 func (rc *Cache) IsExist(ctx context.Context, key string) (bool, error) {
 	resp, err := rc.conn.Do("exists", key)
@@ -101,6 +101,41 @@ func TestSsdbcacheCache(t *testing.T) {
 	assert.False(t, e2)
 }
 
+func TestAtomicCache(t *testing.T) {
+	ssdbAddr := os.Getenv("SSDB_ADDR")
+	if ssdbAddr == "" {
+		ssdbAddr = "127.0.0.1:8888"
+	}
+
+	bm, err := cache.NewCache("ssdb", fmt.Sprintf(`{"conn": "%s"}`, ssdbAddr))
+	assert.Nil(t, err)
+	ac, ok := bm.(cache.AtomicCache)
+	assert.True(t, ok)
+	_, ok = bm.(cache.CASCache)
+	assert.False(t, ok)
+	ctx := context.Background()
+
+	val, err := ac.IncrBy(ctx, "counter", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), val)
+	val, err = ac.IncrBy(ctx, "counter", -7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-2), val)
+
+	old, err := ac.GetAndSet(ctx, "key", "v1", 3*time.Second)
+	assert.Nil(t, err)
+	assert.Nil(t, old)
+	old, err = ac.GetAndSet(ctx, "key", "v2", 3*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", old)
+
+	_, err = ac.GetAndSet(ctx, "key", 1, 3*time.Second)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, bm.Delete(ctx, "counter"))
+	assert.Nil(t, bm.Delete(ctx, "key"))
+}
+
 func TestReadThroughCachessdbGet(t *testing.T) {
 	bm, err := cache.NewCache("ssdb", fmt.Sprintf(`{"conn": "%s"}`, "127.0.0.1:8888"))
 	assert.Nil(t, err)
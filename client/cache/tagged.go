@@ -0,0 +1,43 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// TaggedCache is an optional capability of Cache adapters which supports bulk invalidation.
+// The memory, file and redis adapters implement it. Use type assertion to detect it:
+//
+//	if tc, ok := c.(cache.TaggedCache); ok {
+//		_ = tc.PutWithTags(ctx, "user:42:profile", profile, time.Minute, "user:42")
+//		_ = tc.InvalidateTag(ctx, "user:42")
+//	}
+type TaggedCache interface {
+	Cache
+	// PutWithTags Set a cached value with key, expire time and tags.
+	PutWithTags(ctx context.Context, key string, val interface{}, timeout time.Duration, tags ...string) error
+	// InvalidateTag Delete all cached values associated with any of tags.
+	InvalidateTag(ctx context.Context, tags ...string) error
+	// DeleteByPrefix Delete all cached values whose key starts with prefix.
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+var (
+	_ TaggedCache = (*MemoryCache)(nil)
+	_ TaggedCache = (*ShardedMemoryCache)(nil)
+	_ TaggedCache = (*FileCache)(nil)
+)
@@ -0,0 +1,76 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTaggedCache(t *testing.T) {
+	testTaggedCache(t, NewMemoryCache())
+}
+
+func TestShardedMemoryTaggedCache(t *testing.T) {
+	testTaggedCache(t, NewShardedMemoryCache(WithShardCount(4)))
+}
+
+func TestFileTaggedCache(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "test_tagged_cache")
+	defer os.RemoveAll(dir)
+	fc := NewFileCache().(*FileCache)
+	fc.CachePath = dir
+	fc.FileSuffix = ".bin"
+	fc.DirectoryLevel = 2
+	assert.Nil(t, fc.Init())
+	testTaggedCache(t, fc)
+}
+
+func testTaggedCache(t *testing.T, c Cache) {
+	ctx := context.Background()
+	tc, ok := c.(TaggedCache)
+	assert.True(t, ok)
+
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:profile", "profile", time.Minute, "user:42"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:42:orders", "orders", time.Minute, "user:42", "orders"))
+	assert.Nil(t, tc.PutWithTags(ctx, "user:43:orders", "orders", time.Minute, "orders"))
+	assert.Nil(t, tc.Put(ctx, "user:43:profile", "profile", time.Minute))
+	assert.Nil(t, tc.Put(ctx, "item:1", "item", time.Minute))
+
+	assert.Nil(t, tc.InvalidateTag(ctx, "user:42"))
+	res, _ := tc.IsExist(ctx, "user:42:profile")
+	assert.False(t, res)
+	res, _ = tc.IsExist(ctx, "user:42:orders")
+	assert.False(t, res)
+	res, _ = tc.IsExist(ctx, "user:43:orders")
+	assert.True(t, res)
+
+	// re-putting a key without tags detaches it from its tags
+	assert.Nil(t, tc.Put(ctx, "user:43:orders", "orders", time.Minute))
+	assert.Nil(t, tc.InvalidateTag(ctx, "orders", "unknown"))
+	res, _ = tc.IsExist(ctx, "user:43:orders")
+	assert.True(t, res)
+
+	assert.Nil(t, tc.DeleteByPrefix(ctx, "user:"))
+	res, _ = tc.IsExist(ctx, "user:43:profile")
+	assert.False(t, res)
+	res, _ = tc.IsExist(ctx, "item:1")
+	assert.True(t, res)
+}
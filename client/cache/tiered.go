@@ -0,0 +1,303 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// DefaultTieredL1TTL is the expiration of the items promoted from L2 into L1
+var DefaultTieredL1TTL = time.Minute
+
+// Invalidation tells the TieredCache instances to drop their L1 copies.
+type Invalidation struct {
+	// Source is the id of the TieredCache which sends the message.
+	// An instance ignores the messages sent by itself.
+	Source string   `json:"source"`
+	Keys   []string `json:"keys,omitempty"`
+	// All means ClearAll
+	All bool `json:"all,omitempty"`
+}
+
+// InvalidationBus broadcasts invalidations between TieredCache instances,
+// for example through redis pub/sub.
+type InvalidationBus interface {
+	// Publish sends msg to all subscribers
+	Publish(ctx context.Context, msg Invalidation) error
+	// Subscribe calls handler for every message until ctx is done.
+	// It must not block the caller.
+	Subscribe(ctx context.Context, handler func(msg Invalidation)) error
+}
+
+// TieredCache is a two-level cache.
+// L1 is usually a local MemoryCache, and L2 is a shared remote cache like redis.
+// Reads go to L1 first and L2 hits are promoted into L1.
+// Writes go to L2 first, and then the L1 copies of all instances are invalidated through the InvalidationBus.
+type TieredCache struct {
+	l1     Cache
+	l2     Cache
+	l1TTL  time.Duration
+	l2TTL  time.Duration
+	bus    InvalidationBus
+	id     string
+	cancel context.CancelFunc
+}
+
+// TieredCacheOption configures a TieredCache created by NewTieredCache
+type TieredCacheOption func(*TieredCache)
+
+// WithTieredL1TTL sets the expiration of L1 items, default is DefaultTieredL1TTL.
+// The expiration passed to Put is used if it is shorter.
+func WithTieredL1TTL(d time.Duration) TieredCacheOption {
+	return func(c *TieredCache) {
+		c.l1TTL = d
+	}
+}
+
+// WithTieredL2TTL overrides the expiration passed to Put for L2 items
+func WithTieredL2TTL(d time.Duration) TieredCacheOption {
+	return func(c *TieredCache) {
+		c.l2TTL = d
+	}
+}
+
+// WithInvalidationBus sets the bus used to invalidate L1 across instances.
+// Without a bus, only the L1 of the current instance is invalidated.
+func WithInvalidationBus(bus InvalidationBus) TieredCacheOption {
+	return func(c *TieredCache) {
+		c.bus = bus
+	}
+}
+
+// NewTieredCache creates a TieredCache over l1 and l2.
+// Both caches must be started already. Call Close to stop listening the InvalidationBus.
+func NewTieredCache(l1, l2 Cache, opts ...TieredCacheOption) (*TieredCache, error) {
+	if l1 == nil || l2 == nil {
+		return nil, berror.Error(InvalidInitParameters, "l1 and l2 can not be nil")
+	}
+	c := &TieredCache{
+		l1:    l1,
+		l2:    l2,
+		l1TTL: DefaultTieredL1TTL,
+		id:    uuid.NewString(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.bus != nil {
+		var ctx context.Context
+		ctx, c.cancel = context.WithCancel(context.Background())
+		if err := c.bus.Subscribe(ctx, c.onInvalidation); err != nil {
+			c.cancel()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *TieredCache) onInvalidation(msg Invalidation) {
+	if msg.Source == c.id {
+		return
+	}
+	ctx := context.Background()
+	if msg.All {
+		_ = c.l1.ClearAll(ctx)
+		return
+	}
+	for _, key := range msg.Keys {
+		_ = c.l1.Delete(ctx, key)
+	}
+}
+
+// invalidate drops the local L1 copies and notifies the other instances
+func (c *TieredCache) invalidate(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if err := c.l1.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	if c.bus == nil {
+		return nil
+	}
+	return c.bus.Publish(ctx, Invalidation{Source: c.id, Keys: keys})
+}
+
+func (c *TieredCache) l1Expiration(timeout time.Duration) time.Duration {
+	if timeout > 0 && (c.l1TTL <= 0 || timeout < c.l1TTL) {
+		return timeout
+	}
+	return c.l1TTL
+}
+
+// Get reads L1 first, and then L2. A value found in L2 is promoted into L1.
+func (c *TieredCache) Get(ctx context.Context, key string) (interface{}, error) {
+	if val, err := c.l1.Get(ctx, key); err == nil && val != nil {
+		return val, nil
+	}
+	val, err := c.l2.Get(ctx, key)
+	if err != nil || val == nil {
+		return val, err
+	}
+	_ = c.l1.Put(ctx, key, val, c.l1TTL)
+	return val, nil
+}
+
+// GetMulti is a batch version of Get.
+func (c *TieredCache) GetMulti(ctx context.Context, keys []string) ([]interface{}, error) {
+	rc := make([]interface{}, len(keys))
+	keysErr := make([]string, 0)
+
+	for i, ki := range keys {
+		val, err := c.Get(ctx, ki)
+		if err != nil {
+			keysErr = append(keysErr, fmt.Sprintf("key [%s] error: %s", ki, err.Error()))
+			continue
+		}
+		rc[i] = val
+	}
+
+	if len(keysErr) == 0 {
+		return rc, nil
+	}
+	return rc, berror.Error(MultiGetFailed, strings.Join(keysErr, "; "))
+}
+
+// Put writes L2 and L1, and invalidates the L1 of the other instances.
+func (c *TieredCache) Put(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	l2Timeout := timeout
+	if c.l2TTL > 0 {
+		l2Timeout = c.l2TTL
+	}
+	if err := c.l2.Put(ctx, key, val, l2Timeout); err != nil {
+		return err
+	}
+	if err := c.l1.Put(ctx, key, val, c.l1Expiration(timeout)); err != nil {
+		return err
+	}
+	if c.bus == nil {
+		return nil
+	}
+	return c.bus.Publish(ctx, Invalidation{Source: c.id, Keys: []string{key}})
+}
+
+// Delete deletes the key from both tiers of all instances.
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// Incr increases the counter in L2 and invalidates the L1 copies.
+func (c *TieredCache) Incr(ctx context.Context, key string) error {
+	if err := c.l2.Incr(ctx, key); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// Decr decreases the counter in L2 and invalidates the L1 copies.
+func (c *TieredCache) Decr(ctx context.Context, key string) error {
+	if err := c.l2.Decr(ctx, key); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// IsExist checks L1 first, and then L2.
+func (c *TieredCache) IsExist(ctx context.Context, key string) (bool, error) {
+	if ok, err := c.l1.IsExist(ctx, key); err == nil && ok {
+		return true, nil
+	}
+	return c.l2.IsExist(ctx, key)
+}
+
+// ClearAll clears both tiers of all instances.
+func (c *TieredCache) ClearAll(ctx context.Context) error {
+	if err := c.l2.ClearAll(ctx); err != nil {
+		return err
+	}
+	if err := c.l1.ClearAll(ctx); err != nil {
+		return err
+	}
+	if c.bus == nil {
+		return nil
+	}
+	return c.bus.Publish(ctx, Invalidation{Source: c.id, All: true})
+}
+
+// StartAndGC does nothing, the tiers should be started before creating the TieredCache.
+func (c *TieredCache) StartAndGC(config string) error {
+	return nil
+}
+
+// Close stops listening the InvalidationBus
+func (c *TieredCache) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
+
+// LocalInvalidationBus is an in-process InvalidationBus.
+// It is useful for tests, or for several TieredCache instances in the same process.
+type LocalInvalidationBus struct {
+	mutex    sync.RWMutex
+	handlers map[int]func(msg Invalidation)
+	nextID   int
+}
+
+// NewLocalInvalidationBus creates a LocalInvalidationBus
+func NewLocalInvalidationBus() *LocalInvalidationBus {
+	return &LocalInvalidationBus{handlers: make(map[int]func(msg Invalidation))}
+}
+
+// Publish delivers msg to all subscribers synchronously
+func (b *LocalInvalidationBus) Publish(ctx context.Context, msg Invalidation) error {
+	b.mutex.RLock()
+	handlers := make([]func(msg Invalidation), 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mutex.RUnlock()
+	for _, h := range handlers {
+		h(msg)
+	}
+	return nil
+}
+
+// Subscribe registers handler until ctx is done
+func (b *LocalInvalidationBus) Subscribe(ctx context.Context, handler func(msg Invalidation)) error {
+	b.mutex.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mutex.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mutex.Lock()
+		delete(b.handlers, id)
+		b.mutex.Unlock()
+	}()
+	return nil
+}
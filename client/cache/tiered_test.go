@@ -0,0 +1,110 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTieredCache(t *testing.T) {
+	_, err := NewTieredCache(nil, NewMemoryCache())
+	assert.NotNil(t, err)
+	_, err = NewTieredCache(NewMemoryCache(), nil)
+	assert.NotNil(t, err)
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	l1 := NewMemoryCache()
+	l2 := NewMemoryCache()
+	c, err := NewTieredCache(l1, l2, WithTieredL1TTL(time.Second), WithTieredL2TTL(time.Minute))
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.Put(ctx, "key", "value", 10*time.Second))
+	val, err := l1.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+
+	// L1 expires earlier, and the value is promoted from L2 again
+	time.Sleep(1500 * time.Millisecond)
+	res, _ := l1.IsExist(ctx, "key")
+	assert.False(t, res)
+	val, err = c.Get(ctx, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", val)
+	res, _ = l1.IsExist(ctx, "key")
+	assert.True(t, res)
+
+	assert.Nil(t, l2.Put(ctx, "counter", 1, 0))
+	assert.Nil(t, c.Incr(ctx, "counter"))
+	val, _ = c.Get(ctx, "counter")
+	assert.Equal(t, 2, val)
+	assert.Nil(t, c.Decr(ctx, "counter"))
+	val, _ = c.Get(ctx, "counter")
+	assert.Equal(t, 1, val)
+
+	vals, err := c.GetMulti(ctx, []string{"key", "counter", "missing"})
+	assert.NotNil(t, err)
+	assert.Equal(t, []interface{}{"value", 1, nil}, vals)
+
+	assert.Nil(t, c.Delete(ctx, "key"))
+	res, _ = c.IsExist(ctx, "key")
+	assert.False(t, res)
+
+	assert.Nil(t, c.ClearAll(ctx))
+	res, _ = c.IsExist(ctx, "counter")
+	assert.False(t, res)
+}
+
+func TestTieredCacheInvalidation(t *testing.T) {
+	ctx := context.Background()
+	bus := NewLocalInvalidationBus()
+	l2 := NewMemoryCache()
+	l1a := NewMemoryCache()
+	l1b := NewMemoryCache()
+	a, err := NewTieredCache(l1a, l2, WithInvalidationBus(bus))
+	assert.Nil(t, err)
+	defer a.Close()
+	b, err := NewTieredCache(l1b, l2, WithInvalidationBus(bus))
+	assert.Nil(t, err)
+	defer b.Close()
+
+	assert.Nil(t, a.Put(ctx, "key", "v1", time.Minute))
+	val, _ := b.Get(ctx, "key")
+	assert.Equal(t, "v1", val)
+
+	// b's L1 copy is invalidated by a's update, while a keeps its own copy
+	assert.Nil(t, a.Put(ctx, "key", "v2", time.Minute))
+	res, _ := l1b.IsExist(ctx, "key")
+	assert.False(t, res)
+	res, _ = l1a.IsExist(ctx, "key")
+	assert.True(t, res)
+	val, _ = b.Get(ctx, "key")
+	assert.Equal(t, "v2", val)
+
+	assert.Nil(t, a.Delete(ctx, "key"))
+	res, _ = b.IsExist(ctx, "key")
+	assert.False(t, res)
+
+	assert.Nil(t, b.Put(ctx, "key", "v3", time.Minute))
+	_, _ = a.Get(ctx, "key")
+	assert.Nil(t, b.ClearAll(ctx))
+	res, _ = l1a.IsExist(ctx, "key")
+	assert.False(t, res)
+}
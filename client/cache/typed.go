@@ -0,0 +1,192 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/jialequ/android-sdk/core/berror"
+)
+
+// Codec converts values to and from the bytes stored by TypedCache
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// GobCodec encodes values with encoding/gob
+type GobCodec struct{}
+
+// Marshal encodes v by gob
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data by gob into v
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// JSONCodec encodes values with encoding/json
+type JSONCodec struct{}
+
+// Marshal encodes v by json
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes data by json into v
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// MsgpackCodec encodes values with MessagePack
+type MsgpackCodec struct{}
+
+// Marshal encodes v by MessagePack
+func (MsgpackCodec) Marshal(v any) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal decodes data by MessagePack into v
+func (MsgpackCodec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// TypedCache wraps a Cache and stores values of type T encoded by a Codec.
+// It composes with the other decorators through TypedLoadFunc, for example:
+//
+//	codec := cache.JSONCodec{}
+//	rt, _ := cache.NewReadThroughCache(mem, time.Minute, cache.TypedLoadFunc(codec, loadUser))
+//	users := cache.NewTypedCache[*User](rt, codec)
+//	u, err := users.Get(ctx, "42")
+type TypedCache[T any] struct {
+	Cache Cache
+	codec Codec
+}
+
+// NewTypedCache creates a TypedCache. If codec is nil, GobCodec is used.
+func NewTypedCache[T any](c Cache, codec Codec) *TypedCache[T] {
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	return &TypedCache[T]{Cache: c, codec: codec}
+}
+
+// Get returns the decoded value of key.
+// It returns ErrKeyNotExist if the underlying cache returns nil value.
+func (c *TypedCache[T]) Get(ctx context.Context, key string) (T, error) {
+	var res T
+	val, err := c.Cache.Get(ctx, key)
+	if err != nil && val == nil {
+		return res, err
+	}
+	if val == nil {
+		return res, ErrKeyNotExist
+	}
+	if res, err = c.decode(val); err != nil {
+		return res, berror.Wrapf(err, CodecFailed, "could not decode the value of key: %s", key)
+	}
+	return res, nil
+}
+
+// GetMulti is a batch version of Get.
+// The missing values are the zero value of T.
+func (c *TypedCache[T]) GetMulti(ctx context.Context, keys []string) ([]T, error) {
+	vals, err := c.Cache.GetMulti(ctx, keys)
+	if err != nil && vals == nil {
+		return nil, err
+	}
+	res := make([]T, len(keys))
+	for i, val := range vals {
+		if val == nil || i >= len(res) {
+			continue
+		}
+		v, er := c.decode(val)
+		if er != nil {
+			return nil, berror.Wrapf(er, CodecFailed, "could not decode the value of key: %s", keys[i])
+		}
+		res[i] = v
+	}
+	return res, err
+}
+
+// Put encodes val and puts it into the underlying cache
+func (c *TypedCache[T]) Put(ctx context.Context, key string, val T, timeout time.Duration) error {
+	data, err := c.codec.Marshal(val)
+	if err != nil {
+		return berror.Wrapf(err, CodecFailed, "could not encode the value of key: %s", key)
+	}
+	return c.Cache.Put(ctx, key, data, timeout)
+}
+
+// Delete deletes the key from the underlying cache
+func (c *TypedCache[T]) Delete(ctx context.Context, key string) error {
+	return c.Cache.Delete(ctx, key)
+}
+
+// IsExist checks if the key exists in the underlying cache
+func (c *TypedCache[T]) IsExist(ctx context.Context, key string) (bool, error) {
+	return c.Cache.IsExist(ctx, key)
+}
+
+func (c *TypedCache[T]) decode(val any) (T, error) {
+	var res T
+	var data []byte
+	switch v := val.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return res, fmt.Errorf("unexpected type %T, the value is not encoded by TypedCache", val)
+	}
+	err := c.codec.Unmarshal(data, &res)
+	return res, err
+}
+
+// TypedLoadFunc adapts a typed load function to the decorators like ReadThroughCache,
+// SingleflightCache and BloomFilterCache, so that the loaded values are stored encoded by codec
+// and can be read by a TypedCache wrapping the decorator.
+func TypedLoadFunc[T any](codec Codec, fn func(ctx context.Context, key string) (T, error)) func(ctx context.Context, key string) (any, error) {
+	if fn == nil {
+		return nil
+	}
+	if codec == nil {
+		codec = GobCodec{}
+	}
+	return func(ctx context.Context, key string) (any, error) {
+		val, err := fn(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		data, err := codec.Marshal(val)
+		if err != nil {
+			return nil, berror.Wrapf(err, CodecFailed, "could not encode the value of key: %s", key)
+		}
+		return data, nil
+	}
+}
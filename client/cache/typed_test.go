@@ -0,0 +1,108 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedUser struct {
+	Id   int
+	Name string
+}
+
+func TestTypedCache(t *testing.T) {
+	codecs := map[string]Codec{
+		"gob":     GobCodec{},
+		"json":    JSONCodec{},
+		"msgpack": MsgpackCodec{},
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewTypedCache[*typedUser](NewMemoryCache(), codec)
+			assert.Nil(t, c.Put(ctx, "1", &typedUser{Id: 1, Name: "Tom"}, time.Minute))
+			assert.Nil(t, c.Put(ctx, "2", &typedUser{Id: 2, Name: "Jerry"}, time.Minute))
+
+			u, err := c.Get(ctx, "1")
+			assert.Nil(t, err)
+			assert.Equal(t, &typedUser{Id: 1, Name: "Tom"}, u)
+
+			_, err = c.Get(ctx, "3")
+			assert.Equal(t, ErrKeyNotExist, err)
+
+			us, err := c.GetMulti(ctx, []string{"1", "3", "2"})
+			assert.NotNil(t, err)
+			assert.Equal(t, []*typedUser{{Id: 1, Name: "Tom"}, nil, {Id: 2, Name: "Jerry"}}, us)
+
+			assert.Nil(t, c.Delete(ctx, "1"))
+			res, _ := c.IsExist(ctx, "1")
+			assert.False(t, res)
+		})
+	}
+}
+
+func TestTypedCacheInvalidValue(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemoryCache()
+	assert.Nil(t, mem.Put(ctx, "key", 1, time.Minute))
+	c := NewTypedCache[string](mem, nil)
+	_, err := c.Get(ctx, "key")
+	assert.NotNil(t, err)
+}
+
+func TestTypedCacheDecorators(t *testing.T) {
+	codec := JSONCodec{}
+	load := TypedLoadFunc(codec, func(ctx context.Context, key string) (*typedUser, error) {
+		if key == "404" {
+			return nil, errors.New("not found")
+		}
+		return &typedUser{Id: 1, Name: key}, nil
+	})
+
+	rt, err := NewReadThroughCache(NewMemoryCache(), time.Minute, load)
+	assert.Nil(t, err)
+	sf, err := NewSingleflightCache(NewMemoryCache(), time.Minute, load)
+	assert.Nil(t, err)
+	bf, err := NewBloomFilterCache(NewMemoryCache(), load, &AlwaysExist{}, time.Minute)
+	assert.Nil(t, err)
+
+	for name, c := range map[string]Cache{"read through": rt, "singleflight": sf, "bloom filter": bf} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			users := NewTypedCache[*typedUser](c, codec)
+			u, err := users.Get(ctx, "Tom")
+			assert.Nil(t, err)
+			assert.Equal(t, &typedUser{Id: 1, Name: "Tom"}, u)
+
+			// the loaded value is stored encoded
+			u, err = users.Get(ctx, "Tom")
+			assert.Nil(t, err)
+			assert.Equal(t, "Tom", u.Name)
+
+			_, err = users.Get(ctx, "404")
+			assert.NotNil(t, err)
+		})
+	}
+
+	_, err = NewReadThroughCache(NewMemoryCache(), time.Minute,
+		TypedLoadFunc[string](codec, nil))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,299 @@
+// Package expr provides the sql expressions which can be used in
+// QuerySeter.Update, Filter, OrderClauses and Annotate.
+//
+// The columns are resolved by the orm like the expressions of Filter,
+// and the string literals are always passed as the args of the query.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// Builder writes the sql of an Expr
+type Builder interface {
+	// WriteColumn writes the column of the field name, such as "score" or "user__profile__age"
+	WriteColumn(name string)
+	// WriteArg writes the placeholder of arg
+	WriteArg(arg interface{})
+	// WriteString writes s as it is
+	WriteString(s string)
+}
+
+// Expr is a sql expression
+type Expr interface {
+	Build(b Builder)
+}
+
+var funcName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type column string
+
+// Col refers to the column of the field name, the related fields are separated by "__"
+func Col(name string) Expr {
+	return column(name)
+}
+
+func (c column) Build(b Builder) {
+	b.WriteColumn(string(c))
+}
+
+type value struct {
+	v interface{}
+}
+
+// Value is a literal, the numbers are written in the sql,
+// and the others are passed as the args of the query.
+func Value(v interface{}) Expr {
+	return value{v: v}
+}
+
+func (v value) Build(b Builder) {
+	if v.v == nil {
+		b.WriteString("NULL")
+		return
+	}
+	var s string
+	val := reflect.ValueOf(v.v)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s = strconv.FormatInt(val.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = strconv.FormatUint(val.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		f := val.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			b.WriteArg(v.v)
+			return
+		}
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		b.WriteArg(v.v)
+		return
+	}
+	// the negative number is wrapped to keep it from becoming a comment, such as "a --1"
+	if s[0] == '-' {
+		s = "(" + s + ")"
+	}
+	b.WriteString(s)
+}
+
+// toExpr takes v as a Value if it is not an Expr
+func toExpr(v interface{}) Expr {
+	if e, ok := v.(Expr); ok {
+		return e
+	}
+	return Value(v)
+}
+
+type binary struct {
+	op          string
+	left, right Expr
+}
+
+func (e binary) Build(b Builder) {
+	b.WriteString("(")
+	e.left.Build(b)
+	b.WriteString(" " + e.op + " ")
+	e.right.Build(b)
+	b.WriteString(")")
+}
+
+func newBinary(op string, left, right interface{}) Expr {
+	return binary{op: op, left: toExpr(left), right: toExpr(right)}
+}
+
+// Add is left + right, the operands which are not Expr are taken as Value
+func Add(left, right interface{}) Expr {
+	return newBinary("+", left, right)
+}
+
+// Sub is left - right
+func Sub(left, right interface{}) Expr {
+	return newBinary("-", left, right)
+}
+
+// Mul is left * right
+func Mul(left, right interface{}) Expr {
+	return newBinary("*", left, right)
+}
+
+// Div is left / right
+func Div(left, right interface{}) Expr {
+	return newBinary("/", left, right)
+}
+
+// Eq is left = right
+func Eq(left, right interface{}) Expr {
+	return newBinary("=", left, right)
+}
+
+// Ne is left != right
+func Ne(left, right interface{}) Expr {
+	return newBinary("!=", left, right)
+}
+
+// Gt is left > right
+func Gt(left, right interface{}) Expr {
+	return newBinary(">", left, right)
+}
+
+// Gte is left >= right
+func Gte(left, right interface{}) Expr {
+	return newBinary(">=", left, right)
+}
+
+// Lt is left < right
+func Lt(left, right interface{}) Expr {
+	return newBinary("<", left, right)
+}
+
+// Lte is left <= right
+func Lte(left, right interface{}) Expr {
+	return newBinary("<=", left, right)
+}
+
+type logic struct {
+	op    string
+	conds []Expr
+}
+
+func (e logic) Build(b Builder) {
+	b.WriteString("(")
+	for i, cond := range e.conds {
+		if i > 0 {
+			b.WriteString(" " + e.op + " ")
+		}
+		cond.Build(b)
+	}
+	b.WriteString(")")
+}
+
+// And joins the conditions by AND
+func And(conds ...Expr) Expr {
+	if len(conds) == 0 {
+		panic(fmt.Errorf("expr: And needs at least one condition"))
+	}
+	return logic{op: "AND", conds: conds}
+}
+
+// Or joins the conditions by OR
+func Or(conds ...Expr) Expr {
+	if len(conds) == 0 {
+		panic(fmt.Errorf("expr: Or needs at least one condition"))
+	}
+	return logic{op: "OR", conds: conds}
+}
+
+type unary struct {
+	prefix, suffix string
+	e              Expr
+}
+
+func (e unary) Build(b Builder) {
+	b.WriteString("(" + e.prefix)
+	e.e.Build(b)
+	b.WriteString(e.suffix + ")")
+}
+
+// Not is NOT cond
+func Not(cond Expr) Expr {
+	return unary{prefix: "NOT ", e: cond}
+}
+
+// IsNull is e IS NULL
+func IsNull(e Expr) Expr {
+	return unary{suffix: " IS NULL", e: e}
+}
+
+// IsNotNull is e IS NOT NULL
+func IsNotNull(e Expr) Expr {
+	return unary{suffix: " IS NOT NULL", e: e}
+}
+
+type when struct {
+	cond, then Expr
+}
+
+// CaseExpr is CASE WHEN ... THEN ... ELSE ... END, see Case
+type CaseExpr struct {
+	whens []when
+	els   Expr
+}
+
+// Case starts a CASE expression, such as
+//
+//	expr.Case().When(expr.Gt(expr.Col("score"), 90), "A").Else("B")
+func Case() *CaseExpr {
+	return &CaseExpr{}
+}
+
+// When adds WHEN cond THEN then
+func (c *CaseExpr) When(cond Expr, then interface{}) *CaseExpr {
+	c.whens = append(c.whens, when{cond: cond, then: toExpr(then)})
+	return c
+}
+
+// Else sets ELSE v
+func (c *CaseExpr) Else(v interface{}) *CaseExpr {
+	c.els = toExpr(v)
+	return c
+}
+
+func (c *CaseExpr) Build(b Builder) {
+	if len(c.whens) == 0 {
+		panic(fmt.Errorf("expr: Case needs at least one When"))
+	}
+	b.WriteString("CASE")
+	for _, w := range c.whens {
+		b.WriteString(" WHEN ")
+		w.cond.Build(b)
+		b.WriteString(" THEN ")
+		w.then.Build(b)
+	}
+	if c.els != nil {
+		b.WriteString(" ELSE ")
+		c.els.Build(b)
+	}
+	b.WriteString(" END")
+}
+
+type function struct {
+	name string
+	args []Expr
+}
+
+// Func calls the sql function name with args, the args which are not Expr are taken as Value.
+// It panics if name is not a valid identifier.
+func Func(name string, args ...interface{}) Expr {
+	if !funcName.MatchString(name) {
+		panic(fmt.Errorf("expr: wrong function name `%s`", name))
+	}
+	f := function{name: name, args: make([]Expr, 0, len(args))}
+	for _, arg := range args {
+		f.args = append(f.args, toExpr(arg))
+	}
+	return f
+}
+
+func (f function) Build(b Builder) {
+	b.WriteString(f.name + "(")
+	for i, arg := range f.args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		arg.Build(b)
+	}
+	b.WriteString(")")
+}
+
+// Coalesce is COALESCE(args...)
+func Coalesce(args ...interface{}) Expr {
+	if len(args) == 0 {
+		panic(fmt.Errorf("expr: Coalesce needs at least one arg"))
+	}
+	return Func("COALESCE", args...)
+}
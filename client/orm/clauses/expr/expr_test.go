@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBuilder struct {
+	buf  strings.Builder
+	args []interface{}
+}
+
+func (b *testBuilder) WriteColumn(name string) {
+	b.buf.WriteString("`" + name + "`")
+}
+
+func (b *testBuilder) WriteArg(arg interface{}) {
+	b.buf.WriteString("?")
+	b.args = append(b.args, arg)
+}
+
+func (b *testBuilder) WriteString(s string) {
+	b.buf.WriteString(s)
+}
+
+func build(e Expr) (string, []interface{}) {
+	b := &testBuilder{}
+	e.Build(b)
+	return b.buf.String(), b.args
+}
+
+func TestBuild(t *testing.T) {
+	testCases := []struct {
+		name string
+		e    Expr
+		sql  string
+		args []interface{}
+	}{
+		{
+			name: "arithmetic",
+			e:    Add(Mul(Col("score"), 0.9), Col("bonus")),
+			sql:  "((`score` * 0.9) + `bonus`)",
+		},
+		{
+			name: "negative",
+			e:    Sub(Col("score"), -1),
+			sql:  "(`score` - (-1))",
+		},
+		{
+			name: "negative float",
+			e:    Div(Col("score"), -0.5),
+			sql:  "(`score` / (-0.5))",
+		},
+		{
+			name: "string arg",
+			e:    Eq(Col("name"), "x' OR 1=1"),
+			sql:  "(`name` = ?)",
+			args: []interface{}{"x' OR 1=1"},
+		},
+		{
+			name: "NaN arg",
+			e:    Add(Col("score"), math.NaN()),
+			sql:  "(`score` + ?)",
+		},
+		{
+			name: "case",
+			e: Case().When(Gte(Col("score"), 90), "A").
+				When(And(Gte(Col("score"), 60), Not(IsNull(Col("bonus")))), "B").
+				Else("C"),
+			sql:  "CASE WHEN (`score` >= 90) THEN ? WHEN ((`score` >= 60) AND (NOT (`bonus` IS NULL))) THEN ? ELSE ? END",
+			args: []interface{}{"A", "B", "C"},
+		},
+		{
+			name: "coalesce",
+			e:    Coalesce(Col("nick"), Col("name"), "anonymous"),
+			sql:  "COALESCE(`nick`, `name`, ?)",
+			args: []interface{}{"anonymous"},
+		},
+		{
+			name: "func",
+			e:    Func("LOWER", Col("user__name")),
+			sql:  "LOWER(`user__name`)",
+		},
+		{
+			name: "or",
+			e:    Or(Lt(Col("a"), 1), IsNotNull(Col("b")), Value(nil)),
+			sql:  "((`a` < 1) OR (`b` IS NOT NULL) OR NULL)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args := build(tc.e)
+			assert.Equal(t, tc.sql, sql)
+			if tc.name == "NaN arg" {
+				assert.Len(t, args, 1)
+				return
+			}
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	assert.Panics(t, func() {
+		Func("LOWER(name); DROP TABLE user; --")
+	})
+	assert.Panics(t, func() {
+		build(Case())
+	})
+	assert.Panics(t, func() {
+		Coalesce()
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/jialequ/android-sdk/client/orm/clauses"
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 )
 
 type Sort int8
@@ -20,6 +21,7 @@ type Order struct {
 	column string
 	sort   Sort
 	isRaw  bool
+	expr   expr.Expr
 }
 
 func Clause(options ...Option) *Order {
@@ -54,6 +56,10 @@ func (o *Order) IsRaw() bool {
 	return o.isRaw
 }
 
+func (o *Order) GetExpr() expr.Expr {
+	return o.expr
+}
+
 func ParseOrder(expressions ...string) []*Order {
 	var orders []*Order
 	for _, expression := range expressions {
@@ -102,3 +108,10 @@ func Raw() Option {
 		order.isRaw = true
 	}
 }
+
+// Expr orders by the expression e instead of the column
+func Expr(e expr.Expr) Option {
+	return func(order *Order) {
+		order.expr = e
+	}
+}
@@ -2,6 +2,8 @@ package order_clause
 
 import (
 	"testing"
+
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 )
 
 func TestClause(t *testing.T) {
@@ -135,3 +137,15 @@ func TestSortString(t *testing.T) {
 		t.Errorf(template, o3.SortString(), ``)
 	}
 }
+
+func TestExpr(t *testing.T) {
+	e := expr.Col("a")
+	o := Clause(
+		Expr(e),
+		SortDescending(),
+	)
+
+	if o.GetExpr() != e || o.GetSort() != Descending {
+		t.Error()
+	}
+}
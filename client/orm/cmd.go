@@ -19,7 +19,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/jialequ/android-sdk/client/orm/internal/utils"
 
@@ -37,9 +40,10 @@ var commands = make(map[string]commander)
 func printHelp(errs ...string) {
 	content := `orm command usage:
 
-    syncdb     - auto create tables
-    sqlall     - print sql of create tables
-    help       - print this help
+    syncdb         - auto create tables
+    sqlall         - print sql of create tables
+    makemigration  - generate a migration from the difference between models and database
+    help           - print this help
 `
 
 	if len(errs) > 0 {
@@ -110,7 +114,8 @@ func (d *commandSyncDb) Run() error { // NOSONAR
 		}
 	}
 
-	db := d.al.DB
+	// inspect the schema on the primary, the replicas may lag behind
+	db := d.al.DB.primary()
 
 	if d.force && len(drops) > 0 {
 		for i, mi := range defaultModelCache.AllOrdered() {
@@ -280,9 +285,76 @@ func (d *commandSQLAll) Run() error {
 	return nil
 }
 
+var migrationName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// migration generation commander interface implement.
+type commandMakeMigration struct {
+	al      *alias
+	name    string
+	dir     string
+	verbose bool
+}
+
+// Parse orm command line arguments.
+func (d *commandMakeMigration) Parse(args []string) {
+	var name string
+
+	flagSet := flag.NewFlagSet("orm command: makemigration", flag.ExitOnError)
+	flagSet.StringVar(&name, "db", "default", "DataBase alias name")
+	flagSet.StringVar(&d.name, "name", "AutoMigration", "migration name, the file is named as timestamp_name.go")
+	flagSet.StringVar(&d.dir, "dir", "database/migrations", "directory of the migration files")
+	flagSet.BoolVar(&d.verbose, "v", false, "verbose info")
+	flagSet.Parse(args)
+
+	d.al = getDbAlias(name)
+}
+
+// Run orm line command.
+// The schema of the database is compared with the models by GetColumns and IndexExists, the created tables,
+// added, renamed, dropped and type changed columns, created and dropped indexes are written to a migration file
+// with both Up and Down steps. A new column and a dropped column of the same type are taken as a rename.
+// The file should be reviewed before running it, the dropped columns are added back as nullable by Down.
+func (d *commandMakeMigration) Run() error {
+	if !migrationName.MatchString(d.name) {
+		return fmt.Errorf("migration name `%s` must be a valid go identifier", d.name)
+	}
+
+	// inspect the schema on the primary, the replicas may lag behind
+	changes, err := getMigrationChanges(context.Background(), d.al, d.al.DB.primary())
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("no changes detected")
+		return nil
+	}
+	for _, c := range changes {
+		if c.skipped {
+			fmt.Printf("skip: %s, it's left as a TODO\n", c.desc)
+			continue
+		}
+		fmt.Println(c.desc)
+		if d.verbose {
+			fmt.Printf(literal_5270, c.up)
+		}
+	}
+
+	now := time.Now()
+	file := filepath.Join(d.dir, fmt.Sprintf("%s_%s.go", now.Format(migrationDateFormat), strings.ToLower(d.name)))
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, []byte(renderMigration(d.name, now, changes)), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("migration is written to %s\n", file)
+	return nil
+}
+
 func init() {
 	commands["syncdb"] = new(commandSyncDb)
 	commands["sqlall"] = new(commandSQLAll)
+	commands["makemigration"] = new(commandMakeMigration)
 }
 
 // RunSyncdb run syncdb command line.
@@ -0,0 +1,370 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+)
+
+// migrationDateFormat is the same as migration.DateFormat
+const migrationDateFormat = "20060102_150405"
+
+// schemaChange is one step of a generated migration, down reverts up.
+type schemaChange struct {
+	desc string
+	up   string
+	down string
+	// skipped is the change can't be made by sql, it's left in the migration as a TODO
+	skipped bool
+}
+
+var (
+	checkConstraint = regexp.MustCompile(`\s+check\s*\(.*\)$`)
+	typeLength      = regexp.MustCompile(`\(\s*\d+\s*(,\s*\d+\s*)?\)`)
+	intWidth        = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+)
+
+// normalizeColumnType makes the column types reported by the database comparable with the ones of getColumnTyp.
+func normalizeColumnType(driver DriverType, typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	typ = checkConstraint.ReplaceAllString(typ, "")
+	typ = strings.Join(strings.Fields(typ), " ")
+	typ = strings.ReplaceAll(typ, ", ", ",")
+
+	switch driver {
+	case DRMySQL, DRTiDB:
+		if typ == "tinyint(1)" || typ == "boolean" {
+			return "bool"
+		}
+		typ = intWidth.ReplaceAllString(typ, "$1")
+		typ = strings.Replace(typ, "integer", "int", 1)
+		typ = strings.Replace(typ, "double precision", "double", 1)
+		typ = strings.Replace(typ, "numeric", "decimal", 1)
+	case DRPostgres:
+		// information_schema.columns.data_type has no length
+		typ = typeLength.ReplaceAllString(typ, "")
+		switch typ {
+		case "varchar":
+			typ = "character varying"
+		case "char":
+			typ = "character"
+		case "bool":
+			typ = "boolean"
+		case "timestamptz":
+			typ = "timestamp with time zone"
+		}
+	}
+	return typ
+}
+
+// liveNotNull reports whether the column got from GetColumns is NOT NULL
+func liveNotNull(driver DriverType, col [3]string) bool {
+	if driver == DRSqlite {
+		return col[2] == "1"
+	}
+	return strings.EqualFold(col[2], "NO")
+}
+
+// getMigrationChanges diffs the models against the schema of the database,
+// it returns the steps to bring the database to the models.
+func getMigrationChanges(ctx context.Context, al *alias, db dbQuerier) ([]schemaChange, error) { // NOSONAR
+	switch al.Driver {
+	case DRMySQL, DRTiDB, DRPostgres, DRSqlite:
+	default:
+		return nil, fmt.Errorf("`%s` nonsupport generating migrations", al.DriverName)
+	}
+
+	createQueries, indexes, err := getDbCreateSQL(defaultModelCache, al)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := al.DbBaser.GetTables(db)
+	if err != nil {
+		return nil, err
+	}
+
+	Q := al.DbBaser.TableQuote()
+	var changes []schemaChange
+	for i, mi := range defaultModelCache.AllOrdered() {
+		if !models.IsApplicableTableForDB(mi.AddrField, al.Name) {
+			continue
+		}
+
+		if !tables[mi.Table] {
+			queries := []string{stripSQLComments(createQueries[i])}
+			for _, idx := range indexes[mi.Table] {
+				queries = append(queries, idx.SQL)
+			}
+			changes = append(changes, schemaChange{
+				desc: fmt.Sprintf("create table `%s`", mi.Table),
+				up:   strings.Join(queries, "\n"),
+				down: fmt.Sprintf("DROP TABLE %s%s%s", Q, mi.Table, Q),
+			})
+			continue
+		}
+
+		columns, err := al.DbBaser.GetColumns(ctx, db, mi.Table)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, getTableChanges(ctx, al, db, mi, columns, indexes[mi.Table])...)
+	}
+	return changes, nil
+}
+
+// getTableChanges diffs the columns and indexes of an existing table
+func getTableChanges(ctx context.Context, al *alias, db dbQuerier, mi *models.ModelInfo, columns map[string][3]string, indexes []dbIndex) []schemaChange { // NOSONAR
+	Q := al.DbBaser.TableQuote()
+	table := Q + mi.Table + Q
+
+	var added []*models.FieldInfo
+	var changed []schemaChange
+	for _, fi := range mi.Fields.FieldsDB {
+		col, ok := columns[fi.Column]
+		if !ok {
+			added = append(added, fi)
+			continue
+		}
+		if fi.Auto || fi.Pk {
+			continue
+		}
+		typ := getColumnTyp(al, fi)
+		if fi.DBType != "" {
+			typ = fi.DBType
+		}
+		if normalizeColumnType(al.Driver, typ) == normalizeColumnType(al.Driver, col[1]) {
+			continue
+		}
+		if al.Driver == DRSqlite {
+			changed = append(changed, schemaChange{
+				desc: fmt.Sprintf("type of column `%s` in table `%s` is changed from `%s` to `%s`, sqlite can not alter it",
+					fi.Column, mi.Table, col[1], typ),
+				skipped: true,
+			})
+			continue
+		}
+		changed = append(changed, schemaChange{
+			desc: fmt.Sprintf("alter type of column `%s` in table `%s`", fi.Column, mi.Table),
+			up:   getColumnModifyQuery(al, mi.Table, fi.Column, typ, !fi.Null),
+			down: getColumnModifyQuery(al, mi.Table, fi.Column, col[1], liveNotNull(al.Driver, col)),
+		})
+	}
+
+	var dropped []string
+	for name := range columns {
+		if mi.Fields.GetByColumn(name) == nil {
+			dropped = append(dropped, name)
+		}
+	}
+	sort.Strings(dropped)
+
+	// a new column and a dropped column of the same type are taken as a rename,
+	// only when the type matches exactly one of each.
+	var changes []schemaChange
+	renamed := make(map[string]bool)
+	for _, fi := range added {
+		typ := normalizeColumnType(al.Driver, getColumnTyp(al, fi))
+		var from []string
+		for _, name := range dropped {
+			if normalizeColumnType(al.Driver, columns[name][1]) == typ {
+				from = append(from, name)
+			}
+		}
+		var to int
+		for _, other := range added {
+			if normalizeColumnType(al.Driver, getColumnTyp(al, other)) == typ {
+				to++
+			}
+		}
+		if len(from) != 1 || to != 1 || renamed[from[0]] {
+			continue
+		}
+		renamed[from[0]] = true
+		renamed[fi.Column] = true
+		changes = append(changes, schemaChange{
+			desc: fmt.Sprintf("rename column `%s` to `%s` in table `%s`", from[0], fi.Column, mi.Table),
+			up:   fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s%s%s TO %s%s%s", table, Q, from[0], Q, Q, fi.Column, Q),
+			down: fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s%s%s TO %s%s%s", table, Q, fi.Column, Q, Q, from[0], Q),
+		})
+	}
+
+	for _, fi := range added {
+		if renamed[fi.Column] {
+			continue
+		}
+		changes = append(changes, schemaChange{
+			desc: fmt.Sprintf("add column `%s` to table `%s`", fi.Column, mi.Table),
+			up:   getColumnAddQuery(al, fi),
+			down: fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s%s%s", table, Q, fi.Column, Q),
+		})
+	}
+	changes = append(changes, changed...)
+
+	// indexes are named by getDbCreateSQL as table_col1_col2, the single column ones of
+	// the existing columns are checked to find the dropped indexes.
+	wanted := make(map[string]bool, len(indexes))
+	for _, idx := range indexes {
+		wanted[idx.Name] = true
+		if !al.DbBaser.IndexExists(ctx, db, idx.Table, idx.Name) {
+			changes = append(changes, schemaChange{
+				desc: fmt.Sprintf("create index `%s` for table `%s`", idx.Name, mi.Table),
+				up:   idx.SQL,
+				down: getIndexDropQuery(al, mi.Table, idx.Name),
+			})
+		}
+	}
+	var dropIndexes []schemaChange
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		idx := mi.Table + "_" + name
+		if wanted[idx] || !al.DbBaser.IndexExists(ctx, db, mi.Table, idx) {
+			continue
+		}
+		dropIndexes = append(dropIndexes, schemaChange{
+			desc: fmt.Sprintf("drop index `%s` of table `%s`", idx, mi.Table),
+			up:   getIndexDropQuery(al, mi.Table, idx),
+			down: fmt.Sprintf("CREATE INDEX %s%s%s ON %s (%s%s%s);", Q, idx, Q, table, Q, name, Q),
+		})
+	}
+
+	// the dropped columns may be indexed, so drop the indexes first
+	changes = append(dropIndexes, changes...)
+	for _, name := range dropped {
+		if renamed[name] {
+			continue
+		}
+		changes = append(changes, schemaChange{
+			desc: fmt.Sprintf("drop column `%s` of table `%s`", name, mi.Table),
+			up:   fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s%s%s", table, Q, name, Q),
+			// the data is lost, the column is added back as nullable
+			down: fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s%s%s %s", table, Q, name, Q, columns[name][1]),
+		})
+	}
+	return changes
+}
+
+// getColumnModifyQuery return the sql to change the type of column
+func getColumnModifyQuery(al *alias, table, column, typ string, notNull bool) string {
+	Q := al.DbBaser.TableQuote()
+	if al.Driver == DRPostgres {
+		// ALTER COLUMN TYPE takes no CHECK, so the check of the column is replaced by a table constraint,
+		// which is named as the one created with the table
+		typ, check := splitColumnCheck(strings.ReplaceAll(typ, "%COL%", column))
+		constraint := Q + table + "_" + column + "_check" + Q
+		query := fmt.Sprintf("ALTER TABLE %s%s%s ALTER COLUMN %s%s%s TYPE %s, DROP CONSTRAINT IF EXISTS %s",
+			Q, table, Q, Q, column, Q, typ, constraint)
+		if check != "" {
+			query += fmt.Sprintf(", ADD CONSTRAINT %s %s", constraint, check)
+		}
+		return query
+	}
+	if notNull {
+		typ += " NOT NULL"
+	}
+	return fmt.Sprintf("ALTER TABLE %s%s%s MODIFY COLUMN %s%s%s %s", Q, table, Q, Q, column, Q, typ)
+}
+
+// splitColumnCheck splits the column type like `bigint CHECK("id" >= 0)` to the type and the CHECK clause
+func splitColumnCheck(typ string) (string, string) {
+	i := strings.Index(strings.ToUpper(typ), " CHECK(")
+	if i < 0 {
+		return typ, ""
+	}
+	return typ[:i], typ[i+1:]
+}
+
+// getIndexDropQuery return the sql to drop the index of table
+func getIndexDropQuery(al *alias, table, name string) string {
+	Q := al.DbBaser.TableQuote()
+	switch al.Driver {
+	case DRMySQL, DRTiDB:
+		return fmt.Sprintf("DROP INDEX %s%s%s ON %s%s%s", Q, name, Q, Q, table, Q)
+	default:
+		return fmt.Sprintf("DROP INDEX %s%s%s", Q, name, Q)
+	}
+}
+
+// stripSQLComments removes the "-- " comment lines of the create table sql
+func stripSQLComments(query string) string {
+	lines := strings.Split(query, "\n")
+	res := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "--") {
+			res = append(res, line)
+		}
+	}
+	return strings.Join(res, "\n")
+}
+
+// renderMigration return the go source of the migration made of changes,
+// it's registered to the migration package in the same way as the hand-written ones.
+func renderMigration(name string, created time.Time, changes []schemaChange) string {
+	ts := created.Format(migrationDateFormat)
+	typ := name + "_" + ts
+
+	var up, down strings.Builder
+	for _, c := range changes {
+		if c.skipped {
+			fmt.Fprintf(&up, "\t// TODO: %s\n", c.desc)
+			continue
+		}
+		fmt.Fprintf(&up, "\t// %s\n\tm.SQL(%q)\n", c.desc, c.up)
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].skipped {
+			continue
+		}
+		fmt.Fprintf(&down, "\t// revert %s\n\tm.SQL(%q)\n", changes[i].desc, changes[i].down)
+	}
+
+	return fmt.Sprintf(`package main
+
+import (
+	"github.com/jialequ/android-sdk/client/orm/migration"
+)
+
+// DO NOT MODIFY
+type %[1]s struct {
+	migration.Migration
+}
+
+// DO NOT MODIFY
+func init() {
+	m := &%[1]s{}
+	m.Created = "%[2]s"
+	migration.Register("%[1]s", m)
+}
+
+// Up run the migration, it's generated by "orm makemigration"
+func (m *%[1]s) Up() {
+%[3]s}
+
+// Down reverse the migration
+func (m *%[1]s) Down() {
+%[4]s}
+`, typ, ts, up.String(), down.String())
+}
@@ -0,0 +1,84 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeColumnType(t *testing.T) {
+	testCases := []struct {
+		driver DriverType
+		live   string
+		model  string
+	}{
+		{driver: DRMySQL, live: "int(11)", model: "integer"},
+		{driver: DRMySQL, live: "int(10) unsigned", model: "integer unsigned"},
+		{driver: DRMySQL, live: "tinyint(1)", model: "bool"},
+		{driver: DRMySQL, live: "decimal(10,2)", model: "numeric(10, 2)"},
+		{driver: DRMySQL, live: "double", model: "double precision"},
+		{driver: DRMySQL, live: "varchar(255)", model: "varchar(255)"},
+		{driver: DRPostgres, live: "character varying", model: "varchar(255)"},
+		{driver: DRPostgres, live: "boolean", model: "bool"},
+		{driver: DRPostgres, live: "smallint", model: `smallint CHECK("age" >= 0 AND "age" <= 255)`},
+		{driver: DRPostgres, live: "timestamp with time zone", model: "timestamp(3) with time zone"},
+		{driver: DRSqlite, live: "VARCHAR(255)", model: "varchar(255)"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, normalizeColumnType(tc.driver, tc.model), normalizeColumnType(tc.driver, tc.live), tc.live)
+	}
+
+	assert.NotEqual(t, normalizeColumnType(DRMySQL, "varchar(100)"), normalizeColumnType(DRMySQL, "varchar(255)"))
+	assert.NotEqual(t, normalizeColumnType(DRPostgres, "bigint"), normalizeColumnType(DRPostgres, "integer"))
+}
+
+func TestGetColumnModifyQuery(t *testing.T) {
+	al := &alias{Driver: DRPostgres, DbBaser: newdbBasePostgres()}
+	assert.Equal(t, `ALTER TABLE "user" ALTER COLUMN "age" TYPE bigint, DROP CONSTRAINT IF EXISTS "user_age_check", `+
+		`ADD CONSTRAINT "user_age_check" CHECK("age" >= 0)`,
+		getColumnModifyQuery(al, "user", "age", `bigint CHECK("%COL%" >= 0)`, true))
+	assert.Equal(t, `ALTER TABLE "user" ALTER COLUMN "age" TYPE integer, DROP CONSTRAINT IF EXISTS "user_age_check"`,
+		getColumnModifyQuery(al, "user", "age", "integer", true))
+
+	al = &alias{Driver: DRMySQL, DbBaser: newdbBaseMysql()}
+	assert.Equal(t, "ALTER TABLE `user` MODIFY COLUMN `age` bigint unsigned NOT NULL",
+		getColumnModifyQuery(al, "user", "age", "bigint unsigned", true))
+}
+
+func TestRenderMigration(t *testing.T) {
+	created := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	src := renderMigration("AddAge", created, []schemaChange{
+		{desc: "add column `age`", up: "ALTER TABLE `user` ADD COLUMN `age` integer", down: "ALTER TABLE `user` DROP COLUMN `age`"},
+		{desc: "create index `user_age`", up: "CREATE INDEX `user_age` ON `user` (`age`);", down: "DROP INDEX `user_age`"},
+		{desc: "type of column `name` in table `user` is changed", skipped: true},
+	})
+
+	assert.Contains(t, src, "type AddAge_20210102_030405 struct {")
+	assert.Contains(t, src, `m.Created = "20210102_030405"`)
+	assert.Contains(t, src, `migration.Register("AddAge_20210102_030405", m)`)
+
+	up := src[strings.Index(src, ") Up() {"):strings.Index(src, ") Down() {")]
+	assert.Less(t, strings.Index(up, "ADD COLUMN"), strings.Index(up, "CREATE INDEX"))
+	down := src[strings.Index(src, ") Down() {"):]
+	assert.Less(t, strings.Index(down, "DROP INDEX"), strings.Index(down, "DROP COLUMN"))
+
+	assert.Contains(t, up, "\t// TODO: type of column `name` in table `user` is changed\n")
+	assert.Equal(t, 2, strings.Count(up, "m.SQL("))
+	assert.NotContains(t, down, "column `name`")
+}
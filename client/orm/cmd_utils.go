@@ -101,6 +101,13 @@ checkColumn:
 		}
 		col = T["json"]
 	case TypeJsonbField:
+		// the json tag is json on mysql and sqlite, and jsonb on postgres
+		if fi.JSON && al.Driver != DRPostgres {
+			if col = T["json"]; col == "" {
+				col = T["string-text"]
+			}
+			break
+		}
 		if al.Driver != DRPostgres {
 			fieldType = TypeVarCharField
 			goto checkColumn
@@ -124,16 +131,24 @@ func getColumnAddQuery(al *alias, fi *models.FieldInfo) string {
 		typ += " " + "NOT NULL"
 	}
 
-	return fmt.Sprintf("ALTER TABLE %s%s%s ADD COLUMN %s%s%s %s %s",
+	// mssql adds column without the keyword COLUMN
+	add := "ADD COLUMN"
+	if al.Driver == DRMsSQL {
+		add = "ADD"
+	}
+
+	return fmt.Sprintf("ALTER TABLE %s%s%s %s %s%s%s %s %s",
 		Q, fi.Mi.Table, Q,
+		add,
 		Q, fi.Column, Q,
-		typ, getColumnDefault(fi),
+		typ, getColumnDefault(al, fi),
 	)
 }
 
 // Get string value for the attribute "DEFAULT" for the CREATE, ALTER commands
-func getColumnDefault(fi *models.FieldInfo) string {
+func getColumnDefault(al *alias, fi *models.FieldInfo) string {
 	var v, t, d string
+	initial := fi.Initial
 
 	// Skip default attribute if field is in relations
 	if fi.Rel || fi.Reverse {
@@ -156,15 +171,28 @@ func getColumnDefault(fi *models.FieldInfo) string {
 	case TypeBooleanField:
 		t = " DEFAULT %s "
 		d = "FALSE"
+		if al.Driver == DRMsSQL {
+			// bit column of mssql has no boolean literal
+			d = "0"
+			if b, err := initial.Bool(); err == nil && b {
+				initial.Set("1")
+			} else if err == nil {
+				initial.Set("0")
+			}
+		}
 	case TypeJSONField, TypeJsonbField:
+		// mysql can't set the default of json column, and {} is wrong for the json of slice
+		if fi.JSON {
+			return v
+		}
 		d = "{}"
 	}
 
 	if fi.ColDefault {
-		if !fi.Initial.Exist() {
+		if !initial.Exist() {
 			v = fmt.Sprintf(t, "")
 		} else {
-			v = fmt.Sprintf(t, fi.Initial.String())
+			v = fmt.Sprintf(t, initial.String())
 		}
 	} else {
 		if !fi.Null {
@@ -29,6 +29,7 @@ import (
 
 	"github.com/jialequ/android-sdk/client/orm/internal/models"
 
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/hints"
 )
 
@@ -62,8 +63,12 @@ var operators = map[string]bool{
 	// "week_day":    true,
 	"isnull": true,
 	// "search":      true,
+	"json_contains": true,
 }
 
+// jsonExpr separate the json field and the path in it, e.g. attrs__json__color
+const jsonExpr = "json"
+
 // an instance of dbBaser interface/
 type dbBase struct {
 	ins dbBaser
@@ -125,6 +130,12 @@ func (d *dbBase) collectFieldValue(mi *models.ModelInfo, fi *models.FieldInfo, i
 		if fi.IsFielder {
 			f := field.Addr().Interface().(models.Fielder)
 			value = f.RawValue()
+		} else if fi.JSON {
+			v, err := jsonFieldValue(fi, field.Interface())
+			if err != nil {
+				return nil, err
+			}
+			value = v
 		} else {
 			switch fi.FieldType {
 			case TypeBooleanField:
@@ -333,30 +344,14 @@ func (d *dbBase) Read(ctx context.Context, q dbQuerier, mi *models.ModelInfo, in
 		args = append(args, pkValue)
 	}
 
-	Q := d.ins.TableQuote()
-
-	sep := fmt.Sprintf("%s, %s", Q, Q)
-	sels := strings.Join(mi.Fields.DBcols, sep)
-	colsNum := len(mi.Fields.DBcols)
-
-	sep = fmt.Sprintf("%s = ? AND %s", Q, Q)
-	wheres := strings.Join(whereCols, sep)
+	query := d.readOneSQL(mi, whereCols, isForUpdate)
 
-	forUpdate := ""
-	if isForUpdate {
-		forUpdate = "FOR UPDATE"
-	}
-
-	query := fmt.Sprintf("SELECT %s%s%s FROM %s%s%s WHERE %s%s%s = ? %s", Q, sels, Q, Q, mi.Table, Q, Q, wheres, Q, forUpdate)
-
-	refs := make([]interface{}, colsNum)
+	refs := make([]interface{}, len(mi.Fields.DBcols))
 	for i := range refs {
 		var ref interface{}
 		refs[i] = &ref
 	}
 
-	d.ins.ReplaceMarks(&query)
-
 	row := q.QueryRowContext(ctx, query, args...)
 	if err := row.Scan(refs...); err != nil {
 		if err == sql.ErrNoRows {
@@ -371,6 +366,38 @@ func (d *dbBase) Read(ctx context.Context, q dbQuerier, mi *models.ModelInfo, in
 	return nil
 }
 
+// readOneSQL generate the select sql of Read
+func (d *dbBase) readOneSQL(mi *models.ModelInfo, whereCols []string, isForUpdate bool) string {
+	Q := d.ins.TableQuote()
+
+	sep := fmt.Sprintf("%s, %s", Q, Q)
+	sels := strings.Join(mi.Fields.DBcols, sep)
+
+	sep = fmt.Sprintf("%s = ? AND %s", Q, Q)
+	wheres := strings.Join(whereCols, sep)
+
+	// soft deleted rows are not readable
+	softDelete := ""
+	if sd := mi.Fields.SoftDelete; sd != nil {
+		softDelete = fmt.Sprintf(" AND %s%s%s IS NULL", Q, sd.Column, Q)
+	}
+
+	table := Q + mi.Table + Q
+	forUpdate := ""
+	if isForUpdate {
+		var tableHints string
+		tableHints, forUpdate = d.ins.GenerateForUpdate(mi.Table, 0, nil)
+		if tableHints != "" {
+			table += " " + strings.TrimSpace(tableHints)
+		}
+	}
+
+	query := fmt.Sprintf("SELECT %s%s%s FROM %s WHERE %s%s%s = ?%s%s", Q, sels, Q, table, Q, wheres, Q, softDelete, forUpdate)
+
+	d.ins.ReplaceMarks(&query)
+	return query
+}
+
 // Insert execute insert sql dbQuerier with given struct reflect.Value.
 func (d *dbBase) Insert(ctx context.Context, q dbQuerier, mi *models.ModelInfo, ind reflect.Value, tz *time.Location) (int64, error) {
 	names := make([]string, 0, len(mi.Fields.DBcols))
@@ -391,14 +418,47 @@ func (d *dbBase) Insert(ctx context.Context, q dbQuerier, mi *models.ModelInfo,
 }
 
 // InsertMulti multi-insert sql with given slice struct reflect.Value.
-func (d *dbBase) InsertMulti(ctx context.Context, q dbQuerier, mi *models.ModelInfo, sind reflect.Value, bulk int, tz *time.Location) (int64, error) { // NOSONAR
+// The returned columns are set back into the structs if the dialect supports RETURNING,
+// and the rows are matched with the structs by the pk unless it's auto.
+func (d *dbBase) InsertMulti(ctx context.Context, q dbQuerier, mi *models.ModelInfo, sind reflect.Value, bulk int, tz *time.Location) (int64, error) {
+	var keys []string
+	if pk := mi.Fields.Pk; pk != nil && !pk.Auto {
+		keys = []string{pk.Column}
+	}
+	return d.insertMulti(ctx, q, mi, sind, bulk, tz, keys, func(names []string, values []interface{}) (string, error) {
+		return d.InsertValueSQL(names, values, true, mi), nil
+	})
+}
+
+// InsertMultiOrUpdate multi-insert sql with given slice struct reflect.Value,
+// the rows conflicted on conflictCols are updated by updateCols.
+// The returned rows are matched with the structs by conflictCols.
+func (d *dbBase) InsertMultiOrUpdate(ctx context.Context, q dbQuerier, mi *models.ModelInfo, sind reflect.Value, bulk int, a *alias, conflictCols []string, updateCols []string) (int64, error) {
+	keys, err := columnsOfFields(mi, conflictCols)
+	if err != nil {
+		return 0, err
+	}
+	return d.insertMulti(ctx, q, mi, sind, bulk, a.TZ, keys, func(names []string, values []interface{}) (string, error) {
+		return d.InsertMultiOrUpdateSQL(names, values, mi, a, conflictCols, updateCols)
+	})
+}
+
+// insertMulti collect the values of models by bulk, and execute the sql generated by genSQL for each bulk.
+// keys are the columns to match the returned rows with the models.
+func (d *dbBase) insertMulti(ctx context.Context, q dbQuerier, mi *models.ModelInfo, sind reflect.Value, bulk int, tz *time.Location, keys []string,
+	genSQL func(names []string, values []interface{}) (string, error),
+) (int64, error) { // NOSONAR
 	var (
 		cnt    int64
 		nums   int
 		values []interface{}
 		names  []string
+		inds   []reflect.Value
 	)
 
+	if bulk < 1 {
+		bulk = 1
+	}
 	length, autoFields := sind.Len(), make([]string, 0, 1)
 
 	for i := 1; i <= length; i++ {
@@ -428,14 +488,20 @@ func (d *dbBase) InsertMulti(ctx context.Context, q dbQuerier, mi *models.ModelI
 
 			nums += copy(values[nums:], vus)
 		}
+		inds = append(inds, ind)
 
-		if i > 1 && i%bulk == 0 || length == i {
-			num, err := d.InsertValue(ctx, q, mi, true, names, values[:nums])
+		if i%bulk == 0 || length == i {
+			query, err := genSQL(names, values[:nums])
+			if err != nil {
+				return cnt, err
+			}
+			num, err := d.insertRows(ctx, q, mi, query, values[:nums], inds, keys, tz)
 			if err != nil {
 				return cnt, err
 			}
 			cnt += num
 			nums = 0
+			inds = inds[:0]
 		}
 	}
 
@@ -447,6 +513,203 @@ func (d *dbBase) InsertMulti(ctx context.Context, q dbQuerier, mi *models.ModelI
 	return cnt, err
 }
 
+// insertRows execute the multi-insert query, and the number of returned rows is the affected count.
+// RETURNING doesn't guarantee the order of rows, so that the returned columns are set back into
+// the model of inds which has the same values of keys, or into the only model if keys is empty.
+func (d *dbBase) insertRows(ctx context.Context, q dbQuerier, mi *models.ModelInfo, query string, values []interface{}, inds []reflect.Value, keys []string, tz *time.Location) (int64, error) {
+	cols := make([]string, 0, len(mi.Fields.FieldsDB))
+	for _, fi := range mi.Fields.FieldsDB {
+		if !fi.Rel {
+			cols = append(cols, fi.Column)
+		}
+	}
+
+	if !d.ins.HasReturning(&query, cols) {
+		res, err := q.ExecContext(ctx, query, values...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	rows, err := q.QueryContext(ctx, query, values...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var cnt int64
+	matched := make([]bool, len(inds))
+	for rows.Next() {
+		refs := make([]interface{}, len(cols))
+		for i := range refs {
+			var ref interface{}
+			refs[i] = &ref
+		}
+		if err := rows.Scan(refs...); err != nil {
+			return cnt, err
+		}
+		if i := d.matchReturned(mi, inds, matched, keys, cols, refs, tz); i >= 0 && inds[i].CanSet() {
+			matched[i] = true
+			d.setColsValues(mi, &inds[i], cols, refs, tz)
+		}
+		cnt++
+	}
+	return cnt, rows.Err()
+}
+
+// matchReturned returns the index of the unmatched model in inds, whose values of keys are the same as
+// the returned row, or -1 if there is no such model.
+func (d *dbBase) matchReturned(mi *models.ModelInfo, inds []reflect.Value, matched []bool, keys []string,
+	cols []string, refs []interface{}, tz *time.Location,
+) int {
+	if len(keys) == 0 {
+		if len(inds) == 1 && !matched[0] {
+			return 0
+		}
+		return -1
+	}
+
+	keyRefs := make([]interface{}, len(keys))
+	for i, key := range keys {
+		for j, col := range cols {
+			if col == key {
+				keyRefs[i] = refs[j]
+			}
+		}
+		if keyRefs[i] == nil {
+			return -1
+		}
+	}
+	row := reflect.New(inds[0].Type()).Elem()
+	d.setColsValues(mi, &row, keys, keyRefs, tz)
+
+	for i, ind := range inds {
+		if matched[i] {
+			continue
+		}
+		same := true
+		for _, key := range keys {
+			index := mi.Fields.GetByColumn(key).FieldIndex
+			if !reflect.DeepEqual(ind.FieldByIndex(index).Interface(), row.FieldByIndex(index).Interface()) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnsOfFields returns the columns of fields, which are the names of fields or columns.
+func columnsOfFields(mi *models.ModelInfo, fields []string) ([]string, error) {
+	cols := make([]string, 0, len(fields))
+	for _, name := range fields {
+		fi, ok := mi.Fields.GetByAny(name)
+		if !ok || !fi.DBcol {
+			return nil, fmt.Errorf("wrong db field/column name `%s` for model `%s`", name, mi.FullName)
+		}
+		cols = append(cols, fi.Column)
+	}
+	return cols, nil
+}
+
+// InsertMultiOrUpdateSQL generate the multi-insert sql, which updates the conflicted rows.
+// The conflict and update columns are the names of fields or columns, the update columns are
+// all inserted columns except the pk, auto_now_add and conflict columns if it's empty,
+// and the version of the existing row is increased by 1.
+// mysql updates the rows conflicted on any unique key, so that conflictCols is not used.
+func (d *dbBase) InsertMultiOrUpdateSQL(names []string, values []interface{}, mi *models.ModelInfo, a *alias, conflictCols []string, updateCols []string) (string, error) { // NOSONAR
+	switch a.Driver {
+	case DRMySQL, DRTiDB:
+	case DRPostgres, DRSqlite:
+		if len(conflictCols) == 0 {
+			return "", fmt.Errorf("`%s` use InsertMultiOrUpdate must have conflict columns", a.DriverName)
+		}
+	default:
+		return "", fmt.Errorf("`%s` nonsupport InsertMultiOrUpdate in beego", a.DriverName)
+	}
+
+	conflicts, err := columnsOfFields(mi, conflictCols)
+	if err != nil {
+		return "", err
+	}
+	updates, err := columnsOfFields(mi, updateCols)
+	if err != nil {
+		return "", err
+	}
+
+	isConflict := make(map[string]bool, len(conflicts))
+	for _, col := range conflicts {
+		isConflict[col] = true
+	}
+
+	version := versionField(mi)
+	if len(updates) == 0 {
+		for _, name := range names {
+			fi := mi.Fields.GetByColumn(name)
+			if fi.Pk || fi.AutoNowAdd || fi == version || isConflict[name] {
+				continue
+			}
+			updates = append(updates, name)
+		}
+	}
+	if len(updates) == 0 && version == nil {
+		// keep the conflicted rows in the RETURNING by an update changes nothing
+		updates = conflicts
+	}
+
+	Q := d.ins.TableQuote()
+
+	buf := buffers.Get()
+	defer buffers.Put(buf)
+
+	_, _ = buf.WriteString(d.InsertValueSQL(names, values, true, mi))
+
+	if a.Driver == DRMySQL || a.Driver == DRTiDB {
+		_, _ = buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+	} else {
+		_, _ = buf.WriteString(" ON CONFLICT (")
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(strings.Join(conflicts, Q+", "+Q))
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(") DO UPDATE SET ")
+	}
+
+	for i, col := range updates {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		if a.Driver == DRMySQL || a.Driver == DRTiDB {
+			_, _ = fmt.Fprintf(buf, "%s%s%s = VALUES(%s%s%s)", Q, col, Q, Q, col, Q)
+		} else {
+			_, _ = fmt.Fprintf(buf, "%s%s%s = EXCLUDED.%s%s%s", Q, col, Q, Q, col, Q)
+		}
+	}
+
+	updateVersion := version != nil
+	for _, col := range updates {
+		if version != nil && col == version.Column {
+			updateVersion = false
+		}
+	}
+	if updateVersion {
+		if len(updates) > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		// the version of the existing row is increased instead of overwritten
+		if a.Driver == DRMySQL || a.Driver == DRTiDB {
+			_, _ = fmt.Fprintf(buf, "%s%s%s = %s%s%s + 1", Q, version.Column, Q, Q, version.Column, Q)
+		} else {
+			_, _ = fmt.Fprintf(buf, "%s%s%s = %s%s%s.%s%s%s + 1", Q, version.Column, Q, Q, mi.Table, Q, Q, version.Column, Q)
+		}
+	}
+
+	return buf.String(), nil
+}
+
 // InsertValue execute insert sql with given struct and given values.
 // insert the given values, not the field values in struct.
 func (d *dbBase) InsertValue(ctx context.Context, q dbQuerier, mi *models.ModelInfo, isMulti bool, names []string, values []interface{}) (int64, error) {
@@ -632,9 +895,24 @@ func (d *dbBase) InsertOrUpdateSQL(names []string, values *[]interface{}, mi *mo
 		if i > 0 {
 			_, _ = buf.WriteString(", ")
 		}
+		isVersion := versionField(mi) != nil && versionField(mi).Column == v
 		// identifier in database may not be case-sensitive, so quote it
 		v = fmt.Sprintf("%s%s%s", quote, v, quote)
 		valueStr := argsMap[strings.ToLower(v)]
+		if isVersion && valueStr == "" {
+			// the version of the existing row is increased instead of overwritten
+			_, _ = buf.WriteString(v)
+			_, _ = buf.WriteString("=")
+			if a.Driver == DRPostgres {
+				_, _ = buf.WriteString(quote)
+				_, _ = buf.WriteString(mi.Table)
+				_, _ = buf.WriteString(quote)
+				_, _ = buf.WriteString(".")
+			}
+			_, _ = buf.WriteString(v)
+			_, _ = buf.WriteString("+1")
+			continue
+		}
 		if v == args0 {
 			conflitValue = (*values)[i]
 		}
@@ -721,15 +999,42 @@ func (d *dbBase) Update(ctx context.Context, q dbQuerier, mi *models.ModelInfo,
 		}
 	}
 
+	// optimistic locking, the row is updated only if its version is not changed since read
+	vf := mi.Fields.Version
+	var version reflect.Value
+	if vf != nil {
+		version = ind.FieldByIndex(vf.FieldIndex)
+		for i, col := range setNames {
+			if col == vf.Column {
+				setNames = append(setNames[:i], setNames[i+1:]...)
+				setValues = append(setValues[:i], setValues[i+1:]...)
+				break
+			}
+		}
+		setNames = append(setNames, vf.Column)
+		setValues = append(setValues, nextVersion(version).Interface())
+	}
+
 	setValues = append(setValues, pkValue)
+	if vf != nil {
+		setValues = append(setValues, version.Interface())
+	}
 
 	query := d.UpdateSQL(setNames, pkName, mi)
 
 	res, err := q.ExecContext(ctx, query, setValues...)
-	if err == nil {
-		return res.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
-	return 0, err
+	num, err := res.RowsAffected()
+	if err != nil || vf == nil {
+		return num, err
+	}
+	if num == 0 {
+		return 0, ErrStaleObject
+	}
+	version.Set(nextVersion(version))
+	return num, nil
 }
 
 func (d *dbBase) UpdateSQL(setNames []string, pkName string, mi *models.ModelInfo) string {
@@ -760,6 +1065,23 @@ func (d *dbBase) UpdateSQL(setNames []string, pkName string, mi *models.ModelInf
 	_, _ = buf.WriteString(Q)
 	_, _ = buf.WriteString(" = ?")
 
+	if vf := versionField(mi); vf != nil {
+		_, _ = buf.WriteString(" AND ")
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(vf.Column)
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(" = ?")
+	}
+
+	// the soft deleted record is not updated, like it is not read
+	if sd := softDeleteField(mi); sd != nil {
+		_, _ = buf.WriteString(" AND ")
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(sd.Column)
+		_, _ = buf.WriteString(Q)
+		_, _ = buf.WriteString(" IS NULL")
+	}
+
 	query := buf.String()
 	d.ins.ReplaceMarks(&query)
 
@@ -840,14 +1162,31 @@ func (d *dbBase) DeleteSQL(whereCols []string, mi *models.ModelInfo) string {
 // UpdateBatch update table-related record by querySet.
 // need querySet not struct reflect.Value to update related records.
 func (d *dbBase) UpdateBatch(ctx context.Context, q dbQuerier, qs *querySet, mi *models.ModelInfo, cond *Condition, params Params, tz *time.Location) (int64, error) {
-	columns := make([]string, 0, len(params))
-	values := make([]interface{}, 0, len(params))
+	columns := make([]string, 0, len(params)+1)
+	values := make([]interface{}, 0, len(params)+1)
+	hasVersion := false
 	for col, val := range params {
 		if fi, ok := mi.Fields.GetByAny(col); !ok || !fi.DBcol {
 			panic(fmt.Errorf("wrong field/column name `%s`", col))
 		} else {
+			if fi.JSON {
+				// string and []byte are taken as encoded json
+				switch val.(type) {
+				case colValue, expr.Expr, string, []byte:
+				default:
+					v, err := jsonFieldValue(fi, val)
+					if err != nil {
+						return 0, err
+					}
+					val = v
+				}
+			}
+			if e, ok := val.(expr.Expr); ok {
+				val = d.buildSetExpr(mi, e, tz)
+			}
 			columns = append(columns, fi.Column)
 			values = append(values, val)
+			hasVersion = hasVersion || fi.Version
 		}
 	}
 
@@ -855,6 +1194,12 @@ func (d *dbBase) UpdateBatch(ctx context.Context, q dbQuerier, qs *querySet, mi
 		panic(fmt.Errorf("update params cannot empty"))
 	}
 
+	// the models read before must see the batch update as a change of the rows
+	if vf := mi.Fields.Version; vf != nil && !hasVersion {
+		columns = append(columns, vf.Column)
+		values = append(values, ColValue(ColAdd, 1))
+	}
+
 	tables := newDbTables(mi, d.ins)
 	var specifyIndexes string
 	if qs != nil {
@@ -870,7 +1215,17 @@ func (d *dbBase) UpdateBatch(ctx context.Context, q dbQuerier, qs *querySet, mi
 
 	query := d.UpdateBatchSQL(mi, columns, values, specifyIndexes, join, where)
 
-	res, err := q.ExecContext(ctx, query, values...)
+	// the args of the expressions take the places of them
+	args = make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if e, ok := v.(exprValue); ok {
+			args = append(args, e.args...)
+		} else {
+			args = append(args, v)
+		}
+	}
+
+	res, err := q.ExecContext(ctx, query, args...)
 	if err == nil {
 		return res.RowsAffected()
 	}
@@ -976,6 +1331,8 @@ func (d *dbBase) buildSetSQL(buf buffers.Buffer, cols []string, values []interfa
 				_, _ = buf.WriteString(" | ?")
 			}
 			values[i] = c.value
+		} else if e, ok := values[i].(exprValue); ok {
+			_, _ = buf.WriteString(e.sql)
 		} else {
 			_, _ = buf.WriteString("?")
 		}
@@ -1010,6 +1367,65 @@ func (d *dbBase) deleteRels(ctx context.Context, q dbQuerier, mi *models.ModelIn
 	return nil
 }
 
+// SoftDelete set the soft_delete field of the record to the current time instead of deleting it.
+// the records which are already soft deleted are not affected.
+func (d *dbBase) SoftDelete(ctx context.Context, q dbQuerier, mi *models.ModelInfo, ind reflect.Value, tz *time.Location, cols []string) (int64, error) {
+	var whereCols []string
+	var args []interface{}
+	// if specify cols length > 0, then use it for where condition.
+	if len(cols) > 0 {
+		var err error
+		whereCols = make([]string, 0, len(cols))
+		args, _, err = d.collectValues(mi, ind, cols, false, false, &whereCols, tz)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		// default use pk value as where condtion.
+		pkColumn, pkValue, ok := getExistPk(mi, ind)
+		if !ok {
+			return 0, ErrMissPK
+		}
+		whereCols = []string{pkColumn}
+		args = append(args, pkValue)
+	}
+
+	cond := NewCondition()
+	for i, col := range whereCols {
+		cond = cond.And(col, args[i])
+	}
+
+	sd := mi.Fields.SoftDelete
+	tnow := time.Now()
+	d.ins.TimeToDB(&tnow, tz)
+	num, err := d.UpdateBatch(ctx, q, nil, mi, aliveCond(mi, cond), Params{sd.Column: tnow}, tz)
+	if err != nil || num == 0 {
+		return num, err
+	}
+
+	field := ind.FieldByIndex(sd.FieldIndex)
+	v := tnow.In(DefaultTimeLoc)
+	if sd.IsFielder {
+		err = field.Addr().Interface().(models.Fielder).SetRaw(v)
+	} else if field.Kind() == reflect.Ptr {
+		field.Set(reflect.ValueOf(&v))
+	} else {
+		field.Set(reflect.ValueOf(v))
+	}
+	return num, err
+}
+
+// SoftDeleteBatch set the soft_delete field of the records to the current time instead of deleting them.
+func (d *dbBase) SoftDeleteBatch(ctx context.Context, q dbQuerier, qs *querySet, mi *models.ModelInfo, cond *Condition, tz *time.Location) (int64, error) {
+	if cond == nil || cond.IsEmpty() {
+		panic(fmt.Errorf("delete operation cannot execute without condition"))
+	}
+
+	tnow := time.Now()
+	d.ins.TimeToDB(&tnow, tz)
+	return d.UpdateBatch(ctx, q, qs, mi, aliveCond(mi, cond), Params{mi.Fields.SoftDelete.Column: tnow}, tz)
+}
+
 // DeleteBatch delete table-related records.
 func (d *dbBase) DeleteBatch(ctx context.Context, q dbQuerier, qs *querySet, mi *models.ModelInfo, cond *Condition, tz *time.Location) (int64, error) { // NOSONAR
 	tables := newDbTables(mi, d.ins)
@@ -1125,35 +1541,9 @@ func (d *dbBase) ReadBatch(ctx context.Context, q dbQuerier, qs querySet, mi *mo
 		RegisterModel(container)
 	}
 
-	var tCols []string
-	if len(cols) > 0 {
-		hasRel := len(qs.related) > 0 || qs.relDepth > 0
-		tCols = make([]string, 0, len(cols))
-		var maps map[string]bool
-		if hasRel {
-			maps = make(map[string]bool)
-		}
-		for _, col := range cols {
-			if fi, ok := mi.Fields.GetByAny(col); ok {
-				tCols = append(tCols, fi.Column)
-				if hasRel {
-					maps[fi.Column] = true
-				}
-			} else {
-				return 0, fmt.Errorf("wrong field/column name `%s`", col)
-			}
-		}
-		if hasRel {
-			for _, fi := range mi.Fields.FieldsDB {
-				if fi.FieldType&IsRelField > 0 {
-					if !maps[fi.Column] {
-						tCols = append(tCols, fi.Column)
-					}
-				}
-			}
-		}
-	} else {
-		tCols = mi.Fields.DBcols
+	tCols, err := d.readBatchCols(qs, mi, cols)
+	if err != nil {
+		return 0, err
 	}
 
 	tables := newDbTables(mi, d.ins)
@@ -1198,54 +1588,8 @@ func (d *dbBase) ReadBatch(ctx context.Context, q dbQuerier, qs querySet, mi *mo
 			elm := reflect.New(mi.AddrField.Elem().Type())
 			mind := reflect.Indirect(elm)
 
-			cacheV := make(map[string]*reflect.Value)
-			cacheM := make(map[string]*models.ModelInfo)
-			trefs := refs
-
 			d.setColsValues(mi, &mind, tCols, refs[:len(tCols)], tz)
-			trefs = refs[len(tCols):]
-
-			for _, tbl := range tables.tables {
-				// loop selected tables
-				if tbl.sel {
-					last := mind
-					names := ""
-					mmi := mi
-					// loop cascade models
-					for _, name := range tbl.names {
-						names += name
-						if val, ok := cacheV[names]; ok {
-							last = *val
-							mmi = cacheM[names]
-						} else {
-							fi := mmi.Fields.GetByName(name)
-							lastm := mmi
-							mmi = fi.RelModelInfo
-							field := last
-							if last.Kind() != reflect.Invalid {
-								field = reflect.Indirect(last.FieldByIndex(fi.FieldIndex))
-								if field.IsValid() {
-									d.setColsValues(mmi, &field, mmi.Fields.DBcols, trefs[:len(mmi.Fields.DBcols)], tz)
-									for _, fi := range mmi.Fields.FieldsReverse {
-										if fi.InModel && fi.ReverseFieldInfo.Mi == lastm {
-											if fi.ReverseFieldInfo != nil {
-												f := field.FieldByIndex(fi.FieldIndex)
-												if f.Kind() == reflect.Ptr {
-													f.Set(last.Addr())
-												}
-											}
-										}
-									}
-									last = field
-								}
-							}
-							cacheV[names] = &field
-							cacheM[names] = mmi
-						}
-					}
-					trefs = trefs[len(mmi.Fields.DBcols):]
-				}
-			}
+			d.setRelatedValues(mi, mind, tables, refs[len(tCols):], tz)
 
 			if one {
 				ind.Set(mind)
@@ -1289,6 +1633,128 @@ func (d *dbBase) ReadBatch(ctx context.Context, q dbQuerier, qs querySet, mi *mo
 	return cnt, nil
 }
 
+// ReadIterator query the same records as ReadBatch, but return an iterator that maps them row by row.
+func (d *dbBase) ReadIterator(ctx context.Context, q dbQuerier, qs querySet, mi *models.ModelInfo, cond *Condition, tz *time.Location, cols []string) (RowIterator, error) {
+	tCols, err := d.readBatchCols(qs, mi, cols)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := newDbTables(mi, d.ins)
+	tables.parseRelated(qs.related, qs.relDepth)
+
+	colsNum := len(tCols)
+	for _, tbl := range tables.tables {
+		if tbl.sel {
+			colsNum += len(tbl.mi.Fields.DBcols)
+		}
+	}
+
+	query, args := d.readBatchSQL(tables, tCols, cond, qs, mi, tz)
+
+	rs, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]interface{}, colsNum)
+	for i := range refs {
+		var ref interface{}
+		refs[i] = &ref
+	}
+
+	return &modelIterator{
+		rows:   rs,
+		d:      d,
+		mi:     mi,
+		tables: tables,
+		tCols:  tCols,
+		refs:   refs,
+		tz:     tz,
+	}, nil
+}
+
+// readBatchCols resolves the selected field/column names to db columns.
+// related columns are always appended when related tables are selected.
+func (d *dbBase) readBatchCols(qs querySet, mi *models.ModelInfo, cols []string) ([]string, error) {
+	if len(cols) == 0 {
+		return mi.Fields.DBcols, nil
+	}
+	hasRel := len(qs.related) > 0 || qs.relDepth > 0
+	tCols := make([]string, 0, len(cols))
+	var maps map[string]bool
+	if hasRel {
+		maps = make(map[string]bool)
+	}
+	for _, col := range cols {
+		if fi, ok := mi.Fields.GetByAny(col); ok {
+			tCols = append(tCols, fi.Column)
+			if hasRel {
+				maps[fi.Column] = true
+			}
+		} else {
+			return nil, fmt.Errorf("wrong field/column name `%s`", col)
+		}
+	}
+	if hasRel {
+		for _, fi := range mi.Fields.FieldsDB {
+			if fi.FieldType&IsRelField > 0 {
+				if !maps[fi.Column] {
+					tCols = append(tCols, fi.Column)
+				}
+			}
+		}
+	}
+	return tCols, nil
+}
+
+// setRelatedValues set the values of the selected related tables into mind.
+func (d *dbBase) setRelatedValues(mi *models.ModelInfo, mind reflect.Value, tables *dbTables, trefs []interface{}, tz *time.Location) {
+	cacheV := make(map[string]*reflect.Value)
+	cacheM := make(map[string]*models.ModelInfo)
+	for _, tbl := range tables.tables {
+		// loop selected tables
+		if tbl.sel {
+			last := mind
+			names := ""
+			mmi := mi
+			// loop cascade models
+			for _, name := range tbl.names {
+				names += name
+				if val, ok := cacheV[names]; ok {
+					last = *val
+					mmi = cacheM[names]
+				} else {
+					fi := mmi.Fields.GetByName(name)
+					lastm := mmi
+					mmi = fi.RelModelInfo
+					field := last
+					if last.Kind() != reflect.Invalid {
+						field = reflect.Indirect(last.FieldByIndex(fi.FieldIndex))
+						if field.IsValid() {
+							d.setColsValues(mmi, &field, mmi.Fields.DBcols, trefs[:len(mmi.Fields.DBcols)], tz)
+							for _, fi := range mmi.Fields.FieldsReverse {
+								if fi.InModel && fi.ReverseFieldInfo.Mi == lastm {
+									if fi.ReverseFieldInfo != nil {
+										f := field.FieldByIndex(fi.FieldIndex)
+										if f.Kind() == reflect.Ptr {
+											f.Set(last.Addr())
+										}
+									}
+								}
+							}
+							last = field
+						}
+					}
+					cacheV[names] = &field
+					cacheM[names] = mmi
+				}
+			}
+			trefs = trefs[len(mmi.Fields.DBcols):]
+		}
+	}
+}
+
 func (d *dbBase) readBatchSQL(tables *dbTables, tCols []string, cond *Condition, qs querySet, mi *models.ModelInfo, tz *time.Location) (string, []interface{}) {
 	cols := d.preProcCols(tCols) // pre process columns
 
@@ -1323,10 +1789,15 @@ func (d *dbBase) readSQL(buf buffers.Buffer, tables *dbTables, tCols []string, c
 
 	where, args := tables.getCondSQL(cond, false, tz)
 	groupBy := tables.getGroupSQL(qs.groups)
-	orderBy := tables.getOrderSQL(qs.orders)
-	limit := tables.getLimitSQL(mi, qs.offset, qs.limit)
+	orderBy, orderArgs := tables.getOrderSQL(qs.orders, tz)
+	args = append(args, orderArgs...)
+	limit := tables.getLimitSQL(mi, qs.offset, qs.limit, orderBy != "")
 	join := tables.getJoinSQL()
 	specifyIndexes := tables.getIndexSql(mi.Table, qs.useIndex, qs.indexes)
+	forUpdate := ""
+	if qs.forUpdate {
+		specifyIndexes, forUpdate = d.ins.GenerateForUpdate(mi.Table, qs.useIndex, qs.indexes)
+	}
 
 	_, _ = buf.WriteString("SELECT ")
 
@@ -1372,10 +1843,7 @@ func (d *dbBase) readSQL(buf buffers.Buffer, tables *dbTables, tCols []string, c
 	_, _ = buf.WriteString(groupBy)
 	_, _ = buf.WriteString(orderBy)
 	_, _ = buf.WriteString(limit)
-
-	if qs.forUpdate {
-		_, _ = buf.WriteString(" FOR UPDATE")
-	}
+	_, _ = buf.WriteString(forUpdate)
 
 	return args
 }
@@ -1480,6 +1948,24 @@ func (d *dbBase) GenerateOperatorLeftCol(*models.FieldInfo, string, *string) {
 	// default not use
 }
 
+// GenerateJSONExtract generate sql extracting the value at path of the json column col as scalar.
+// arg is the value compared with, it's nil if the operator doesn't compare.
+func (d *dbBase) GenerateJSONExtract(col string, path []string, arg interface{}) string {
+	if len(path) == 0 {
+		return col
+	}
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, '%s'))", col, jsonPath(path))
+}
+
+// GenerateJSONContains generate sql checking whether the json at path of the column col contains arg.
+// arg is encoded by encoding/json, use json.RawMessage for the encoded json.
+func (d *dbBase) GenerateJSONContains(col string, path []string, arg interface{}) (string, []interface{}) {
+	if len(path) == 0 {
+		return fmt.Sprintf("JSON_CONTAINS(%s, ?)", col), []interface{}{jsonArg(arg)}
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%s, ?, '%s')", col, jsonPath(path)), []interface{}{jsonArg(arg)}
+}
+
 // Set values to struct column.
 func (d *dbBase) setColsValues(mi *models.ModelInfo, ind *reflect.Value, cols []string, values []interface{}, tz *time.Location) {
 	for i, column := range cols {
@@ -1508,6 +1994,22 @@ func (d *dbBase) convertValueFromDB(fi *models.FieldInfo, val interface{}, tz *t
 		return nil, nil
 	}
 
+	// the annotation has no field, its value is kept as it is except the bytes
+	if fi == nil {
+		if b, ok := val.([]byte); ok {
+			return string(b), nil
+		}
+		return val, nil
+	}
+
+	// the json is unmarshaled by setFieldValue, which knows the go type
+	if fi.JSON {
+		if b, ok := val.([]byte); ok {
+			return string(b), nil
+		}
+		return utils.ToStr(val), nil
+	}
+
 	var value interface{}
 	var tErr error
 
@@ -1544,7 +2046,8 @@ setValue:
 			}
 			value = b
 		}
-	case fieldType == TypeVarCharField || fieldType == TypeCharField:
+	case fieldType == TypeVarCharField || fieldType == TypeCharField || fieldType == TypeTextField ||
+		fieldType == TypeJSONField || fieldType == TypeJsonbField:
 		if str == nil {
 			value = utils.ToStr(val)
 		} else {
@@ -1668,6 +2171,10 @@ end:
 
 // Set one value to struct column field.
 func (d *dbBase) setFieldValue(fi *models.FieldInfo, value interface{}, field reflect.Value) (interface{}, error) { // NOSONAR
+	if fi.JSON {
+		return value, setJSONFieldValue(fi, value, field)
+	}
+
 	fieldType := fi.FieldType
 	isNative := !fi.IsFielder
 
@@ -1695,7 +2202,8 @@ setValue:
 				field.SetBool(value.(bool))
 			}
 		}
-	case fieldType == TypeVarCharField || fieldType == TypeCharField:
+	case fieldType == TypeVarCharField || fieldType == TypeCharField || fieldType == TypeTextField ||
+		fieldType == TypeJSONField || fieldType == TypeJsonbField:
 		if isNative {
 			if ns, ok := field.Interface().(sql.NullString); ok {
 				if value == nil {
@@ -1900,10 +2408,26 @@ func (d *dbBase) ReadValues(ctx context.Context, q dbQuerier, qs querySet, mi *m
 
 	Q := d.ins.TableQuote()
 
+	// the args of annotations are in the SELECT, before the others
+	var annotationArgs []interface{}
+	selectAnnotation := func(a annotation) {
+		col, args := tables.getAnnotationSQL(a, tz)
+		cols = append(cols, col)
+		infos = append(infos, nil)
+		annotationArgs = append(annotationArgs, args...)
+	}
+
 	if hasExprs {
 		cols = make([]string, 0, len(exprs))
 		infos = make([]*models.FieldInfo, 0, len(exprs))
+	outExprs:
 		for _, ex := range exprs {
+			for _, a := range qs.annotations {
+				if a.name == ex {
+					selectAnnotation(a)
+					continue outExprs
+				}
+			}
 			index, name, fi, suc := tables.parseExprs(mi, strings.Split(ex, ExprSep))
 			if !suc {
 				panic(fmt.Errorf("unknown field/column name `%s`", ex))
@@ -1918,9 +2442,13 @@ func (d *dbBase) ReadValues(ctx context.Context, q dbQuerier, qs querySet, mi *m
 			cols = append(cols, fmt.Sprintf("T0.%s%s%s %s%s%s", Q, fi.Column, Q, Q, fi.Name, Q))
 			infos = append(infos, fi)
 		}
+		for _, a := range qs.annotations {
+			selectAnnotation(a)
+		}
 	}
 
 	query, args := d.readValuesSQL(tables, cols, qs, mi, cond, tz)
+	args = append(annotationArgs, args...)
 
 	rs, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -2038,6 +2566,26 @@ func (d *dbBase) MaxLimit() uint64 {
 	return 18446744073709551615
 }
 
+// GenerateLimitSQL generate the LIMIT/OFFSET clause, the negative limit means no limit.
+func (d *dbBase) GenerateLimitSQL(offset int64, limit int64, ordered bool) (limits string) {
+	if limit < 0 {
+		// no limit
+		if offset > 0 {
+			maxLimit := d.ins.MaxLimit()
+			if maxLimit == 0 {
+				limits = fmt.Sprintf("OFFSET %d", offset)
+			} else {
+				limits = fmt.Sprintf("LIMIT %d OFFSET %d", maxLimit, offset)
+			}
+		}
+	} else if offset <= 0 {
+		limits = fmt.Sprintf("LIMIT %d", limit)
+	} else {
+		limits = fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	}
+	return
+}
+
 // TableQuote return quote.
 func (d *dbBase) TableQuote() string {
 	return "`"
@@ -2053,6 +2601,11 @@ func (d *dbBase) HasReturningID(*models.ModelInfo, *string) bool {
 	return false
 }
 
+// HasReturning add the RETURNING of cols to query, false if it's not supported.
+func (d *dbBase) HasReturning(*string, []string) bool {
+	return false
+}
+
 // sync auto key
 func (d *dbBase) setval(ctx context.Context, db dbQuerier, mi *models.ModelInfo, autoFields []string) error {
 	return nil
@@ -2171,6 +2724,16 @@ func (d *dbBase) GenerateSpecifyIndex(tableName string, useIndex int, indexes []
 	return fmt.Sprintf(` %s INDEX(%s) `, useWay, strings.Join(s, `,`))
 }
 
+// GenerateForUpdate return the table hints and the suffix of the select locking the rows,
+// the table hints are the specifying index clause of useIndex and indexes.
+func (d *dbBase) GenerateForUpdate(tableName string, useIndex int, indexes []string) (string, string) {
+	var specifyIndexes string
+	if len(indexes) > 0 {
+		specifyIndexes = d.ins.GenerateSpecifyIndex(tableName, useIndex, indexes)
+	}
+	return specifyIndexes, " FOR UPDATE"
+}
+
 const literal_6392 = " WHERE "
 
 const literal_3672 = "db value convert failed `%v` %s"
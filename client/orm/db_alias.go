@@ -19,6 +19,7 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -35,6 +36,7 @@ const (
 	DROracle                     // oracle
 	DRPostgres                   // pgsql
 	DRTiDB                       // TiDB
+	DRMsSQL                      // mssql
 )
 
 // database driver string.
@@ -57,13 +59,15 @@ var _ Driver = new(driver)
 var (
 	dataBaseCache = &_dbCache{cache: make(map[string]*alias)}
 	drivers       = map[string]DriverType{
-		"mysql":    DRMySQL,
-		"postgres": DRPostgres,
-		"sqlite3":  DRSqlite,
-		"tidb":     DRTiDB,
-		"oracle":   DROracle,
-		"oci8":     DROracle, // github.com/mattn/go-oci8
-		"ora":      DROracle, // https://github.com/rana/ora
+		"mysql":     DRMySQL,
+		"postgres":  DRPostgres,
+		"sqlite3":   DRSqlite,
+		"tidb":      DRTiDB,
+		"oracle":    DROracle,
+		"oci8":      DROracle, // github.com/mattn/go-oci8
+		"ora":       DROracle, // https://github.com/rana/ora
+		"mssql":     DRMsSQL,  // github.com/microsoft/go-mssqldb
+		"sqlserver": DRMsSQL,  // github.com/microsoft/go-mssqldb
 	}
 	dbBasers = map[DriverType]dbBaser{
 		DRMySQL:    newdbBaseMysql(),
@@ -71,6 +75,7 @@ var (
 		DROracle:   newdbBaseOracle(),
 		DRPostgres: newdbBasePostgres(),
 		DRTiDB:     newdbBaseTidb(),
+		DRMsSQL:    newdbBaseMssql(),
 	}
 )
 
@@ -110,6 +115,7 @@ type DB struct {
 	DB                  *sql.DB
 	stmtDecorators      *lru.Cache
 	stmtDecoratorsLimit int
+	replicas            atomic.Pointer[replicaSet]
 }
 
 var (
@@ -188,6 +194,10 @@ func (d *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
 }
 
 func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if r := d.replica(ctx, query); r != nil {
+		return r.QueryContext(ctx, query, args...)
+	}
+
 	if d.stmtDecorators == nil {
 		return d.DB.QueryContext(ctx, query, args...)
 	}
@@ -206,6 +216,10 @@ func (d *DB) QueryRow(query string, args ...interface{}) *sql.Row {
 }
 
 func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if r := d.replica(ctx, query); r != nil {
+		return r.QueryRowContext(ctx, query, args...)
+	}
+
 	if d.stmtDecorators == nil {
 		return d.DB.QueryRowContext(ctx, query, args...)
 	}
@@ -281,6 +295,39 @@ func (t *TxDB) QueryRowContext(ctx context.Context, query string, args ...interf
 	return t.tx.QueryRowContext(ctx, query, args...)
 }
 
+// savepointDB is the nested transaction of the transaction in dbQuerier, see txOrm.BeginWithCtxAndOpts
+type savepointDB struct {
+	dbQuerier
+	name string
+	done bool
+}
+
+var _ txEnder = new(savepointDB)
+
+func (s *savepointDB) Commit() error {
+	return s.end("RELEASE SAVEPOINT ")
+}
+
+func (s *savepointDB) Rollback() error {
+	return s.end("ROLLBACK TO SAVEPOINT ")
+}
+
+func (s *savepointDB) RollbackUnlessCommit() error {
+	if s.done {
+		return nil
+	}
+	return s.Rollback()
+}
+
+func (s *savepointDB) end(stmt string) error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	_, err := s.ExecContext(context.Background(), stmt+s.name)
+	return err
+}
+
 type alias struct {
 	Name            string
 	Driver          DriverType
@@ -295,6 +342,9 @@ type alias struct {
 	DbBaser         dbBaser
 	TZ              *time.Location
 	Engine          string
+
+	// ReplicaHealthCheckInterval is used when the alias is added as a replica
+	ReplicaHealthCheckInterval time.Duration
 }
 
 func detectTZ(al *alias) { // NOSONAR
@@ -337,7 +387,7 @@ func detectTZ(al *alias) { // NOSONAR
 			al.Engine = "INNODB"
 		}
 
-	case DRSqlite, DROracle:
+	case DRSqlite, DROracle, DRMsSQL:
 		al.TZ = time.UTC
 
 	case DRPostgres:
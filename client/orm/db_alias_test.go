@@ -15,10 +15,14 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/orm/hints"
 )
 
 func TestRegisterDataBase(t *testing.T) {
@@ -86,3 +90,74 @@ func TestDBCache(t *testing.T) {
 	assert.NotNil(t, al)
 	assert.True(t, ok)
 }
+
+func TestReplica(t *testing.T) {
+	aliasName := "TestReplica"
+	primarySource := "file:replica_primary?mode=memory&cache=shared"
+	replicaSource := "file:replica_replica?mode=memory&cache=shared"
+
+	// keep the replica memory db alive and seed it
+	seed, err := sql.Open("sqlite3", replicaSource)
+	assert.Nil(t, err)
+	defer seed.Close()
+	_, err = seed.Exec("CREATE TABLE replica_test (name TEXT)")
+	assert.Nil(t, err)
+	_, err = seed.Exec("INSERT INTO replica_test VALUES ('replica')")
+	assert.Nil(t, err)
+
+	assert.NotNil(t, RegisterReplica(aliasName, replicaSource))
+
+	assert.Nil(t, RegisterDataBase(aliasName, "sqlite3", primarySource))
+	o := NewOrmUsingDB(aliasName)
+	_, err = o.Raw("CREATE TABLE replica_test (name TEXT)").Exec()
+	assert.Nil(t, err)
+	_, err = o.Raw("INSERT INTO replica_test VALUES ('primary')").Exec()
+	assert.Nil(t, err)
+
+	assert.Nil(t, RegisterReplica(aliasName, replicaSource, ReplicaHealthCheckInterval(time.Hour)))
+
+	var name string
+	assert.Nil(t, o.Raw("SELECT name FROM replica_test").QueryRow(&name))
+	assert.Equal(t, "replica", name)
+
+	ctx := WithHints(context.Background(), hints.UsePrimary())
+	assert.Nil(t, o.RawWithCtx(ctx, "SELECT name FROM replica_test").QueryRow(&name))
+	assert.Equal(t, "primary", name)
+
+	tx, err := o.Begin()
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Raw("SELECT name FROM replica_test").QueryRow(&name))
+	assert.Equal(t, "primary", name)
+	assert.Nil(t, tx.Rollback())
+
+	// the primary is used while no replica is healthy
+	al := getDbAlias(aliasName)
+	r := al.DB.replicas.Load().replicas[0]
+	assert.Nil(t, r.db.DB.Close())
+	r.check()
+	assert.False(t, r.isHealthy())
+	assert.Nil(t, o.Raw("SELECT name FROM replica_test").QueryRow(&name))
+	assert.Equal(t, "primary", name)
+
+	// the health checks are stopped with the replicas
+	assert.Nil(t, RemoveReplicas(aliasName))
+	assert.Nil(t, al.DB.replicas.Load())
+	select {
+	case <-r.stop:
+	default:
+		t.Error("the health check of the removed replica is not stopped")
+	}
+	assert.NotNil(t, RemoveReplicas("TestReplicaNotExist"))
+}
+
+func TestIsReadQuery(t *testing.T) {
+	assert.True(t, isReadQuery("SELECT * FROM user"))
+	assert.True(t, isReadQuery(" (select id FROM user) UNION (SELECT id FROM post)"))
+	assert.False(t, isReadQuery("SELECT * FROM user WHERE id = ? FOR UPDATE"))
+	assert.False(t, isReadQuery("SELECT * FROM user LOCK IN SHARE MODE"))
+	assert.False(t, isReadQuery("SELECT * FROM [user] WITH (UPDLOCK, ROWLOCK) WHERE id = ?"))
+	assert.False(t, isReadQuery("SELECT * FROM [user] WITH (INDEX([idx]), UPDLOCK, ROWLOCK)  WHERE id = ?"))
+	assert.False(t, isReadQuery("INSERT INTO user VALUES (1)"))
+	assert.False(t, isReadQuery("UPDATE user SET name = 'select'"))
+	assert.False(t, isReadQuery("SEL"))
+}
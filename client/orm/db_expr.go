@@ -0,0 +1,139 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+)
+
+// the operators which can compare a column with an expression in Filter
+var exprOperators = map[string]string{
+	"exact": "=",
+	"eq":    "=",
+	"ne":    "!=",
+	"nq":    "!=",
+	"gt":    ">",
+	"gte":   ">=",
+	"lt":    "<",
+	"lte":   "<=",
+}
+
+var annotationName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// annotation is a computed column of Values, see QuerySeter.Annotate
+type annotation struct {
+	name string
+	e    expr.Expr
+}
+
+// addAnnotation add a to the annotations, the one of same name is replaced
+func addAnnotation(annotations []annotation, a annotation) []annotation {
+	res := make([]annotation, 0, len(annotations)+1)
+	for _, v := range annotations {
+		if v.name != a.name {
+			res = append(res, v)
+		}
+	}
+	return append(res, a)
+}
+
+// condExpr return the expression if it is the only arg of the condition
+func condExpr(p condValue) (expr.Expr, bool) {
+	if p.isRaw || len(p.args) != 1 {
+		return nil, false
+	}
+	e, ok := p.args[0].(expr.Expr)
+	return e, ok
+}
+
+// exprValue is an expression rendered in the SET of update
+type exprValue struct {
+	sql  string
+	args []interface{}
+}
+
+// exprBuilder build the sql of expr.Expr.
+// The columns are resolved by tables like the expressions of Filter,
+// or by the fields of mi with owner if tables is nil, such as the SET of update.
+type exprBuilder struct {
+	tables *dbTables
+	mi     *models.ModelInfo
+	owner  string
+	quote  string
+	tz     *time.Location
+	buf    strings.Builder
+	args   []interface{}
+}
+
+var _ expr.Builder = new(exprBuilder)
+
+func (b *exprBuilder) WriteColumn(name string) {
+	if b.tables != nil {
+		index, _, fi, suc := b.tables.parseExprs(b.mi, strings.Split(name, ExprSep))
+		if !suc {
+			panic(fmt.Errorf(literal_7658, name))
+		}
+		b.buf.WriteString(fmt.Sprintf("%s.%s%s%s", index, b.quote, fi.Column, b.quote))
+		return
+	}
+	fi, ok := b.mi.Fields.GetByAny(name)
+	if !ok || !fi.DBcol {
+		panic(fmt.Errorf("wrong field/column name `%s`", name))
+	}
+	b.buf.WriteString(b.owner + b.quote + fi.Column + b.quote)
+}
+
+func (b *exprBuilder) WriteArg(arg interface{}) {
+	params := getFlatParams(nil, []interface{}{arg}, b.tz)
+	if len(params) != 1 {
+		panic(fmt.Errorf("expression arg `%v` need 1 value not %d", arg, len(params)))
+	}
+	b.buf.WriteString("?")
+	b.args = append(b.args, params[0])
+}
+
+func (b *exprBuilder) WriteString(s string) {
+	b.buf.WriteString(s)
+}
+
+// buildExpr return the sql and args of e, whose columns are in the tables of the query
+func (t *dbTables) buildExpr(e expr.Expr, tz *time.Location) (string, []interface{}) {
+	b := &exprBuilder{tables: t, mi: t.mi, quote: t.base.TableQuote(), tz: tz}
+	e.Build(b)
+	return b.buf.String(), b.args
+}
+
+// buildSetExpr return the expression of e for the SET of update
+func (d *dbBase) buildSetExpr(mi *models.ModelInfo, e expr.Expr, tz *time.Location) exprValue {
+	b := &exprBuilder{mi: mi, quote: d.ins.TableQuote(), tz: tz}
+	if d.ins.SupportUpdateJoin() {
+		b.owner = "T0."
+	}
+	e.Build(b)
+	return exprValue{sql: b.buf.String(), args: b.args}
+}
+
+// getAnnotationSQL return the column and args of the annotation
+func (t *dbTables) getAnnotationSQL(a annotation, tz *time.Location) (string, []interface{}) {
+	Q := t.base.TableQuote()
+	sql, args := t.buildExpr(a.e, tz)
+	return fmt.Sprintf("%s %s%s%s", sql, Q, a.name, Q), args
+}
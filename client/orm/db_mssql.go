@@ -0,0 +1,382 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jialequ/android-sdk/client/orm/hints"
+	"github.com/jialequ/android-sdk/client/orm/internal/buffers"
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+)
+
+// mssql operators.
+var mssqlOperators = map[string]string{
+	"exact":       "= ?",
+	"iexact":      "= UPPER(?)",
+	"contains":    literal_0492,
+	"icontains":   literal_7315,
+	"gt":          "> ?",
+	"gte":         ">= ?",
+	"lt":          "< ?",
+	"lte":         "<= ?",
+	"eq":          "= ?",
+	"ne":          "!= ?",
+	"startswith":  literal_0492,
+	"endswith":    literal_0492,
+	"istartswith": literal_7315,
+	"iendswith":   literal_7315,
+}
+
+// mssql column field types.
+var mssqlTypes = map[string]string{
+	"auto":                "IDENTITY(1,1) NOT NULL PRIMARY KEY",
+	"pk":                  "NOT NULL PRIMARY KEY",
+	"bool":                "bit",
+	"string":              "nvarchar(%d)",
+	"string-char":         "nchar(%d)",
+	"string-text":         "nvarchar(max)",
+	"time.Time-date":      "date",
+	"time.Time-clock":     "time",
+	"time.Time":           "datetime2",
+	"int8":                `smallint CHECK("%COL%" >= -127 AND "%COL%" <= 128)`,
+	"int16":               "smallint",
+	"int32":               "int",
+	"int64":               "bigint",
+	"uint8":               "tinyint",
+	"uint16":              `int CHECK("%COL%" >= 0)`,
+	"uint32":              `bigint CHECK("%COL%" >= 0)`,
+	"uint64":              `decimal(20, 0) CHECK("%COL%" >= 0)`,
+	"float64":             "float",
+	"float64-decimal":     "decimal(%d, %d)",
+	"json":                "nvarchar(max)",
+	"time.Time-precision": "datetime2(%d)",
+}
+
+// mssql dbBaser.
+type dbBaseMssql struct {
+	dbBase
+}
+
+var _ dbBaser = new(dbBaseMssql)
+
+// Get mssql operator.
+func (d *dbBaseMssql) OperatorSQL(operator string) string {
+	return mssqlOperators[operator]
+}
+
+// generate functioned sql string, such as UPPER(text).
+func (d *dbBaseMssql) GenerateOperatorLeftCol(fi *models.FieldInfo, operator string, leftCol *string) {
+	switch operator {
+	case "iexact", "icontains", "istartswith", "iendswith":
+		*leftCol = fmt.Sprintf("UPPER(%s)", *leftCol)
+	}
+}
+
+// GenerateJSONExtract extract the scalar value at path by JSON_VALUE.
+func (d *dbBaseMssql) GenerateJSONExtract(col string, path []string, _ interface{}) string {
+	if len(path) == 0 {
+		return col
+	}
+	return fmt.Sprintf("JSON_VALUE(%s, '%s')", col, jsonPath(path))
+}
+
+// GenerateJSONContains only support the scalar arg in mssql,
+// it's true if the json at path is an array or object has the value arg.
+func (d *dbBaseMssql) GenerateJSONContains(col string, path []string, arg interface{}) (string, []interface{}) {
+	switch reflect.Indirect(reflect.ValueOf(arg)).Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array, reflect.Invalid:
+		panic(fmt.Errorf("operator `json_contains` only support scalar arg in mssql, not `%T`", arg))
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM OPENJSON(%s, '%s') WHERE value = ?)", col, jsonPath(path)),
+		getFlatParams(nil, []interface{}{arg}, nil)
+}
+
+// mssql unsupports updating joined record by the syntax of dbBase.
+func (d *dbBaseMssql) SupportUpdateJoin() bool {
+	return false
+}
+
+func (d *dbBaseMssql) MaxLimit() uint64 {
+	return 0
+}
+
+// GenerateLimitSQL page by OFFSET/FETCH, which must follow an ORDER BY in mssql.
+// The unordered query is ordered by the first selected column, which also works with DISTINCT and COUNT.
+func (d *dbBaseMssql) GenerateLimitSQL(offset int64, limit int64, ordered bool) string {
+	if limit < 0 && offset <= 0 {
+		return ""
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var limits string
+	if !ordered {
+		limits = "ORDER BY 1 "
+	}
+	limits += fmt.Sprintf("OFFSET %d ROWS", offset)
+	if limit >= 0 {
+		limits += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return limits
+}
+
+// mssql quote is ".
+func (d *dbBaseMssql) TableQuote() string {
+	return `"`
+}
+
+// mssql value placeholder is @pn.
+// replace default ? to @pn.
+func (d *dbBaseMssql) ReplaceMarks(query *string) {
+	q := *query
+	num := strings.Count(q, "?")
+	if num == 0 {
+		return
+	}
+	data := make([]byte, 0, len(q)+num*2)
+	num = 1
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		if c == '?' {
+			data = append(data, '@', 'p')
+			data = append(data, []byte(strconv.Itoa(num))...)
+			num++
+		} else {
+			data = append(data, c)
+		}
+	}
+	*query = string(data)
+}
+
+// make OUTPUT INSERTED sql support for mssql.
+// the clause is put before the VALUES of INSERT, or at the end of MERGE.
+func (d *dbBaseMssql) HasReturningID(mi *models.ModelInfo, query *string) bool {
+	fi := mi.Fields.Pk
+	if fi.FieldType&IsPositiveIntegerField == 0 && fi.FieldType&IsIntegerField == 0 {
+		return false
+	}
+
+	if query != nil {
+		output := fmt.Sprintf(`OUTPUT INSERTED."%s"`, fi.Column)
+		q := *query
+		if strings.HasPrefix(q, "MERGE ") {
+			*query = fmt.Sprintf("%s %s;", strings.TrimSuffix(q, ";"), output)
+		} else if i := strings.Index(q, ") VALUES ("); i >= 0 {
+			*query = q[:i+1] + " " + output + q[i+1:]
+		} else {
+			*query = fmt.Sprintf("%s %s", q, output)
+		}
+	}
+	return true
+}
+
+// InsertOrUpdate a row by MERGE, the conflict columns are required as the first arg,
+// such as "name" or "name,email".
+func (d *dbBaseMssql) InsertOrUpdate(ctx context.Context, q dbQuerier, mi *models.ModelInfo, ind reflect.Value, a *alias, args ...string) (int64, error) {
+	names := make([]string, 0, len(mi.Fields.DBcols)-1)
+
+	values, _, err := d.collectValues(mi, ind, mi.Fields.DBcols, true, true, &names, a.TZ)
+	if err != nil {
+		return 0, err
+	}
+
+	query, err := d.InsertOrUpdateSQL(names, &values, mi, a, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if !d.ins.HasReturningID(mi, &query) {
+		_, err := q.ExecContext(ctx, query, values...)
+		if err != nil {
+			return 0, err
+		}
+		return 0, ErrLastInsertIdUnavailable
+	}
+
+	row := q.QueryRowContext(ctx, query, values...)
+	var id int64
+	err = row.Scan(&id)
+	return id, err
+}
+
+// InsertOrUpdateSQL generate the MERGE sql of InsertOrUpdate.
+// The values are only used by the USING clause, so they are not changed.
+func (d *dbBaseMssql) InsertOrUpdateSQL(names []string, values *[]interface{}, mi *models.ModelInfo, a *alias, args ...string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("`%s` use InsertOrUpdate must have a conflict column", a.DriverName)
+	}
+
+	Q := d.ins.TableQuote()
+	quoted := func(prefix, name string) string {
+		return fmt.Sprintf("%s%s%s%s", prefix, Q, name, Q)
+	}
+
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[strings.ToLower(name)] = true
+	}
+
+	conflicts := make(map[string]bool)
+	var on []string
+	for _, col := range strings.Split(args[0], ",") {
+		col = strings.Trim(strings.TrimSpace(col), Q)
+		if !exists[strings.ToLower(col)] {
+			return "", fmt.Errorf("conflict column `%s` of InsertOrUpdate is not in the inserted columns of `%s`", col, mi.FullName)
+		}
+		conflicts[strings.ToLower(col)] = true
+		on = append(on, fmt.Sprintf("%s = %s", quoted("T.", col), quoted("S.", col)))
+	}
+
+	argsMap := map[string]string{}
+	// Get on the key-value pairs
+	for _, v := range args[1:] {
+		kv := strings.Split(v, "=")
+		if len(kv) == 2 {
+			argsMap[strings.ToLower(strings.TrimSpace(kv[0]))] = kv[1]
+		}
+	}
+
+	sources := make([]string, len(names))
+	inserts := make([]string, len(names))
+	cols := make([]string, len(names))
+	var updates []string
+	version := versionField(mi)
+	for i, name := range names {
+		sources[i] = "? AS " + quoted("", name)
+		inserts[i] = quoted("S.", name)
+		cols[i] = quoted("", name)
+		if conflicts[strings.ToLower(name)] {
+			continue
+		}
+		if valueStr := argsMap[strings.ToLower(name)]; valueStr != "" {
+			updates = append(updates, fmt.Sprintf("%s = %s", quoted("T.", name), valueStr))
+		} else if version != nil && version.Column == name {
+			// the version of the existing row is increased instead of overwritten
+			updates = append(updates, fmt.Sprintf("%s = %s + 1", quoted("T.", name), quoted("T.", name)))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = %s", quoted("T.", name), quoted("S.", name)))
+		}
+	}
+	if len(updates) == 0 {
+		// keep the matched row in the OUTPUT by an update changes nothing
+		for _, name := range names {
+			updates = append(updates, fmt.Sprintf("%s = %s", quoted("T.", name), quoted("S.", name)))
+		}
+	}
+
+	buf := buffers.Get()
+	defer buffers.Put(buf)
+
+	_, _ = buf.WriteString("MERGE INTO ")
+	_, _ = buf.WriteString(quoted("", mi.Table))
+	_, _ = buf.WriteString(" WITH (HOLDLOCK) AS T USING (SELECT ")
+	_, _ = buf.WriteString(strings.Join(sources, ", "))
+	_, _ = buf.WriteString(") AS S ON (")
+	_, _ = buf.WriteString(strings.Join(on, " AND "))
+	_, _ = buf.WriteString(") WHEN MATCHED THEN UPDATE SET ")
+	_, _ = buf.WriteString(strings.Join(updates, ", "))
+	_, _ = buf.WriteString(" WHEN NOT MATCHED THEN INSERT (")
+	_, _ = buf.WriteString(strings.Join(cols, ", "))
+	_, _ = buf.WriteString(") VALUES (")
+	_, _ = buf.WriteString(strings.Join(inserts, ", "))
+	_, _ = buf.WriteString(");")
+
+	query := buf.String()
+
+	d.ins.ReplaceMarks(&query)
+
+	return query, nil
+}
+
+// show table sql for mssql.
+func (d *dbBaseMssql) ShowTablesQuery() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE' AND table_schema = SCHEMA_NAME()"
+}
+
+// show table Columns sql for mssql.
+func (d *dbBaseMssql) ShowColumnsQuery(table string) string {
+	return fmt.Sprintf("SELECT column_name, data_type, is_nullable FROM information_schema.columns WHERE table_schema = SCHEMA_NAME() AND table_name = '%s'", table)
+}
+
+// Get column types of mssql.
+func (d *dbBaseMssql) DbTypes() map[string]string {
+	return mssqlTypes
+}
+
+// check index exist in mssql.
+func (d *dbBaseMssql) IndexExists(ctx context.Context, db dbQuerier, table string, name string) bool {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM sys.indexes WHERE object_id = OBJECT_ID(N'%s') AND name = N'%s'", table, name)
+	row := db.QueryRowContext(ctx, query)
+	var cnt int
+	row.Scan(&cnt)
+	return cnt > 0
+}
+
+// GenerateSpecifyIndex return a table hint of index,
+// mssql can only force the indexes, so that USE INDEX acts as FORCE INDEX.
+func (d *dbBaseMssql) GenerateSpecifyIndex(tableName string, useIndex int, indexes []string) string {
+	hint := d.indexHint(useIndex, indexes)
+	if hint == "" {
+		return ``
+	}
+	return fmt.Sprintf(` WITH (%s) `, hint)
+}
+
+// GenerateForUpdate lock the rows by the table hints UPDLOCK and ROWLOCK,
+// as mssql does not support SELECT FOR UPDATE.
+func (d *dbBaseMssql) GenerateForUpdate(tableName string, useIndex int, indexes []string) (string, string) {
+	tableHints := []string{"UPDLOCK", "ROWLOCK"}
+	if len(indexes) > 0 {
+		if hint := d.indexHint(useIndex, indexes); hint != "" {
+			tableHints = append([]string{hint}, tableHints...)
+		}
+	}
+	return fmt.Sprintf(` WITH (%s) `, strings.Join(tableHints, `, `)), ``
+}
+
+// indexHint return the INDEX table hint, it's empty if useIndex is not supported
+func (d *dbBaseMssql) indexHint(useIndex int, indexes []string) string {
+	switch useIndex {
+	case hints.KeyUseIndex, hints.KeyForceIndex:
+	case hints.KeyIgnoreIndex:
+		DebugLog.Println("[WARN] SQL Server does not support ignoring index, so that action is ignored")
+		return ``
+	default:
+		DebugLog.Println("[WARN] Not a valid specifying action, so that action is ignored")
+		return ``
+	}
+
+	Q := d.TableQuote()
+	s := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		s = append(s, fmt.Sprintf(`%s%s%s`, Q, index, Q))
+	}
+	return fmt.Sprintf(`INDEX(%s)`, strings.Join(s, `,`))
+}
+
+// create new mssql dbBaser.
+func newdbBaseMssql() dbBaser {
+	b := new(dbBaseMssql)
+	b.ins = b
+	return b
+}
+
+const literal_7315 = "LIKE UPPER(?) ESCAPE '\\'"
@@ -64,6 +64,7 @@ var mysqlTypes = map[string]string{
 	"uint64":              "bigint unsigned",
 	"float64":             "double precision",
 	"float64-decimal":     "numeric(%d, %d)",
+	"json":                "json",
 	"time.Time-precision": "datetime(%d)",
 }
 
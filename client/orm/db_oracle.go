@@ -53,6 +53,7 @@ var oracleTypes = map[string]string{
 	"uint64":              "INTEGER",
 	"float64":             "NUMBER",
 	"float64-decimal":     "NUMBER(%d, %d)",
+	"json":                "CLOB",
 	"time.Time-precision": "TIMESTAMP(%d)",
 }
 
@@ -75,6 +76,19 @@ func (d *dbBaseOracle) OperatorSQL(operator string) string {
 	return oracleOperators[operator]
 }
 
+// GenerateJSONExtract extract the scalar at path by JSON_VALUE.
+func (d *dbBaseOracle) GenerateJSONExtract(col string, path []string, _ interface{}) string {
+	if len(path) == 0 {
+		return col
+	}
+	return fmt.Sprintf("JSON_VALUE(%s, '%s')", col, jsonPath(path))
+}
+
+// GenerateJSONContains not implement.
+func (d *dbBaseOracle) GenerateJSONContains(string, []string, interface{}) (string, []interface{}) {
+	panic(ErrNotImplement)
+}
+
 // DbTypes Get oracle table field types.
 func (d *dbBaseOracle) DbTypes() map[string]string {
 	return oracleTypes
@@ -17,7 +17,9 @@ package orm
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/jialequ/android-sdk/client/orm/internal/models"
 )
@@ -87,6 +89,33 @@ func (d *dbBasePostgres) GenerateOperatorLeftCol(fi *models.FieldInfo, operator
 	}
 }
 
+// GenerateJSONExtract extract the value at path as text by #>>, which is parenthesized
+// as :: of GenerateOperatorLeftCol binds tighter than #>>.
+// It's cast to numeric or boolean if arg is, so that "10" > "3" is not compared as text.
+func (d *dbBasePostgres) GenerateJSONExtract(col string, path []string, arg interface{}) string {
+	if len(path) == 0 {
+		return col
+	}
+	sql := fmt.Sprintf("(%s #>> '{%s}')", col, strings.Join(path, ","))
+	switch reflect.Indirect(reflect.ValueOf(arg)).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return sql + "::numeric"
+	case reflect.Bool:
+		return sql + "::boolean"
+	}
+	return sql
+}
+
+// GenerateJSONContains check the containment by @>, the json column is cast to jsonb.
+func (d *dbBasePostgres) GenerateJSONContains(col string, path []string, arg interface{}) (string, []interface{}) {
+	if len(path) > 0 {
+		col = fmt.Sprintf("%s #> '{%s}'", col, strings.Join(path, ","))
+	}
+	return fmt.Sprintf("(%s)::jsonb @> ?::jsonb", col), []interface{}{jsonArg(arg)}
+}
+
 // postgresql unsupports updating joined record.
 func (d *dbBasePostgres) SupportUpdateJoin() bool {
 	return false
@@ -142,6 +171,12 @@ func (d *dbBasePostgres) HasReturningID(mi *models.ModelInfo, query *string) boo
 	return true
 }
 
+// HasReturning add RETURNING of cols for postgresql.
+func (d *dbBasePostgres) HasReturning(query *string, cols []string) bool {
+	*query = fmt.Sprintf(`%s RETURNING "%s"`, *query, strings.Join(cols, `", "`))
+	return true
+}
+
 // sync auto key
 func (d *dbBasePostgres) setval(ctx context.Context, db dbQuerier, mi *models.ModelInfo, autoFields []string) error {
 	if len(autoFields) == 0 {
@@ -0,0 +1,280 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jialequ/android-sdk/client/orm/hints"
+	"github.com/jialequ/android-sdk/core/utils"
+)
+
+// DefaultReplicaHealthCheckInterval is how often a replica is pinged when
+// ReplicaHealthCheckInterval is not used.
+var DefaultReplicaHealthCheckInterval = 10 * time.Second
+
+type hintsCtxKey struct{}
+
+// WithHints returns a copy of ctx carrying the hints,
+// they are applied to the queries which are executed with the ctx.
+// Only the db level hints, like hints.UsePrimary, are supported.
+// for example:
+//
+//	ctx = orm.WithHints(ctx, hints.UsePrimary())
+//	err = o.ReadWithCtx(ctx, &user) // read from the primary even if the alias has replicas
+func WithHints(ctx context.Context, hs ...utils.KV) context.Context {
+	if prev, ok := ctx.Value(hintsCtxKey{}).([]utils.KV); ok {
+		hs = append(append(make([]utils.KV, 0, len(prev)+len(hs)), prev...), hs...)
+	}
+	return context.WithValue(ctx, hintsCtxKey{}, hs)
+}
+
+// usePrimary reports whether hints.UsePrimary is set in ctx
+func usePrimary(ctx context.Context) bool {
+	hs, ok := ctx.Value(hintsCtxKey{}).([]utils.KV)
+	if !ok {
+		return false
+	}
+	primary := false
+	utils.NewKVs(hs...).IfContains(hints.KeyUsePrimary, func(value interface{}) {
+		primary, _ = value.(bool)
+	})
+	return primary
+}
+
+// isReadQuery reports whether query can be sent to a replica.
+// Only plain SELECT statements are, locking reads stay on the primary,
+// including the table hints of mssql like WITH (UPDLOCK).
+func isReadQuery(query string) bool {
+	q := strings.TrimLeft(query, " \t\r\n(")
+	if len(q) < 6 || !strings.EqualFold(q[:6], "SELECT") {
+		return false
+	}
+	q = strings.ToUpper(q)
+	for _, lock := range lockingClauses {
+		if strings.Contains(q, lock) {
+			return false
+		}
+	}
+	return true
+}
+
+// lockingClauses are the clauses of locking reads
+var lockingClauses = []string{" FOR UPDATE", " FOR SHARE", " LOCK IN SHARE MODE", "UPDLOCK", "XLOCK", "HOLDLOCK"}
+
+// replica is a read only database of an alias
+type replica struct {
+	db       *DB
+	interval time.Duration
+	healthy  int32
+	// closeDB is true if the db is opened by RegisterReplica, so that it's closed with the replica
+	closeDB bool
+	stop    chan struct{}
+}
+
+// healthCheck ping the replica every interval until it's closed
+func (r *replica) healthCheck() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.check()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// close stops the health check, and closes the db if it's opened by RegisterReplica
+func (r *replica) close() {
+	close(r.stop)
+	if r.closeDB {
+		_ = r.db.DB.Close()
+	}
+}
+
+// check ping the replica and update its health state
+func (r *replica) check() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+	defer cancel()
+	if err := r.db.DB.PingContext(ctx); err != nil {
+		if atomic.SwapInt32(&r.healthy, 0) == 1 {
+			DebugLog.Printf("replica health check failed, %s\n", err.Error())
+		}
+		return
+	}
+	atomic.StoreInt32(&r.healthy, 1)
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+// replicaSet balances the reads over the healthy replicas in round robin
+type replicaSet struct {
+	mux      sync.RWMutex
+	replicas []*replica
+	next     uint32
+}
+
+func (rs *replicaSet) add(r *replica) {
+	rs.mux.Lock()
+	defer rs.mux.Unlock()
+	rs.replicas = append(rs.replicas, r)
+}
+
+// pick return a healthy replica, or nil if there is none
+func (rs *replicaSet) pick() *DB {
+	rs.mux.RLock()
+	defer rs.mux.RUnlock()
+	n := uint32(len(rs.replicas))
+	start := atomic.AddUint32(&rs.next, 1)
+	for i := uint32(0); i < n; i++ {
+		if r := rs.replicas[(start+i)%n]; r.isHealthy() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+// replica return the DB which the query should be sent to, or nil for the primary
+func (d *DB) replica(ctx context.Context, query string) *DB {
+	rs := d.replicas.Load()
+	if rs == nil || usePrimary(ctx) || !isReadQuery(query) {
+		return nil
+	}
+	return rs.pick()
+}
+
+// primary return the DB without its replicas
+func (d *DB) primary() *DB {
+	return &DB{
+		RWMutex:             d.RWMutex,
+		DB:                  d.DB,
+		stmtDecorators:      d.stmtDecorators,
+		stmtDecoratorsLimit: d.stmtDecoratorsLimit,
+	}
+}
+
+func (d *DB) addReplica(r *replica) {
+	d.Lock()
+	rs := d.replicas.Load()
+	if rs == nil {
+		rs = &replicaSet{}
+		d.replicas.Store(rs)
+	}
+	d.Unlock()
+	rs.add(r)
+}
+
+// removeReplicas removes and closes all replicas
+func (d *DB) removeReplicas() {
+	d.Lock()
+	rs := d.replicas.Swap(nil)
+	d.Unlock()
+	if rs == nil {
+		return
+	}
+	rs.mux.Lock()
+	defer rs.mux.Unlock()
+	for _, r := range rs.replicas {
+		r.close()
+	}
+}
+
+// AddReplicaWithDB add db as a read replica of the alias.
+// Plain reads of the alias are balanced over its healthy replicas, while writes, locking reads,
+// transactions and the ctx with hints.UsePrimary stay on the primary.
+// A replica is pinged every ReplicaHealthCheckInterval and skipped while the ping fails,
+// the primary is used if no replica is healthy.
+// The health checks are stopped by RemoveReplicas.
+func AddReplicaWithDB(aliasName string, db *sql.DB, params ...DBOption) error {
+	return addReplicaWithDB(aliasName, db, false, params...)
+}
+
+func addReplicaWithDB(aliasName string, db *sql.DB, closeDB bool, params ...DBOption) error {
+	al, ok := dataBaseCache.get(aliasName)
+	if !ok {
+		return fmt.Errorf("DataBase alias name `%s` not registered", aliasName)
+	}
+
+	ral, err := newAliasWithDb(aliasName, al.DriverName, db, params...)
+	if err != nil {
+		return err
+	}
+
+	interval := ral.ReplicaHealthCheckInterval
+	if interval <= 0 {
+		interval = DefaultReplicaHealthCheckInterval
+	}
+	r := &replica{
+		db:       ral.DB,
+		interval: interval,
+		healthy:  1,
+		closeDB:  closeDB,
+		stop:     make(chan struct{}),
+	}
+	al.DB.addReplica(r)
+
+	go r.healthCheck()
+	return nil
+}
+
+// RemoveReplicas removes all read replicas of the alias, and stops their health checks.
+// The replicas opened by RegisterReplica are closed, while the db of AddReplicaWithDB is left to the caller.
+func RemoveReplicas(aliasName string) error {
+	al, ok := dataBaseCache.get(aliasName)
+	if !ok {
+		return fmt.Errorf("DataBase alias name `%s` not registered", aliasName)
+	}
+	al.DB.removeReplicas()
+	return nil
+}
+
+// RegisterReplica open dataSource with the driver of the alias and add it as a read replica.
+// see AddReplicaWithDB
+func RegisterReplica(aliasName, dataSource string, params ...DBOption) error {
+	al, ok := dataBaseCache.get(aliasName)
+	if !ok {
+		return fmt.Errorf("DataBase alias name `%s` not registered", aliasName)
+	}
+
+	db, err := sql.Open(al.DriverName, dataSource)
+	if err != nil {
+		err = fmt.Errorf("Register replica of db `%s`, %s", aliasName, err.Error())
+		DebugLog.Println(err.Error())
+		return err
+	}
+
+	if err = addReplicaWithDB(aliasName, db, true, params...); err != nil {
+		db.Close()
+		DebugLog.Println(err.Error())
+	}
+	return err
+}
+
+// ReplicaHealthCheckInterval return a hint about the interval to ping a replica
+func ReplicaHealthCheckInterval(v time.Duration) DBOption {
+	return func(al *alias) {
+		al.ReplicaHealthCheckInterval = v
+	}
+}
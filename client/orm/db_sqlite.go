@@ -66,6 +66,7 @@ var sqliteTypes = map[string]string{
 	"uint64":              "bigint unsigned",
 	"float64":             "real",
 	"float64-decimal":     "decimal",
+	"json":                "json",
 }
 
 // sqlite dbBaser.
@@ -96,6 +97,25 @@ func (d *dbBaseSqlite) GenerateOperatorLeftCol(fi *models.FieldInfo, operator st
 	}
 }
 
+// GenerateJSONExtract extract the value at path by json_extract, which returns the sql value of scalar.
+func (d *dbBaseSqlite) GenerateJSONExtract(col string, path []string, _ interface{}) string {
+	if len(path) == 0 {
+		return col
+	}
+	return fmt.Sprintf("json_extract(%s, '%s')", col, jsonPath(path))
+}
+
+// GenerateJSONContains only support the scalar arg in sqlite,
+// it's true if the json at path is an array or object has the value arg, or equals to arg.
+func (d *dbBaseSqlite) GenerateJSONContains(col string, path []string, arg interface{}) (string, []interface{}) {
+	switch reflect.Indirect(reflect.ValueOf(arg)).Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array, reflect.Invalid:
+		panic(fmt.Errorf("operator `json_contains` only support scalar arg in sqlite, not `%T`", arg))
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, '%s') WHERE value = ?)", col, jsonPath(path)),
+		getFlatParams(nil, []interface{}{arg}, nil)
+}
+
 // unable updating joined record in sqlite.
 func (d *dbBaseSqlite) SupportUpdateJoin() bool {
 	return false
@@ -106,6 +126,12 @@ func (d *dbBaseSqlite) MaxLimit() uint64 {
 	return 9223372036854775807
 }
 
+// HasReturning add RETURNING of cols for sqlite, which is supported since sqlite 3.35.
+func (d *dbBaseSqlite) HasReturning(query *string, cols []string) bool {
+	*query = fmt.Sprintf("%s RETURNING `%s`", *query, strings.Join(cols, "`, `"))
+	return true
+}
+
 // Get column types in sqlite.
 func (d *dbBaseSqlite) DbTypes() map[string]string {
 	return sqliteTypes
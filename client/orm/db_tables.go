@@ -329,6 +329,30 @@ loopFor:
 	return
 }
 
+// splitJSONPath split the exprs like attrs__json__tags__0 to the exprs of the json field and the path in it.
+// The keys of path can only be letters, digits and _, the digits are index of array.
+func splitJSONPath(mi *models.ModelInfo, exprs []string) (fieldExprs []string, path []string, isPath bool) {
+	for i := 1; i < len(exprs); i++ {
+		if exprs[i] != jsonExpr {
+			continue
+		}
+		// parse by a new dbTables, so the tables joined by t are not changed
+		if _, _, fi, ok := newDbTables(mi, nil).parseExprs(mi, exprs[:i]); !ok || !isJSONField(fi) {
+			continue
+		}
+		path = exprs[i+1:]
+		for _, key := range path {
+			for _, c := range key {
+				if !(c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+					panic(fmt.Errorf("wrong json path key `%s` in `%s`", key, strings.Join(exprs, ExprSep)))
+				}
+			}
+		}
+		return exprs[:i], path, true
+	}
+	return exprs, nil, false
+}
+
 // generate condition sql.
 func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (where string, params []interface{}) { // NOSONAR
 	if cond == nil || cond.IsEmpty() {
@@ -367,6 +391,8 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 				exprs = exprs[:num]
 			}
 
+			exprs, path, isPath := splitJSONPath(mi, exprs)
+
 			index, _, fi, suc := t.parseExprs(mi, exprs)
 			if !suc {
 				panic(fmt.Errorf(literal_7658, strings.Join(p.exprs, ExprSep)))
@@ -376,6 +402,35 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 				operator = "exact"
 			}
 
+			leftCol := fmt.Sprintf("%s.%s%s%s", index, Q, fi.Column, Q)
+
+			if operator == "json_contains" && !p.isRaw {
+				if !isJSONField(fi) {
+					panic(fmt.Errorf("operator `%s` need a json field, `%s` is not", operator, fi.FullName))
+				}
+				if len(p.args) != 1 {
+					panic(fmt.Errorf("operator `%s` need 1 args not %d", operator, len(p.args)))
+				}
+				sql, args := t.base.GenerateJSONContains(leftCol, path, p.args[0])
+				where += sql + " "
+				params = append(params, args...)
+				continue
+			}
+
+			if e, ok := condExpr(p); ok {
+				op, ok := exprOperators[operator]
+				if !ok {
+					panic(fmt.Errorf("operator `%s` can't compare with an expression", operator))
+				}
+				if isPath {
+					leftCol = t.base.GenerateJSONExtract(leftCol, path, nil)
+				}
+				sql, args := t.buildExpr(e, tz)
+				where += fmt.Sprintf("%s %s %s ", leftCol, op, sql)
+				params = append(params, args...)
+				continue
+			}
+
 			var operSQL string
 			var args []interface{}
 			if p.isRaw {
@@ -384,7 +439,9 @@ func (t *dbTables) getCondSQL(cond *Condition, sub bool, tz *time.Location) (whe
 				operSQL, args = t.base.GenerateOperatorSQL(mi, fi, operator, p.args, tz)
 			}
 
-			leftCol := fmt.Sprintf("%s.%s%s%s", index, Q, fi.Column, Q)
+			if isPath {
+				leftCol = t.base.GenerateJSONExtract(leftCol, path, jsonCompareArg(operator, p))
+			}
 			t.base.GenerateOperatorLeftCol(fi, operator, &leftCol)
 
 			where += fmt.Sprintf("%s %s ", leftCol, operSQL)
@@ -425,7 +482,7 @@ func (t *dbTables) getGroupSQL(groups []string) (groupSQL string) {
 }
 
 // generate order sql.
-func (t *dbTables) getOrderSQL(orders []*order_clause.Order) (orderSQL string) {
+func (t *dbTables) getOrderSQL(orders []*order_clause.Order, tz *time.Location) (orderSQL string, params []interface{}) {
 	if len(orders) == 0 {
 		return
 	}
@@ -434,6 +491,13 @@ func (t *dbTables) getOrderSQL(orders []*order_clause.Order) (orderSQL string) {
 
 	orderSqls := make([]string, 0, len(orders))
 	for _, order := range orders {
+		if e := order.GetExpr(); e != nil {
+			sql, args := t.buildExpr(e, tz)
+			orderSqls = append(orderSqls, fmt.Sprintf("%s %s", sql, order.SortString()))
+			params = append(params, args...)
+			continue
+		}
+
 		column := order.GetColumn()
 		clause := strings.Split(column, clauses.ExprDot)
 
@@ -460,26 +524,11 @@ func (t *dbTables) getOrderSQL(orders []*order_clause.Order) (orderSQL string) {
 }
 
 // generate limit sql.
-func (t *dbTables) getLimitSQL(mi *models.ModelInfo, offset int64, limit int64) (limits string) {
+func (t *dbTables) getLimitSQL(mi *models.ModelInfo, offset int64, limit int64, ordered bool) (limits string) {
 	if limit == 0 {
 		limit = int64(DefaultRowsLimit)
 	}
-	if limit < 0 {
-		// no limit
-		if offset > 0 {
-			maxLimit := t.base.MaxLimit()
-			if maxLimit == 0 {
-				limits = fmt.Sprintf("OFFSET %d", offset)
-			} else {
-				limits = fmt.Sprintf("LIMIT %d OFFSET %d", maxLimit, offset)
-			}
-		}
-	} else if offset <= 0 {
-		limits = fmt.Sprintf("LIMIT %d", limit)
-	} else {
-		limits = fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
-	}
-	return
+	return t.base.GenerateLimitSQL(offset, limit, ordered)
 }
 
 // getIndexSql generate index sql.
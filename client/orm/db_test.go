@@ -15,13 +15,17 @@
 package orm
 
 import (
+	"database/sql"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
+	"github.com/jialequ/android-sdk/client/orm/hints"
 	"github.com/jialequ/android-sdk/client/orm/internal/buffers"
 
 	"github.com/jialequ/android-sdk/client/orm/internal/models"
@@ -62,6 +66,16 @@ func TestDbBaseInsertValueSQL(t *testing.T) {
 			values:  []interface{}{"test", 18},
 			wantRes: literal_2571,
 		},
+		{
+			name: "single insert by dbBaseMssql",
+			db: &dbBase{
+				ins: newdbBaseMssql(),
+			},
+			isMulti: false,
+			names:   []string{"name", "age"},
+			values:  []interface{}{"test", 18},
+			wantRes: "INSERT INTO \"test_table\" (\"name\", \"age\") VALUES (@p1, @p2)",
+		},
 		{
 			name: "multi insert by dbBase",
 			db: &dbBase{
@@ -178,6 +192,76 @@ func TestDbBaseUpdateSQL(t *testing.T) {
 	}
 }
 
+func TestDbBaseUpdateSQLWithVersion(t *testing.T) {
+	mi := &models.ModelInfo{
+		Table:  "test_table",
+		Fields: models.NewFields(),
+	}
+	mi.Fields.Version = &models.FieldInfo{Column: "version", Version: true}
+
+	res := (&dbBase{ins: &dbBase{}}).UpdateSQL([]string{"name", "version"}, "id", mi)
+	assert.Equal(t, "UPDATE `test_table` SET `name` = ?, `version` = ? WHERE `id` = ? AND `version` = ?", res)
+
+	res = (&dbBase{ins: newdbBasePostgres()}).UpdateSQL([]string{"name", "version"}, "id", mi)
+	assert.Equal(t, "UPDATE \"test_table\" SET \"name\" = $1, \"version\" = $2 WHERE \"id\" = $3 AND \"version\" = $4", res)
+
+	mi.Fields.Version = nil
+	mi.Fields.SoftDelete = &models.FieldInfo{Column: "deleted_at"}
+	res = (&dbBase{ins: &dbBase{}}).UpdateSQL([]string{"name"}, "id", mi)
+	assert.Equal(t, "UPDATE `test_table` SET `name` = ? WHERE `id` = ? AND `deleted_at` IS NULL", res)
+}
+
+func TestDbBaseJSONSQL(t *testing.T) {
+	path := []string{"tags", "0"}
+
+	mysql := newdbBaseMysql()
+	assert.Equal(t, "JSON_UNQUOTE(JSON_EXTRACT(T0.`attrs`, '$.tags[0]'))", mysql.GenerateJSONExtract("T0.`attrs`", path, "go"))
+	sql, args := mysql.GenerateJSONContains("T0.`attrs`", path[:1], []string{"go"})
+	assert.Equal(t, "JSON_CONTAINS(T0.`attrs`, ?, '$.tags')", sql)
+	assert.Equal(t, []interface{}{`["go"]`}, args)
+
+	pg := newdbBasePostgres()
+	assert.Equal(t, `(T0."attrs" #>> '{tags,0}')`, pg.GenerateJSONExtract(`T0."attrs"`, path, "go"))
+	assert.Equal(t, `(T0."attrs" #>> '{tags,0}')`, pg.GenerateJSONExtract(`T0."attrs"`, path, nil))
+	// the operator suffix casts the whole extracted value
+	leftCol := pg.GenerateJSONExtract(`T0."attrs"`, path, nil)
+	pg.GenerateOperatorLeftCol(nil, "icontains", &leftCol)
+	assert.Equal(t, `UPPER((T0."attrs" #>> '{tags,0}')::text)`, leftCol)
+	leftCol = pg.GenerateJSONExtract(`T0."attrs"`, path, nil)
+	pg.GenerateOperatorLeftCol(nil, "startswith", &leftCol)
+	assert.Equal(t, `(T0."attrs" #>> '{tags,0}')::text`, leftCol)
+	assert.Equal(t, `(T0."attrs" #>> '{size}')::numeric`, pg.GenerateJSONExtract(`T0."attrs"`, []string{"size"}, 10))
+	assert.Equal(t, `(T0."attrs" #>> '{ok}')::boolean`, pg.GenerateJSONExtract(`T0."attrs"`, []string{"ok"}, true))
+	sql, args = pg.GenerateJSONContains(`T0."attrs"`, nil, map[string]string{"color": "red"})
+	assert.Equal(t, `(T0."attrs")::jsonb @> ?::jsonb`, sql)
+	assert.Equal(t, []interface{}{`{"color":"red"}`}, args)
+
+	sqlite := newdbBaseSqlite()
+	assert.Equal(t, "json_extract(T0.`attrs`, '$.tags[0]')", sqlite.GenerateJSONExtract("T0.`attrs`", path, "go"))
+	assert.Panics(t, func() { sqlite.GenerateJSONContains("T0.`attrs`", nil, []string{"go"}) })
+}
+
+func TestDbBaseTextFieldValue(t *testing.T) {
+	d := &dbBase{}
+	for _, fieldType := range []int{TypeTextField, TypeJSONField, TypeJsonbField} {
+		fi := &models.FieldInfo{FieldType: fieldType}
+
+		value, err := d.convertValueFromDB(fi, []byte(`{"name":"beego"}`), time.UTC)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"name":"beego"}`, value)
+
+		var s string
+		_, err = d.setFieldValue(fi, value, reflect.ValueOf(&s).Elem())
+		assert.Nil(t, err)
+		assert.Equal(t, `{"name":"beego"}`, s)
+
+		var ns sql.NullString
+		_, err = d.setFieldValue(fi, nil, reflect.ValueOf(&ns).Elem())
+		assert.Nil(t, err)
+		assert.False(t, ns.Valid)
+	}
+}
+
 func TestDbBaseDeleteSQL(t *testing.T) {
 	mi := &models.ModelInfo{
 		Table: "test_table",
@@ -1179,6 +1263,63 @@ func TestDbBasereadBatchSQL(t *testing.T) {
 			wantRes:  `SELECT T0."name", T0."score", T1."id", T1."name_1", T1."age_1", T1."score_1", T1."test_tab_2_id", T2."id", T2."name_2", T2."age_2", T2."score_2" FROM "test_tab" T0 INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" INNER JOIN "test_tab2" T2 ON T2."id" = T1."test_tab_2_id" WHERE T0."name" = $1 OR ( T0."age" > $2 AND T0."score" < $3 ) GROUP BY T0."name", T0."age" ORDER BY T0."score" DESC, T0."age" ASC LIMIT 10 OFFSET 100 FOR UPDATE`,
 			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
 		},
+		{
+			name: "read batch with mssql",
+			db: &dbBase{
+				ins: newdbBaseMssql(),
+			},
+			tCols: []string{"name", "score"},
+			qs: querySet{
+				mi:     mi,
+				cond:   cond,
+				limit:  10,
+				offset: 100,
+				orders: []*order_clause.Order{
+					order_clause.Clause(order_clause.Column("score"),
+						order_clause.SortDescending()),
+				},
+				useIndex: 1,
+				indexes:  []string{"name", "score"},
+				related:  make([]string, 0),
+				relDepth: 2,
+			},
+			wantRes:  `SELECT T0."name", T0."score", T1."id", T1."name_1", T1."age_1", T1."score_1", T1."test_tab_2_id", T2."id", T2."name_2", T2."age_2", T2."score_2" FROM "test_tab" T0  WITH (INDEX("name","score")) INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" INNER JOIN "test_tab2" T2 ON T2."id" = T1."test_tab_2_id" WHERE T0."name" = @p1 OR ( T0."age" > @p2 AND T0."score" < @p3 ) ORDER BY T0."score" DESC OFFSET 100 ROWS FETCH NEXT 10 ROWS ONLY`,
+			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
+		},
+		{
+			name: "read batch with mssql and for update",
+			db: &dbBase{
+				ins: newdbBaseMssql(),
+			},
+			tCols: []string{"name", "score"},
+			qs: querySet{
+				mi:        mi,
+				cond:      cond,
+				limit:     10,
+				useIndex:  1,
+				indexes:   []string{"name"},
+				forUpdate: true,
+			},
+			wantRes:  `SELECT T0."name", T0."score" FROM "test_tab" T0  WITH (INDEX("name"), UPDLOCK, ROWLOCK) WHERE T0."name" = @p1 OR ( T0."age" > @p2 AND T0."score" < @p3 ) ORDER BY 1 OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
+		},
+		{
+			name: "read batch with mssql and no order",
+			db: &dbBase{
+				ins: newdbBaseMssql(),
+			},
+			tCols: []string{"name", "score"},
+			qs: querySet{
+				mi:       mi,
+				cond:     cond,
+				limit:    10,
+				distinct: true,
+				related:  make([]string, 0),
+				relDepth: 2,
+			},
+			wantRes:  `SELECT DISTINCT T0."name", T0."score", T1."id", T1."name_1", T1."age_1", T1."score_1", T1."test_tab_2_id", T2."id", T2."name_2", T2."age_2", T2."score_2" FROM "test_tab" T0 INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" INNER JOIN "test_tab2" T2 ON T2."id" = T1."test_tab_2_id" WHERE T0."name" = @p1 OR ( T0."age" > @p2 AND T0."score" < @p3 ) ORDER BY 1 OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1423,6 +1564,22 @@ func TestDbBasecountSQL(t *testing.T) {
 			wantRes:  `SELECT COUNT(*) FROM (SELECT COUNT(*) FROM "test_tab" T0 INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" INNER JOIN "test_tab2" T2 ON T2."id" = T1."test_tab_2_id" WHERE T0."name" = $1 OR ( T0."age" > $2 AND T0."score" < $3 ) GROUP BY T0."name", T0."age" ) AS T`,
 			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
 		},
+		{
+			name: "count with mssql has group by",
+			db: &dbBase{
+				ins: newdbBaseMssql(),
+			},
+			qs: querySet{
+				mi:       mi,
+				cond:     cond,
+				related:  make([]string, 0),
+				relDepth: 2,
+				groups:   []string{"name", "age"},
+				offset:   5,
+			},
+			wantRes:  `SELECT COUNT(*) FROM (SELECT COUNT(*) FROM "test_tab" T0 INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" INNER JOIN "test_tab2" T2 ON T2."id" = T1."test_tab_2_id" WHERE T0."name" = @p1 OR ( T0."age" > @p2 AND T0."score" < @p3 ) GROUP BY T0."name", T0."age" ORDER BY 1 OFFSET 5 ROWS) AS T`,
+			wantArgs: []interface{}{"test_name", int64(18), int64(60)},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1435,6 +1592,183 @@ func TestDbBasecountSQL(t *testing.T) {
 	}
 }
 
+func TestDbBaseInsertMultiOrUpdateSQL(t *testing.T) {
+	mc := models.NewModelCacheHandler()
+	err := mc.Register("", false, new(testTab), new(testTab1), new(testTab2))
+	assert.Nil(t, err)
+	mc.Bootstrap()
+	mi, ok := mc.GetByMd(new(testTab))
+	assert.True(t, ok)
+
+	names := []string{"name", "age", "score"}
+	values := []interface{}{"a", 18, 12, "b", 19, 13}
+
+	testCases := []struct {
+		name string
+		db   *dbBase
+		a    *alias
+
+		conflictCols []string
+		updateCols   []string
+
+		wantRes string
+		wantErr error
+	}{
+		{
+			name:       "MySQL",
+			db:         &dbBase{ins: newdbBaseMysql()},
+			a:          &alias{Driver: DRMySQL, DriverName: "mysql"},
+			updateCols: []string{"Age", "score"},
+			wantRes:    "INSERT INTO `test_tab` (`name`, `age`, `score`) VALUES (?, ?, ?), (?, ?, ?) ON DUPLICATE KEY UPDATE `age` = VALUES(`age`), `score` = VALUES(`score`)",
+		},
+		{
+			name:         "PostgreSQL",
+			db:           &dbBase{ins: newdbBasePostgres()},
+			a:            &alias{Driver: DRPostgres, DriverName: "postgres"},
+			conflictCols: []string{"Name"},
+			wantRes:      `INSERT INTO "test_tab" ("name", "age", "score") VALUES ($1, $2, $3), ($4, $5, $6) ON CONFLICT ("name") DO UPDATE SET "age" = EXCLUDED."age", "score" = EXCLUDED."score"`,
+		},
+		{
+			name:         "Sqlite with all columns conflicted",
+			db:           &dbBase{ins: newdbBaseSqlite()},
+			a:            &alias{Driver: DRSqlite, DriverName: "sqlite3"},
+			conflictCols: []string{"name", "age", "score"},
+			wantRes:      "INSERT INTO `test_tab` (`name`, `age`, `score`) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT (`name`, `age`, `score`) DO UPDATE SET `name` = EXCLUDED.`name`, `age` = EXCLUDED.`age`, `score` = EXCLUDED.`score`",
+		},
+		{
+			name:    "PostgreSQL without conflict columns",
+			db:      &dbBase{ins: newdbBasePostgres()},
+			a:       &alias{Driver: DRPostgres, DriverName: "postgres"},
+			wantErr: errors.New("`postgres` use InsertMultiOrUpdate must have conflict columns"),
+		},
+		{
+			name:         "PostgreSQL with wrong column",
+			db:           &dbBase{ins: newdbBasePostgres()},
+			a:            &alias{Driver: DRPostgres, DriverName: "postgres"},
+			conflictCols: []string{"email"},
+			wantErr:      errors.New("wrong db field/column name `email` for model `github.com/jialequ/android-sdk/client/orm.testTab`"),
+		},
+		{
+			name:         "nonsupport mssql",
+			db:           &dbBase{ins: newdbBaseMssql()},
+			a:            &alias{Driver: DRMsSQL, DriverName: "mssql"},
+			conflictCols: []string{"Name"},
+			wantErr:      errors.New("`mssql` nonsupport InsertMultiOrUpdate in beego"),
+		},
+		{
+			name:    "nonsupport driver",
+			db:      &dbBase{ins: newdbBaseOracle()},
+			a:       &alias{Driver: DROracle, DriverName: "oracle"},
+			wantErr: errors.New("`oracle` nonsupport InsertMultiOrUpdate in beego"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.db.InsertMultiOrUpdateSQL(names, values, mi, tc.a, tc.conflictCols, tc.updateCols)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+
+	query := "INSERT"
+	assert.True(t, newdbBasePostgres().HasReturning(&query, []string{"id", "name"}))
+	assert.Equal(t, `INSERT RETURNING "id", "name"`, query)
+	assert.False(t, newdbBaseMysql().HasReturning(&query, []string{"id"}))
+}
+
+func TestDbBaseMssqlSQL(t *testing.T) {
+	mc := models.NewModelCacheHandler()
+	err := mc.Register("", false, new(testTab), new(testTab1), new(testTab2))
+	assert.Nil(t, err)
+	mc.Bootstrap()
+	mi, ok := mc.GetByMd(new(testTab))
+	assert.True(t, ok)
+
+	d := newdbBaseMssql().(*dbBaseMssql)
+	a := &alias{Driver: DRMsSQL, DriverName: "sqlserver"}
+	names := []string{"name", "age", "score"}
+	values := []interface{}{"test_name", 18, 12}
+
+	query, err := d.InsertOrUpdateSQL(names, &values, mi, a, "name", "score=T.score+1")
+	assert.Nil(t, err)
+	assert.True(t, d.HasReturningID(mi, &query))
+	assert.Equal(t, `MERGE INTO "test_tab" WITH (HOLDLOCK) AS T USING (SELECT @p1 AS "name", @p2 AS "age", @p3 AS "score") AS S ON (T."name" = S."name") WHEN MATCHED THEN UPDATE SET T."age" = S."age", T."score" = T.score+1 WHEN NOT MATCHED THEN INSERT ("name", "age", "score") VALUES (S."name", S."age", S."score") OUTPUT INSERTED."id";`, query)
+	assert.Equal(t, []interface{}{"test_name", 18, 12}, values)
+
+	_, err = d.InsertOrUpdateSQL(names, &values, mi, a)
+	assert.Equal(t, errors.New("`sqlserver` use InsertOrUpdate must have a conflict column"), err)
+	_, err = d.InsertOrUpdateSQL(names, &values, mi, a, "email")
+	assert.NotNil(t, err)
+
+	query = d.InsertValueSQL(names, values, false, mi)
+	assert.True(t, d.HasReturningID(mi, &query))
+	assert.Equal(t, `INSERT INTO "test_tab" ("name", "age", "score") OUTPUT INSERTED."id" VALUES (@p1, @p2, @p3)`, query)
+
+	assert.Equal(t, ` WITH (INDEX("name","score")) `, d.GenerateSpecifyIndex(mi.Table, hints.KeyForceIndex, []string{"name", "score"}))
+	assert.Equal(t, ``, d.GenerateSpecifyIndex(mi.Table, hints.KeyIgnoreIndex, []string{"name"}))
+
+	assert.Equal(t, `SELECT "id", "name", "age", "score", "test_tab_1_id" FROM "test_tab" WITH (UPDLOCK, ROWLOCK) WHERE "id" = @p1`, d.readOneSQL(mi, []string{"id"}, true))
+	assert.Equal(t, `SELECT "id", "name", "age", "score", "test_tab_1_id" FROM "test_tab" WHERE "id" = @p1`, d.readOneSQL(mi, []string{"id"}, false))
+	assert.Equal(t, "SELECT `id`, `name`, `age`, `score`, `test_tab_1_id` FROM `test_tab` WHERE `id` = ? FOR UPDATE", newdbBaseMysql().(*dbBaseMysql).readOneSQL(mi, []string{"id"}, true))
+
+	assert.Equal(t, "", d.GenerateLimitSQL(0, -1, false))
+	assert.Equal(t, "OFFSET 10 ROWS", d.GenerateLimitSQL(10, -1, true))
+	assert.Equal(t, "ORDER BY 1 OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", d.GenerateLimitSQL(0, 5, false))
+}
+
+func TestDbBaseExprSQL(t *testing.T) {
+	mc := models.NewModelCacheHandler()
+	err := mc.Register("", false, new(testTab), new(testTab1), new(testTab2))
+	assert.Nil(t, err)
+	mc.Bootstrap()
+	mi, ok := mc.GetByMd(new(testTab))
+	assert.True(t, ok)
+
+	tz := time.Local
+	score := expr.Add(expr.Mul(expr.Col("score"), 0.9), expr.Coalesce(expr.Col("age"), 0))
+	grade := expr.Case().When(expr.Gte(expr.Col("score"), 90), "A").Else("B")
+
+	// update
+	db := &dbBase{ins: newdbBaseMysql()}
+	columns := []string{"score", "name"}
+	values := []interface{}{db.buildSetExpr(mi, score, tz), db.buildSetExpr(mi, grade, tz)}
+	buf := buffers.Get()
+	db.buildSetSQL(buf, columns, values)
+	assert.Equal(t, "SET T0.`score` = ((T0.`score` * 0.9) + COALESCE(T0.`age`, 0)), T0.`name` = CASE WHEN (T0.`score` >= 90) THEN ? ELSE ? END", buf.String())
+	assert.Equal(t, []interface{}{"A", "B"}, values[1].(exprValue).args)
+	buffers.Put(buf)
+
+	db = &dbBase{ins: newdbBasePostgres()}
+	assert.Equal(t, `(("score" * 0.9) + COALESCE("age", 0))`, db.buildSetExpr(mi, score, tz).sql)
+	assert.Panics(t, func() {
+		db.buildSetExpr(mi, expr.Col("TestTab1__Name1"), tz)
+	})
+
+	// filter, order and annotation
+	qs := querySet{
+		mi:   mi,
+		cond: NewCondition().And("score__gt", expr.Mul(expr.Col("TestTab1__Score1"), 2)).And("name", "test_name"),
+		orders: []*order_clause.Order{
+			order_clause.Clause(order_clause.Expr(expr.Func("LENGTH", expr.Col("name"))),
+				order_clause.SortDescending()),
+		},
+	}
+	tables := newDbTables(mi, db.ins)
+	col, annotationArgs := tables.getAnnotationSQL(annotation{name: "grade", e: grade}, tz)
+	assert.Equal(t, `CASE WHEN (T0."score" >= 90) THEN ? ELSE ? END "grade"`, col)
+	assert.Equal(t, []interface{}{"A", "B"}, annotationArgs)
+
+	query, args := db.readValuesSQL(tables, []string{`T0."name" "name"`, col}, qs, mi, qs.cond, tz)
+	assert.Equal(t, `SELECT T0."name" "name", CASE WHEN (T0."score" >= 90) THEN $1 ELSE $2 END "grade" FROM "test_tab" T0 INNER JOIN "test_tab1" T1 ON T1."id" = T0."test_tab_1_id" WHERE T0."score" > (T1."score_1" * 2) AND T0."name" = $3 ORDER BY LENGTH(T0."name") DESC `, query)
+	assert.Equal(t, []interface{}{"test_name"}, args)
+
+	assert.Panics(t, func() {
+		cond := NewCondition().And("score__in", expr.Col("age"))
+		newDbTables(mi, db.ins).getCondSQL(cond, false, tz)
+	})
+}
+
 type testTab struct {
 	ID       int64     `orm:"auto;pk;column(id)"`
 	Name     string    `orm:"column(name)"`
@@ -15,8 +15,10 @@
 package orm
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/jialequ/android-sdk/client/orm/internal/utils"
@@ -177,3 +179,137 @@ outFor:
 	}
 	return
 }
+
+// aliveCond restrict cond to the records which are not soft deleted
+func aliveCond(mi *models.ModelInfo, cond *Condition) *Condition {
+	sd := mi.Fields.SoftDelete
+	if sd == nil {
+		return cond
+	}
+	alive := NewCondition().And(sd.Column+ExprSep+"isnull", true)
+	if cond == nil || cond.IsEmpty() {
+		return alive
+	}
+	return alive.AndCond(cond)
+}
+
+// versionField return the version field of mi, or nil if it has none
+func versionField(mi *models.ModelInfo) *models.FieldInfo {
+	if mi.Fields == nil {
+		return nil
+	}
+	return mi.Fields.Version
+}
+
+// softDeleteField return the soft_delete field of mi, or nil if it has none
+func softDeleteField(mi *models.ModelInfo) *models.FieldInfo {
+	if mi.Fields == nil {
+		return nil
+	}
+	return mi.Fields.SoftDelete
+}
+
+// nextVersion return the value of the version field plus one
+func nextVersion(v reflect.Value) reflect.Value {
+	next := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		next.SetInt(v.Int() + 1)
+	default:
+		next.SetUint(v.Uint() + 1)
+	}
+	return next
+}
+
+// jsonFieldValue encode v as the value of the json field.
+// nil pointer, map and slice are NULL if the field can be null.
+func jsonFieldValue(fi *models.FieldInfo, v interface{}) (interface{}, error) {
+	if v == nil && fi.Null {
+		return nil, nil
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if rv.IsNil() && fi.Null {
+			return nil, nil
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("field `%s` encode json failed: %w", fi.FullName, err)
+	}
+	return string(b), nil
+}
+
+// setJSONFieldValue decode the json value into field, NULL set the field to zero value
+func setJSONFieldValue(fi *models.FieldInfo, value interface{}, field reflect.Value) error {
+	if value == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	ptr := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(utils.ToStr(value)), ptr.Interface()); err != nil {
+		return fmt.Errorf("field `%s` decode json failed: %w", fi.FullName, err)
+	}
+	field.Set(ptr.Elem())
+	return nil
+}
+
+// isJSONField report whether the field is stored as json
+func isJSONField(fi *models.FieldInfo) bool {
+	return fi.JSON || fi.FieldType == TypeJSONField || fi.FieldType == TypeJsonbField
+}
+
+// isJSONIndex report whether the key of json path is an array index
+func isJSONIndex(key string) bool {
+	for _, c := range key {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// jsonPath return the json path of mysql and sqlite, e.g. $.tags[0].name
+func jsonPath(path []string) string {
+	var buf strings.Builder
+	buf.WriteString("$")
+	for _, key := range path {
+		if isJSONIndex(key) {
+			buf.WriteString("[" + key + "]")
+		} else {
+			buf.WriteString("." + key)
+		}
+	}
+	return buf.String()
+}
+
+// jsonArg encode the arg of json operator, it panics like other wrong args of condition
+func jsonArg(arg interface{}) string {
+	b, err := json.Marshal(arg)
+	if err != nil {
+		panic(fmt.Errorf("json operator arg `%v` encode failed: %w", arg, err))
+	}
+	return string(b)
+}
+
+// jsonCompareArg return the value which the json path is compared with in the condition,
+// such as 3 of attrs__json__size__gt or the first one of __in, it's nil for the other operators.
+func jsonCompareArg(operator string, p condValue) interface{} {
+	if p.isRaw || len(p.args) == 0 {
+		return nil
+	}
+	switch operator {
+	case "exact", "ne", "gt", "gte", "lt", "lte", "in", "between":
+	default:
+		return nil
+	}
+	arg := p.args[0]
+	val := reflect.ValueOf(arg)
+	if (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && val.Type().Elem().Kind() != reflect.Uint8 {
+		if val.Len() == 0 {
+			return nil
+		}
+		return val.Index(0).Interface()
+	}
+	return arg
+}
@@ -55,7 +55,12 @@ func getDbCreateSQL(mc *imodels.ModelCache, al *alias) (queries []string, tableI
 		sql += fmt.Sprintf("--  Table Structure for `%s`\n", mi.FullName)
 		sql += fmt.Sprintf("-- %s\n", strings.Repeat("-", 50))
 
-		sql += fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s%s (\n", Q, mi.Table, Q)
+		if al.Driver == DRMsSQL {
+			// mssql has no IF NOT EXISTS for CREATE TABLE
+			sql += fmt.Sprintf("IF OBJECT_ID(N'%s', N'U') IS NULL\nCREATE TABLE %s%s%s (\n", mi.Table, Q, mi.Table, Q)
+		} else {
+			sql += fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s%s%s (\n", Q, mi.Table, Q)
+		}
 
 		columns := make([]string, 0, len(mi.Fields.FieldsDB))
 
@@ -88,7 +93,7 @@ func getDbCreateSQL(mc *imodels.ModelCache, al *alias) (queries []string, tableI
 				// }
 
 				// Append attribute DEFAULT
-				column += getColumnDefault(fi)
+				column += getColumnDefault(al, fi)
 
 				if fi.Unique {
 					column += " " + "UNIQUE"
@@ -103,7 +108,7 @@ func getDbCreateSQL(mc *imodels.ModelCache, al *alias) (queries []string, tableI
 				column = strings.Replace(column, "%COL%", fi.Column, -1)
 			}
 
-			if fi.Description != "" && al.Driver != DRSqlite {
+			if fi.Description != "" && al.Driver != DRSqlite && al.Driver != DRMsSQL {
 				if al.Driver == DRPostgres {
 					commentIndexes = append(commentIndexes, i)
 				} else {
@@ -87,3 +87,29 @@ func TestGetDbCreateSQLWithComment(t *testing.T) {
 		})
 	}
 }
+
+type ModelWithBoolDefault struct {
+	ID     int    `orm:"column(id)"`
+	Name   string `orm:"size(30);index;description(name)"`
+	Active bool   `orm:"default(true)"`
+	Score  uint8
+}
+
+func TestGetDbCreateSQLForMssql(t *testing.T) {
+	al := &alias{
+		Driver:  DRMsSQL,
+		DbBaser: newdbBaseMssql(),
+	}
+	testModelCache := models.NewModelCacheHandler()
+	err := testModelCache.Register("", true, &ModelWithBoolDefault{})
+	assert.NoError(t, err)
+
+	queries, indexes, err := getDbCreateSQL(testModelCache, al)
+	assert.NoError(t, err)
+	assert.Equal(t, "-- --------------------------------------------------\n--  Table Structure for `github.com/jialequ/android-sdk/client/orm.ModelWithBoolDefault`\n-- --------------------------------------------------\nIF OBJECT_ID(N'model_with_bool_default', N'U') IS NULL\nCREATE TABLE \"model_with_bool_default\" (\n    \"id\" int IDENTITY(1,1) NOT NULL PRIMARY KEY,\n    \"name\" nvarchar(30) NOT NULL DEFAULT '' ,\n    \"active\" bit NOT NULL DEFAULT 1 ,\n    \"score\" tinyint NOT NULL DEFAULT 0 \n);", queries[0])
+	assert.Equal(t, `CREATE INDEX "model_with_bool_default_name" ON "model_with_bool_default" ("name");`, indexes["model_with_bool_default"][0].SQL)
+
+	mi, _ := testModelCache.GetByMd(&ModelWithBoolDefault{})
+	fi := mi.Fields.GetByName("Active")
+	assert.Equal(t, `ALTER TABLE "model_with_bool_default" ADD "active" bit NOT NULL  DEFAULT 1 `, getColumnAddQuery(al, fi))
+}
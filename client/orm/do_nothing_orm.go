@@ -107,6 +107,14 @@ func (d *DoNothingOrm) InsertMultiWithCtx(ctx context.Context, bulk int, mds int
 	return 0, nil
 }
 
+func (d *DoNothingOrm) InsertMultiOrUpdate(bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	return 0, nil
+}
+
+func (d *DoNothingOrm) InsertMultiOrUpdateWithCtx(ctx context.Context, bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	return 0, nil
+}
+
 func (d *DoNothingOrm) Update(md interface{}, cols ...string) (int64, error) {
 	return 0, nil
 }
@@ -123,6 +131,14 @@ func (d *DoNothingOrm) DeleteWithCtx(ctx context.Context, md interface{}, cols .
 	return 0, nil
 }
 
+func (d *DoNothingOrm) HardDelete(md interface{}, cols ...string) (int64, error) {
+	return 0, nil
+}
+
+func (d *DoNothingOrm) HardDeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
+	return 0, nil
+}
+
 func (d *DoNothingOrm) Raw(query string, args ...interface{}) RawSeter {
 	return nil
 }
@@ -167,6 +183,10 @@ func (d *DoNothingOrm) DoTxWithCtxAndOpts(ctx context.Context, opts *sql.TxOptio
 	return nil
 }
 
+func (d *DoNothingOrm) DoTxWithPropagation(ctx context.Context, propagation Propagation, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return nil
+}
+
 // DoNothingTxOrm is similar with DoNothingOrm, usually you use it to test
 type DoNothingTxOrm struct {
 	DoNothingOrm
@@ -80,7 +80,7 @@ func (d *DefaultValueFilterChainBuilder) FilterChain(next orm.Filter) orm.Filter
 			d.handleInsert(ctx, inv)
 		case "InsertOrUpdate", "InsertOrUpdateWithCtx":
 			d.handleInsertOrUpdate(ctx, inv)
-		case "InsertMulti", "InsertMultiWithCtx":
+		case "InsertMulti", "InsertMultiWithCtx", "InsertMultiOrUpdate", "InsertMultiOrUpdateWithCtx":
 			d.handleInsertMulti(ctx, inv)
 		}
 		return next(ctx, inv)
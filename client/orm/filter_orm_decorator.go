@@ -47,6 +47,9 @@ type filterOrmDecorator struct {
 	insideTx    bool
 	txStartTime time.Time
 	txName      string
+
+	// outer begins the new transaction of PropagationRequiresNew in the transaction
+	outer TxBeginner
 }
 
 func NewFilterOrmDecorator(delegate Ormer, filterChains ...FilterChain) Ormer {
@@ -68,6 +71,7 @@ func NewFilterOrmDecorator(delegate Ormer, filterChains ...FilterChain) Ormer {
 func NewFilterTxOrmDecorator(delegate TxOrmer, root Filter, txName string) TxOrmer {
 	res := &filterOrmDecorator{
 		ormer:       delegate,
+		TxBeginner:  delegate,
 		TxCommitter: delegate,
 		root:        root,
 		insideTx:    true,
@@ -334,6 +338,41 @@ func (f *filterOrmDecorator) InsertMultiWithCtx(ctx context.Context, bulk int, m
 	return res[0].(int64), f.convertError(res[1])
 }
 
+func (f *filterOrmDecorator) InsertMultiOrUpdate(bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	return f.InsertMultiOrUpdateWithCtx(context.Background(), bulk, mds, conflictCols, updateCols)
+}
+
+// InsertMultiOrUpdateWithCtx uses the first element's model info
+func (f *filterOrmDecorator) InsertMultiOrUpdateWithCtx(ctx context.Context, bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	var (
+		md interface{}
+		mi *models.ModelInfo
+	)
+
+	sind := reflect.Indirect(reflect.ValueOf(mds))
+
+	if (sind.Kind() == reflect.Array || sind.Kind() == reflect.Slice) && sind.Len() > 0 {
+		ind := reflect.Indirect(sind.Index(0))
+		md = ind.Interface()
+		mi, _ = defaultModelCache.GetByMd(md)
+	}
+
+	inv := &Invocation{
+		Method:      "InsertMultiOrUpdateWithCtx",
+		Args:        []interface{}{bulk, mds, conflictCols, updateCols},
+		Md:          md,
+		mi:          mi,
+		InsideTx:    f.insideTx,
+		TxStartTime: f.txStartTime,
+		f: func(c context.Context) []interface{} {
+			res, err := f.ormer.InsertMultiOrUpdateWithCtx(c, bulk, mds, conflictCols, updateCols)
+			return []interface{}{res, err}
+		},
+	}
+	res := f.root(ctx, inv)
+	return res[0].(int64), f.convertError(res[1])
+}
+
 func (f *filterOrmDecorator) Update(md interface{}, cols ...string) (int64, error) {
 	return f.UpdateWithCtx(context.Background(), md, cols...)
 }
@@ -378,6 +417,28 @@ func (f *filterOrmDecorator) DeleteWithCtx(ctx context.Context, md interface{},
 	return res[0].(int64), f.convertError(res[1])
 }
 
+func (f *filterOrmDecorator) HardDelete(md interface{}, cols ...string) (int64, error) {
+	return f.HardDeleteWithCtx(context.Background(), md, cols...)
+}
+
+func (f *filterOrmDecorator) HardDeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
+	mi, _ := defaultModelCache.GetByMd(md)
+	inv := &Invocation{
+		Method:      "HardDeleteWithCtx",
+		Args:        []interface{}{md, cols},
+		Md:          md,
+		mi:          mi,
+		InsideTx:    f.insideTx,
+		TxStartTime: f.txStartTime,
+		f: func(c context.Context) []interface{} {
+			res, err := f.ormer.HardDeleteWithCtx(c, md, cols...)
+			return []interface{}{res, err}
+		},
+	}
+	res := f.root(ctx, inv)
+	return res[0].(int64), f.convertError(res[1])
+}
+
 func (f *filterOrmDecorator) Raw(query string, args ...interface{}) RawSeter {
 	return f.RawWithCtx(context.Background(), query, args...)
 }
@@ -439,6 +500,11 @@ func (f *filterOrmDecorator) BeginWithCtxAndOpts(ctx context.Context, opts *sql.
 		f: func(c context.Context) []interface{} {
 			res, err := f.TxBeginner.BeginWithCtxAndOpts(c, opts)
 			res = NewFilterTxOrmDecorator(res, f.root, getTxNameFromCtx(c))
+			if f.insideTx {
+				res.(*filterOrmDecorator).outer = f.outer
+			} else {
+				res.(*filterOrmDecorator).outer = f
+			}
 			return []interface{}{res, err}
 		},
 	}
@@ -474,6 +540,31 @@ func (f *filterOrmDecorator) DoTxWithCtxAndOpts(ctx context.Context, opts *sql.T
 	return f.convertError(res[0])
 }
 
+func (f *filterOrmDecorator) DoTxWithPropagation(ctx context.Context, propagation Propagation, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	inv := &Invocation{
+		Method:      "DoTxWithPropagation",
+		Args:        []interface{}{propagation, opts, task},
+		InsideTx:    f.insideTx,
+		TxStartTime: f.txStartTime,
+		TxName:      getTxNameFromCtx(ctx),
+		f: func(c context.Context) []interface{} {
+			var err error
+			switch {
+			case !f.insideTx:
+				err = doTxTemplate(c, f, opts, task)
+			case f.outer == nil:
+				// the decorator of TxOrmer isn't begun by the decorator of Ormer
+				err = f.TxBeginner.DoTxWithPropagation(c, propagation, opts, task)
+			default:
+				err = doTxWithPropagation(c, f, f.outer, propagation, opts, task)
+			}
+			return []interface{}{err}
+		},
+	}
+	res := f.root(ctx, inv)
+	return f.convertError(res[0])
+}
+
 func (f *filterOrmDecorator) Commit() error {
 	inv := &Invocation{
 		Method:      "Commit",
@@ -95,6 +95,38 @@ func TestFilterOrmDecoratorBeginTx(t *testing.T) {
 	assert.Equal(t, "rollback", err.Error())
 }
 
+func TestFilterOrmDecoratorDoTxWithPropagation(t *testing.T) {
+	register()
+
+	o := &filterMockOrm{}
+	od := NewFilterOrmDecorator(o, func(next Filter) Filter {
+		return func(ctx context.Context, inv *Invocation) []interface{} {
+			if inv.Method == "DoTxWithPropagation" {
+				assert.Equal(t, 3, len(inv.Args))
+				assert.Equal(t, "", inv.GetTableName())
+			}
+			return next(ctx, inv)
+		}
+	})
+
+	// there is no transaction to join
+	err := od.DoTxWithPropagation(context.Background(), PropagationRequired, nil, func(c context.Context, txOrm TxOrmer) error {
+		t.Fail()
+		return nil
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "begin tx", err.Error())
+
+	to, _ := od.Begin()
+	assert.Equal(t, od, to.(*filterOrmDecorator).outer)
+	err = to.DoTxWithPropagation(context.Background(), PropagationRequired, nil, func(c context.Context, txOrm TxOrmer) error {
+		assert.Equal(t, to, txOrm)
+		return errors.New("task error")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "task error", err.Error())
+}
+
 func TestFilterOrmDecoratorDBStats(t *testing.T) {
 	o := &filterMockOrm{}
 	od := NewFilterOrmDecorator(o, func(next Filter) Filter {
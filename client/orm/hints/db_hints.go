@@ -28,6 +28,8 @@ const (
 	KeyOffset
 	KeyOrderBy
 	KeyRelDepth
+	// db level
+	KeyUsePrimary
 )
 
 type Hint struct {
@@ -94,6 +96,12 @@ func OrderBy(s string) *Hint {
 	return NewHint(KeyOrderBy, s)
 }
 
+// UsePrimary return a hint which sends the reads to the primary database instead of the replicas,
+// use it to read your own writes
+func UsePrimary() *Hint {
+	return NewHint(KeyUsePrimary, true)
+}
+
 // NewHint return a hint
 func NewHint(key interface{}, value interface{}) *Hint {
 	return &Hint{
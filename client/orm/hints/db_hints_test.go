@@ -125,3 +125,9 @@ func TestOrderBy(t *testing.T) {
 	assert.Equal(t, hint.GetValue(), `-ID`)
 	assert.Equal(t, hint.GetKey(), KeyOrderBy)
 }
+
+func TestUsePrimary(t *testing.T) {
+	hint := UsePrimary()
+	assert.Equal(t, hint.GetValue(), true)
+	assert.Equal(t, hint.GetKey(), KeyUsePrimary)
+}
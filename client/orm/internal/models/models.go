@@ -280,7 +280,9 @@ func (mc *ModelCache) Bootstrap() { // NOSONAR
 				if !found {
 				mForC:
 					for _, ffi := range fi.RelModelInfo.Fields.FieldsByType[RelManyToMany] {
-						conditions := fi.RelThrough != "" && fi.RelThrough == ffi.RelThrough
+						conditions := fi.RelThrough != "" && fi.RelThrough == ffi.RelThrough ||
+							fi.RelTable != "" && fi.RelTable == ffi.RelTable ||
+							fi.RelThrough == "" && fi.RelTable == ""
 						if ffi.RelModelInfo == mi && conditions {
 							found = true
 
@@ -36,6 +36,8 @@ type Fields struct {
 	FieldsReverse []*FieldInfo
 	FieldsDB      []*FieldInfo
 	Rels          []*FieldInfo
+	SoftDelete    *FieldInfo
+	Version       *FieldInfo
 	Orders        []string
 	DBcols        []string
 }
@@ -114,6 +116,9 @@ type FieldInfo struct {
 	ToText              bool
 	AutoNow             bool
 	AutoNowAdd          bool
+	SoftDelete          bool // NULL means alive, the delete time otherwise
+	Version             bool // optimistic locking column, increased by each update
+	JSON                bool // any go value stored as json by encoding/json
 	Rel                 bool // if type equal to RelForeignKey, RelOneToOne, RelManyToMany then true
 	Reverse             bool
 	IsFielder           bool // implement Fielder interface
@@ -245,6 +250,12 @@ checkType:
 			}
 		}
 
+		if attrs["json"] {
+			fi.JSON = true
+			fieldType = TypeJsonbField
+			break checkType
+		}
+
 		fieldType, err = getFieldType(addrField)
 		if err != nil {
 			goto end
@@ -367,6 +378,11 @@ checkType:
 	switch fieldType {
 	case TypeBooleanField:
 	case TypeVarCharField, TypeCharField, TypeJSONField, TypeJsonbField:
+		if fi.JSON {
+			fi.Index = false
+			fi.Unique = false
+			break
+		}
 		if size != "" {
 			v, e := utils.StrTo(size).Int32()
 			if e != nil {
@@ -417,6 +433,30 @@ checkType:
 		}
 	}
 
+	if attrs["soft_delete"] {
+		if fieldType != TypeDateTimeField || fi.AutoNow || fi.AutoNowAdd {
+			err = errors.New("soft_delete only support datetime field without auto_now/auto_now_add")
+			goto end
+		}
+		fi.SoftDelete = true
+		fi.Null = true
+	}
+
+	if attrs["version"] {
+		switch addrField.Elem().Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			err = fmt.Errorf("version only support int and uint types but found `%s`", addrField.Elem().Kind())
+			goto end
+		}
+		if fi.Auto || fi.Pk {
+			err = errors.New("version can not be set on auto/pk field")
+			goto end
+		}
+		fi.Version = true
+	}
+
 	if fieldType&IsIntegerField == 0 {
 		if fi.Auto {
 			err = fmt.Errorf("non-integer type cannot set auto")
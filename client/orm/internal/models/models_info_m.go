@@ -83,6 +83,20 @@ func AddModelFields(mi *ModelInfo, ind reflect.Value, mName string, index []int)
 			err = fmt.Errorf("duplicate column name: %s", fi.Column)
 			break
 		}
+		if fi.SoftDelete {
+			if mi.Fields.SoftDelete != nil {
+				err = fmt.Errorf("one model must have one soft_delete field only")
+				break
+			}
+			mi.Fields.SoftDelete = fi
+		}
+		if fi.Version {
+			if mi.Fields.Version != nil {
+				err = fmt.Errorf("one model must have one version field only")
+				break
+			}
+			mi.Fields.Version = fi
+		}
 		if fi.Pk {
 			if mi.Fields.Pk != nil {
 				err = fmt.Errorf("one model must have one pk field only")
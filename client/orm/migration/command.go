@@ -0,0 +1,67 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"flag"
+	"fmt"
+)
+
+// RunCommand run the migration task in args, it's used by the main of the migrations.
+// for example:
+//
+//	func main() {
+//		orm.RegisterDataBase("default", "mysql", dsn)
+//		if err := migration.RunCommand(os.Args[1:]); err != nil {
+//			os.Exit(1)
+//		}
+//	}
+//
+// usage:
+//
+//	[-dry-run] [-lock-timeout 1m] upgrade|rollback <name>|reset|refresh
+//
+// the task is upgrade if it's omitted.
+func RunCommand(args []string) error {
+	var dryRun bool
+	flagSet := flag.NewFlagSet("migration", flag.ContinueOnError)
+	flagSet.BoolVar(&dryRun, "dry-run", false, "print the sql instead of executing it")
+	lockTimeout := flagSet.Duration("lock-timeout", DefaultLockTimeout, "wait for the migration lock of other process")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	opts := []Option{LockTimeout(*lockTimeout)}
+	if dryRun {
+		opts = append(opts, DryRun(nil))
+	}
+
+	task := flagSet.Arg(0)
+	switch task {
+	case "", "upgrade":
+		return Upgrade(0, opts...)
+	case "rollback":
+		if flagSet.NArg() < 2 {
+			return fmt.Errorf("the name of the migration to rollback is required")
+		}
+		return Rollback(flagSet.Arg(1), opts...)
+	case "reset":
+		return Reset(opts...)
+	case "refresh":
+		return Refresh(opts...)
+	default:
+		return fmt.Errorf("unknown migration task %s", task)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jialequ/android-sdk/client/orm"
+	"github.com/jialequ/android-sdk/core/logs"
+)
+
+const (
+	// lockName is the name of the mysql named lock
+	lockName = "beego_migration"
+	// lockKey is the key of the postgres advisory lock
+	lockKey int64 = 0x6265656d6967
+	// lockPollInterval is how often the lock table of sqlite is tried
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// ErrLockTimeout is returned when the migration lock is not released by another process in time
+var ErrLockTimeout = errors.New("wait for the migration lock timeout")
+
+// acquireLock take the migration lock of the default database, it blocks until the lock
+// is released by another process or timeout. The returned func releases the lock.
+// mysql uses GET_LOCK and postgres uses pg_advisory_lock, both are held by a dedicated
+// connection and released if the process dies. sqlite uses the migrations_lock table,
+// the row must be deleted by hand if the process dies while holding it.
+func acquireLock(ctx context.Context, driver orm.DriverType, timeout time.Duration) (func() error, error) {
+	db, err := orm.GetDB()
+	if err != nil {
+		return nil, err
+	}
+
+	switch driver {
+	case orm.DRMySQL, orm.DRTiDB:
+		return sessionLock(ctx, db, "SELECT GET_LOCK(?, ?)", []interface{}{lockName, int(timeout.Seconds())},
+			"SELECT RELEASE_LOCK(?)", lockName, timeout)
+	case orm.DRPostgres:
+		return sessionLock(ctx, db, "SELECT pg_advisory_lock($1)", []interface{}{lockKey},
+			"SELECT pg_advisory_unlock($1)", lockKey, timeout)
+	case orm.DRSqlite:
+		return tableLock(ctx, db, timeout)
+	default:
+		logs.Warn("the migration lock is not supported by the driver, run without it")
+		return func() error { return nil }, nil
+	}
+}
+
+// sessionLock take the lock by lockSQL on a dedicated connection, the lock belongs to the session
+func sessionLock(ctx context.Context, db *sql.DB, lockSQL string, lockArgs []interface{},
+	unlockSQL string, unlockArg interface{}, timeout time.Duration,
+) (func() error, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	// GET_LOCK returns 1 if the lock is taken, 0 if timeout, pg_advisory_lock returns void
+	var res sql.NullString
+	if err = conn.QueryRowContext(lockCtx, lockSQL, lockArgs...).Scan(&res); err != nil {
+		conn.Close()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, ErrLockTimeout
+		}
+		return nil, err
+	}
+	if res.Valid && res.String == "0" {
+		conn.Close()
+		return nil, ErrLockTimeout
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), unlockSQL, unlockArg)
+		return err
+	}, nil
+}
+
+// tableLock take the lock by inserting the only row of migrations_lock
+func tableLock(ctx context.Context, db *sql.DB, timeout time.Duration) (func() error, error) {
+	_, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS migrations_lock (id integer NOT NULL PRIMARY KEY, locked_at varchar(32))")
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err = db.ExecContext(ctx, "INSERT INTO migrations_lock (id, locked_at) VALUES (1, ?)", time.Now().Format(DBDateFormat))
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w, delete the row of migrations_lock if no migration is running: %s", ErrLockTimeout, err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	return func() error {
+		_, err := db.ExecContext(context.Background(), "DELETE FROM migrations_lock WHERE id = 1")
+		return err
+	}, nil
+}
@@ -23,12 +23,24 @@
 //		`statements` longtext COMMENT 'SQL statements for this migration',
 //		`rollback_statements` longtext,
 //		`status` enum('update','rollback') DEFAULT NULL COMMENT 'update indicates it is a normal migration while rollback means this migration is rolled back',
+//		`checksum` varchar(64) DEFAULT NULL COMMENT 'sha256 of the statements of Up, it is added if missing',
 //		PRIMARY KEY (`id_migration`)
 //	) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+//
+// Upgrade, Rollback, Reset and Refresh hold an advisory lock of the database while running,
+// so the processes started together apply each migration once. Each migration runs in a transaction
+// if the database supports transactional DDL (postgres and sqlite), and the applied migrations are
+// checked against their checksums before upgrading, ErrChecksumMismatch is returned if one is edited.
 package migration
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -43,6 +55,12 @@ const (
 	DBDateFormat = "2006-01-02 15:04:05"
 )
 
+// ErrChecksumMismatch is returned by Upgrade when the statements of an applied migration are changed
+var ErrChecksumMismatch = errors.New("migration checksum mismatch, the applied migration is edited")
+
+// DefaultLockTimeout is how long to wait for the migration lock held by another process
+var DefaultLockTimeout = time.Minute
+
 // Migrationer is an interface for all Migration struct
 type Migrationer interface {
 	Up()
@@ -116,38 +134,191 @@ func (m *Migration) Reset() {
 	m.sqls = make([]string, 0)
 }
 
+// Statements return the sql added since the last Reset
+func (m *Migration) Statements() []string {
+	return append([]string(nil), m.sqls...)
+}
+
 // Exec execute the sql already add in the sql
 func (m *Migration) Exec(name, status string) error {
+	return execStatements(context.Background(), orm.NewOrm(), name, status, m.sqls)
+}
+
+// statementer is implemented by Migration, the migrations which don't embed it are executed by
+// their own Exec, so they can't be printed by DryRun and have no checksum.
+type statementer interface {
+	Statements() []string
+}
+
+// Option is used by Upgrade, Rollback, Reset and Refresh
+type Option func(*options)
+
+type options struct {
+	dryRun      bool
+	out         io.Writer
+	lockTimeout time.Duration
+}
+
+// DryRun print the sql of the migrations to w instead of executing them, nothing is recorded.
+// os.Stdout is used if w is nil.
+func DryRun(w io.Writer) Option {
+	return func(o *options) {
+		o.dryRun = true
+		o.out = w
+	}
+}
+
+// LockTimeout set how long to wait for the migration lock held by another process
+func LockTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.lockTimeout = d
+	}
+}
+
+func newOptions(opts []Option) *options {
+	opt := &options{lockTimeout: DefaultLockTimeout}
+	for _, fn := range opts {
+		fn(opt)
+	}
+	if opt.out == nil {
+		opt.out = os.Stdout
+	}
+	return opt
+}
+
+// withLock run fn while holding the migration lock, there is no lock for dry run
+func withLock(opt *options, fn func(ctx context.Context, o orm.Ormer) error) error {
+	ctx := context.Background()
 	o := orm.NewOrm()
-	for _, s := range m.sqls {
-		logs.Info("exec sql:", s)
-		r := o.Raw(s)
-		_, err := r.Exec()
-		if err != nil {
-			return err
+	if opt.dryRun {
+		return fn(ctx, o)
+	}
+	unlock, err := acquireLock(ctx, o.Driver().Type(), opt.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := unlock(); err != nil {
+			logs.Error("release migration lock error:", err)
 		}
+	}()
+	return fn(ctx, o)
+}
+
+// transactionalDDL report whether the DDL of the driver can be rolled back,
+// mysql commits the transaction implicitly before a DDL statement.
+func transactionalDDL(driver orm.DriverType) bool {
+	return driver == orm.DRPostgres || driver == orm.DRSqlite
+}
+
+// run execute the migration up or down
+func run(ctx context.Context, o orm.Ormer, name, status string, m Migrationer, opt *options) error {
+	m.Reset()
+	if status == "up" {
+		m.Up()
+	} else {
+		m.Down()
+	}
+	st, ok := m.(statementer)
+	if opt.dryRun {
+		fmt.Fprintf(opt.out, "-- %s %s\n", status, name)
+		if !ok {
+			fmt.Fprintln(opt.out, "-- the statements are unknown, it does not embed Migration")
+			return nil
+		}
+		for _, s := range st.Statements() {
+			fmt.Fprintf(opt.out, "%s;\n", s)
+		}
+		return nil
 	}
-	return m.addOrUpdateRecord(name, status)
+	if !ok {
+		return m.Exec(name, status)
+	}
+	return execStatements(ctx, o, name, status, st.Statements())
 }
 
-func (m *Migration) addOrUpdateRecord(name, status string) error {
-	o := orm.NewOrm()
+// execStatements execute sqls and record the migration, in a transaction if the DDL can be rolled back
+func execStatements(ctx context.Context, o orm.Ormer, name, status string, sqls []string) error {
+	exec := func(ctx context.Context, q orm.QueryExecutor) error {
+		for _, s := range sqls {
+			logs.Info("exec sql:", s)
+			if _, err := q.RawWithCtx(ctx, s).Exec(); err != nil {
+				return err
+			}
+		}
+		return addOrUpdateRecord(ctx, q, name, status, sqls)
+	}
+	if !transactionalDDL(o.Driver().Type()) {
+		return exec(ctx, o)
+	}
+	return o.DoTxWithCtx(ctx, func(ctx context.Context, txOrm orm.TxOrmer) error {
+		return exec(ctx, txOrm)
+	})
+}
+
+func addOrUpdateRecord(ctx context.Context, q orm.QueryExecutor, name, status string, sqls []string) error {
 	if status == "down" {
 		status = "rollback"
-		p, err := o.Raw("update migrations set status = ?, rollback_statements = ?, created_at = ? where name = ?").Prepare()
-		if err != nil {
-			return nil
-		}
-		_, err = p.Exec(status, strings.Join(m.sqls, "; "), time.Now().Format(DBDateFormat), name)
+		_, err := q.RawWithCtx(ctx, "update migrations set status = ?, rollback_statements = ?, created_at = ? where name = ?",
+			status, strings.Join(sqls, "; "), time.Now().Format(DBDateFormat), name).Exec()
 		return err
 	}
 	status = "update"
-	p, err := o.Raw("insert into migrations(name, created_at, statements, status) values(?,?,?,?)").Prepare()
+	_, err := q.RawWithCtx(ctx, "insert into migrations(name, created_at, statements, status, checksum) values(?,?,?,?,?)",
+		name, time.Now().Format(DBDateFormat), strings.Join(sqls, "; "), status, checksum(sqls)).Exec()
+	return err
+}
+
+// checksum return the sha256 of the statements of Up
+func checksum(sqls []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(sqls, ";\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifyChecksums compare the checksums of the applied migrations with their statements now.
+// The checksum column is added if missing, and filled for the migrations applied before it.
+func verifyChecksums(ctx context.Context, o orm.Ormer, sm dataSlice, opt *options) error {
+	var maps []orm.Params
+	_, err := o.RawWithCtx(ctx, "select name, checksum from migrations where status = ?", "update").Values(&maps)
 	if err != nil {
-		return err
+		if opt.dryRun {
+			return nil
+		}
+		if _, err = o.RawWithCtx(ctx, "alter table migrations add column checksum varchar(64)").Exec(); err != nil {
+			return err
+		}
+		if _, err = o.RawWithCtx(ctx, "select name, checksum from migrations where status = ?", "update").Values(&maps); err != nil {
+			return err
+		}
 	}
-	_, err = p.Exec(name, time.Now().Format(DBDateFormat), strings.Join(m.sqls, "; "), status)
-	return err
+
+	sums := make(map[string]string, len(maps))
+	for _, v := range maps {
+		name, _ := v["name"].(string)
+		sum, _ := v["checksum"].(string)
+		sums[name] = sum
+	}
+	for _, v := range sm {
+		recorded, ok := sums[v.name]
+		st, isSt := v.m.(statementer)
+		if !ok || !isSt {
+			continue
+		}
+		v.m.Reset()
+		v.m.Up()
+		sum := checksum(st.Statements())
+		switch {
+		case recorded == sum:
+		case recorded == "" && !opt.dryRun:
+			_, err = o.RawWithCtx(ctx, "update migrations set checksum = ? where name = ? and status = ?", sum, v.name, "update").Exec()
+			if err != nil {
+				return err
+			}
+		case recorded != "":
+			return fmt.Errorf("%w: %s", ErrChecksumMismatch, v.name)
+		}
+	}
+	return nil
 }
 
 // GetCreated get the unixtime from the Created
@@ -169,89 +340,96 @@ func Register(name string, m Migrationer) error {
 }
 
 // Upgrade upgrade the migration from lasttime
-func Upgrade(lasttime int64) error {
-	sm := sortMap(migrationMap)
-	i := 0
-	migs, _ := getAllMigrations()
-	for _, v := range sm {
-		if _, ok := migs[v.name]; !ok {
-			logs.Info("start upgrade", v.name)
-			v.m.Reset()
-			v.m.Up()
-			err := v.m.Exec(v.name, "up")
-			if err != nil {
-				logs.Error(literal_4925, err)
-				time.Sleep(2 * time.Second)
-				return err
+func Upgrade(lasttime int64, opts ...Option) error {
+	opt := newOptions(opts)
+	return withLock(opt, func(ctx context.Context, o orm.Ormer) error {
+		sm := sortMap(migrationMap)
+		if err := verifyChecksums(ctx, o, sm, opt); err != nil {
+			logs.Error(literal_4925, err)
+			return err
+		}
+		i := 0
+		migs, _ := getAllMigrations()
+		for _, v := range sm {
+			if _, ok := migs[v.name]; !ok {
+				logs.Info("start upgrade", v.name)
+				err := run(ctx, o, v.name, "up", v.m, opt)
+				if err != nil {
+					logs.Error(literal_4925, err)
+					time.Sleep(2 * time.Second)
+					return err
+				}
+				logs.Info("end upgrade:", v.name)
+				i++
 			}
-			logs.Info("end upgrade:", v.name)
-			i++
 		}
-	}
-	logs.Info("total success upgrade:", i, " migration")
-	time.Sleep(2 * time.Second)
-	return nil
+		logs.Info("total success upgrade:", i, " migration")
+		time.Sleep(2 * time.Second)
+		return nil
+	})
 }
 
 // Rollback rollback the migration by the name
-func Rollback(name string) error {
-	if v, ok := migrationMap[name]; ok {
-		logs.Info("start rollback")
-		v.Reset()
-		v.Down()
-		err := v.Exec(name, "down")
-		if err != nil {
-			logs.Error(literal_4925, err)
+func Rollback(name string, opts ...Option) error {
+	opt := newOptions(opts)
+	return withLock(opt, func(ctx context.Context, o orm.Ormer) error {
+		if v, ok := migrationMap[name]; ok {
+			logs.Info("start rollback")
+			err := run(ctx, o, name, "down", v, opt)
+			if err != nil {
+				logs.Error(literal_4925, err)
+				time.Sleep(2 * time.Second)
+				return err
+			}
+			logs.Info("end rollback")
 			time.Sleep(2 * time.Second)
-			return err
+			return nil
 		}
-		logs.Info("end rollback")
+		logs.Error("not exist the migrationMap name:" + name)
 		time.Sleep(2 * time.Second)
-		return nil
-	}
-	logs.Error("not exist the migrationMap name:" + name)
-	time.Sleep(2 * time.Second)
-	return errors.New("not exist the migrationMap name:" + name)
+		return errors.New("not exist the migrationMap name:" + name)
+	})
 }
 
 // Reset reset all migration
 // run all migration's down function
-func Reset() error {
-	sm := sortMap(migrationMap)
-	i := 0
-	for j := len(sm) - 1; j >= 0; j-- {
-		v := sm[j]
-		if isRollBack(v.name) {
-			logs.Info("skip the", v.name)
-			time.Sleep(1 * time.Second)
-			continue
-		}
-		logs.Info("start reset:", v.name)
-		v.m.Reset()
-		v.m.Down()
-		err := v.m.Exec(v.name, "down")
-		if err != nil {
-			logs.Error(literal_4925, err)
-			time.Sleep(2 * time.Second)
-			return err
+func Reset(opts ...Option) error {
+	opt := newOptions(opts)
+	return withLock(opt, func(ctx context.Context, o orm.Ormer) error {
+		sm := sortMap(migrationMap)
+		i := 0
+		for j := len(sm) - 1; j >= 0; j-- {
+			v := sm[j]
+			if isRollBack(v.name) {
+				logs.Info("skip the", v.name)
+				time.Sleep(1 * time.Second)
+				continue
+			}
+			logs.Info("start reset:", v.name)
+			err := run(ctx, o, v.name, "down", v.m, opt)
+			if err != nil {
+				logs.Error(literal_4925, err)
+				time.Sleep(2 * time.Second)
+				return err
+			}
+			i++
+			logs.Info("end reset:", v.name)
 		}
-		i++
-		logs.Info("end reset:", v.name)
-	}
-	logs.Info("total success reset:", i, " migration")
-	time.Sleep(2 * time.Second)
-	return nil
+		logs.Info("total success reset:", i, " migration")
+		time.Sleep(2 * time.Second)
+		return nil
+	})
 }
 
 // Refresh first Reset, then Upgrade
-func Refresh() error {
-	err := Reset()
+func Refresh(opts ...Option) error {
+	err := Reset(opts...)
 	if err != nil {
 		logs.Error(literal_4925, err)
 		time.Sleep(2 * time.Second)
 		return err
 	}
-	err = Upgrade(0)
+	err = Upgrade(0, opts...)
 	return err
 }
 
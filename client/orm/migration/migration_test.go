@@ -0,0 +1,94 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/orm"
+)
+
+type createTable struct {
+	Migration
+	table string
+	extra []string
+}
+
+func (m *createTable) Up() {
+	m.SQL("CREATE TABLE " + m.table + " (id integer)")
+	for _, s := range m.extra {
+		m.SQL(s)
+	}
+}
+
+func (m *createTable) Down() {
+	m.SQL("DROP TABLE " + m.table)
+}
+
+func tableExists(t *testing.T, table string) bool {
+	var num int
+	err := orm.NewOrm().Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).QueryRow(&num)
+	assert.Nil(t, err)
+	return num == 1
+}
+
+func TestMigration(t *testing.T) {
+	err := orm.RegisterDataBase("default", "sqlite3", "file:migration_test?mode=memory&cache=shared")
+	assert.Nil(t, err)
+	// the table of the old version without checksum
+	_, err = orm.NewOrm().Raw("CREATE TABLE migrations (id_migration integer NOT NULL PRIMARY KEY AUTOINCREMENT, " +
+		"name varchar(255), created_at varchar(32), statements text, rollback_statements text, status varchar(16))").Exec()
+	assert.Nil(t, err)
+
+	m := &createTable{table: "mig_user"}
+	m.Created = "20210101_000000"
+	assert.Nil(t, Register("CreateUser_20210101_000000", m))
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, Upgrade(0, DryRun(buf)))
+	assert.Equal(t, "-- up CreateUser_20210101_000000\nCREATE TABLE mig_user (id integer);\n", buf.String())
+	assert.False(t, tableExists(t, "mig_user"))
+
+	assert.Nil(t, Upgrade(0))
+	assert.True(t, tableExists(t, "mig_user"))
+	var sum string
+	err = orm.NewOrm().Raw("SELECT checksum FROM migrations WHERE name = ?", "CreateUser_20210101_000000").QueryRow(&sum)
+	assert.Nil(t, err)
+	assert.Equal(t, checksum([]string{"CREATE TABLE mig_user (id integer)"}), sum)
+	var locks int
+	assert.Nil(t, orm.NewOrm().Raw("SELECT count(*) FROM migrations_lock").QueryRow(&locks))
+	assert.Equal(t, 0, locks)
+
+	// the applied migration is edited
+	m.extra = []string{"CREATE INDEX mig_user_id ON mig_user (id)"}
+	err = Upgrade(0)
+	assert.True(t, errors.Is(err, ErrChecksumMismatch))
+	m.extra = nil
+
+	assert.Nil(t, Rollback("CreateUser_20210101_000000"))
+	assert.False(t, tableExists(t, "mig_user"))
+
+	// the DDL of sqlite is rolled back with the failed migration
+	failed := &createTable{table: "mig_failed", extra: []string{"INSERT INTO not_exist VALUES (1)"}}
+	failed.Created = "20210102_000000"
+	assert.Nil(t, Register("CreateFailed_20210102_000000", failed))
+	assert.NotNil(t, Upgrade(0))
+	assert.False(t, tableExists(t, "mig_failed"))
+}
@@ -87,6 +87,11 @@ func MockInsertMultiWithCtx(tableName string, cnt int64, err error) *Mock {
 	return NewMock(NewSimpleCondition(tableName, "InsertMultiWithCtx"), []interface{}{cnt, err}, nil)
 }
 
+// MockInsertMultiOrUpdateWithCtx support InsertMultiOrUpdate and InsertMultiOrUpdateWithCtx
+func MockInsertMultiOrUpdateWithCtx(tableName string, cnt int64, err error) *Mock {
+	return NewMock(NewSimpleCondition(tableName, "InsertMultiOrUpdateWithCtx"), []interface{}{cnt, err}, nil)
+}
+
 // MockInsertOrUpdateWithCtx support InsertOrUpdate and InsertOrUpdateWithCtx
 func MockInsertOrUpdateWithCtx(tableName string, id int64, err error) *Mock {
 	return NewMock(NewSimpleCondition(tableName, "InsertOrUpdateWithCtx"), []interface{}{id, err}, nil)
@@ -102,6 +107,11 @@ func MockDeleteWithCtx(tableName string, affectedRow int64, err error) *Mock {
 	return NewMock(NewSimpleCondition(tableName, "DeleteWithCtx"), []interface{}{affectedRow, err}, nil)
 }
 
+// MockHardDeleteWithCtx support HardDelete and HardDeleteWithCtx
+func MockHardDeleteWithCtx(tableName string, affectedRow int64, err error) *Mock {
+	return NewMock(NewSimpleCondition(tableName, "HardDeleteWithCtx"), []interface{}{affectedRow, err}, nil)
+}
+
 // MockQueryM2MWithCtx support QueryM2MWithCtx and QueryM2M
 // Now you may be need to use golang/mock to generate QueryM2M mock instance
 // Or use DoNothingQueryM2Mer
@@ -152,6 +162,11 @@ func MockDBStats(stats *sql.DBStats) *Mock {
 // 	return MockBeginWithCtxAndOpts(txOrm, err)
 // }
 
+// MockDoTxWithPropagation support DoTxWithPropagation, the task is not run
+func MockDoTxWithPropagation(err error) *Mock {
+	return NewMock(NewSimpleCondition("", "DoTxWithPropagation"), []interface{}{err}, nil)
+}
+
 // MockCommit support Commit
 func MockCommit(err error) *Mock {
 	return NewMock(NewSimpleCondition("", "Commit"), []interface{}{err}, nil)
@@ -120,6 +120,17 @@ func TestMockInsertMultiWithCtx(t *testing.T) {
 	assert.Equal(t, mock, err)
 }
 
+func TestMockInsertMultiOrUpdateWithCtx(t *testing.T) {
+	s := StartMock()
+	defer s.Clear()
+	mock := errors.New(mockErrorMsg)
+	s.Mock(MockInsertMultiOrUpdateWithCtx((&User{}).TableName(), 12, mock))
+	o := orm.NewOrm()
+	res, err := o.InsertMultiOrUpdate(11, []interface{}{&User{}}, []string{"Name"}, nil)
+	assert.Equal(t, int64(12), res)
+	assert.Equal(t, mock, err)
+}
+
 func TestMockInsertWithCtx(t *testing.T) {
 	s := StartMock()
 	defer s.Clear()
@@ -231,6 +242,19 @@ func TestTransactionManually(t *testing.T) {
 	assert.Equal(t, "Tom", u.Name)
 }
 
+func TestMockDoTxWithPropagation(t *testing.T) {
+	s := StartMock()
+	defer s.Clear()
+	mock := errors.New(mockErrorMsg)
+	s.Mock(MockDoTxWithPropagation(mock))
+	o := orm.NewOrm()
+	err := o.DoTxWithPropagation(context.Background(), orm.PropagationNested, nil, func(ctx context.Context, txOrm orm.TxOrmer) error {
+		t.Fail()
+		return nil
+	})
+	assert.Equal(t, mock, err)
+}
+
 func TestTransactionRollback(t *testing.T) {
 	s := StartMock()
 	defer s.Clear()
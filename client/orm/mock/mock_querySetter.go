@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/jialequ/android-sdk/client/orm"
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
 )
 
@@ -73,6 +74,10 @@ func (d *DoNothingQuerySetter) Aggregate(s string) orm.QuerySeter {
 	return d
 }
 
+func (d *DoNothingQuerySetter) Annotate(name string, e expr.Expr) orm.QuerySeter {
+	return d
+}
+
 func (d *DoNothingQuerySetter) Filter(s string, i ...interface{}) orm.QuerySeter {
 	return d
 }
@@ -180,3 +185,19 @@ func (d *DoNothingQuerySetter) RowsToMap(result *orm.Params, keyCol, valueCol st
 func (d *DoNothingQuerySetter) RowsToStruct(ptrStruct interface{}, keyCol, valueCol string) (int64, error) {
 	return 0, nil
 }
+
+func (d *DoNothingQuerySetter) Iterate(ctx context.Context, cols ...string) (orm.RowIterator, error) {
+	return &DoNothingRowIterator{}, nil
+}
+
+func (d *DoNothingQuerySetter) Unscoped() orm.QuerySeter {
+	return d
+}
+
+func (d *DoNothingQuerySetter) Preload(names ...string) orm.QuerySeter {
+	return d
+}
+
+func (d *DoNothingQuerySetter) PreloadWith(name string, cond *orm.Condition, orders ...string) orm.QuerySeter {
+	return d
+}
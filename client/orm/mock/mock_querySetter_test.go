@@ -15,6 +15,7 @@
 package mock
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -71,4 +72,9 @@ func TestDoNothingQuerySetter(t *testing.T) {
 	assert.Nil(t, ins)
 
 	assert.NotNil(t, setter.GetCond())
+
+	it, err := setter.Iterate(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Close())
 }
@@ -15,6 +15,7 @@
 package mock
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/jialequ/android-sdk/client/orm"
@@ -61,3 +62,7 @@ func (d *DoNothingRawSetter) RowsToStruct(ptrStruct interface{}, keyCol, valueCo
 func (d *DoNothingRawSetter) Prepare() (orm.RawPreparer, error) {
 	return nil, nil
 }
+
+func (d *DoNothingRawSetter) Iterate(ctx context.Context) (orm.RowIterator, error) {
+	return &DoNothingRowIterator{}, nil
+}
@@ -15,6 +15,7 @@
 package mock
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,12 @@ func TestDoNothingRawSetter(t *testing.T) {
 	assert.Equal(t, int64(0), i)
 	assert.Nil(t, err)
 
+	it, err := rs.Iterate(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Scan())
+	assert.Nil(t, it.Err())
+
 	i, err = rs.RowsToMap(nil, "", "")
 	assert.Equal(t, int64(0), i)
 	assert.Nil(t, err)
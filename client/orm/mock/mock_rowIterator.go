@@ -0,0 +1,40 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"github.com/jialequ/android-sdk/client/orm"
+)
+
+var _ orm.RowIterator = new(DoNothingRowIterator)
+
+// DoNothingRowIterator is an iterator without any rows
+type DoNothingRowIterator struct{}
+
+func (d *DoNothingRowIterator) Next() bool {
+	return false
+}
+
+func (d *DoNothingRowIterator) Scan(containers ...interface{}) error {
+	return nil
+}
+
+func (d *DoNothingRowIterator) Err() error {
+	return nil
+}
+
+func (d *DoNothingRowIterator) Close() error {
+	return nil
+}
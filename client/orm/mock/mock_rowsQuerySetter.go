@@ -0,0 +1,247 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jialequ/android-sdk/client/orm"
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
+	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
+)
+
+var _ orm.QuerySeter = new(RowsQuerySetter)
+
+// RowsQuerySetter is a QuerySeter which always returns the mocked rows, the conditions are ignored.
+// It's designed for MockQueryTableWithCtx, and works with orm.Query as well.
+// for example:
+//
+//	s.Mock(MockQueryTableWithCtx("user", NewRowsQuerySetter([]*User{{Name: "Tom"}}, nil)))
+//	users, err := orm.Query[User](o).Filter("Name", "Tom").All(ctx)
+type RowsQuerySetter struct {
+	DoNothingQuerySetter
+	rows reflect.Value
+	err  error
+}
+
+// NewRowsQuerySetter create a RowsQuerySetter, rows must be a slice of models or model ptrs.
+// err is returned by the query methods.
+func NewRowsQuerySetter(rows interface{}, err error) *RowsQuerySetter {
+	val := reflect.ValueOf(rows)
+	if val.Kind() != reflect.Slice {
+		panic(errors.New("<RowsQuerySetter> rows must be a slice"))
+	}
+	return &RowsQuerySetter{rows: val, err: err}
+}
+
+// row return the i-th row as the type typ, which is the model or the model ptr
+func (r *RowsQuerySetter) row(i int, typ reflect.Type) reflect.Value {
+	v := r.rows.Index(i)
+	switch {
+	case v.Type() == typ:
+	case v.Kind() == reflect.Ptr:
+		v = v.Elem()
+	default:
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	return v
+}
+
+func (r *RowsQuerySetter) AllWithCtx(ctx context.Context, container interface{}, cols ...string) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	slice := reflect.MakeSlice(ind.Type(), 0, r.rows.Len())
+	for i := 0; i < r.rows.Len(); i++ {
+		slice = reflect.Append(slice, r.row(i, ind.Type().Elem()))
+	}
+	ind.Set(slice)
+	return int64(r.rows.Len()), nil
+}
+
+func (r *RowsQuerySetter) All(container interface{}, cols ...string) (int64, error) {
+	return r.AllWithCtx(context.Background(), container, cols...)
+}
+
+func (r *RowsQuerySetter) OneWithCtx(ctx context.Context, container interface{}, cols ...string) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.rows.Len() == 0 {
+		return orm.ErrNoRows
+	}
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	ind.Set(r.row(0, ind.Type()))
+	return nil
+}
+
+func (r *RowsQuerySetter) One(container interface{}, cols ...string) error {
+	return r.OneWithCtx(context.Background(), container, cols...)
+}
+
+func (r *RowsQuerySetter) CountWithCtx(ctx context.Context) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return int64(r.rows.Len()), nil
+}
+
+func (r *RowsQuerySetter) Count() (int64, error) {
+	return r.CountWithCtx(context.Background())
+}
+
+func (r *RowsQuerySetter) ExistWithCtx(ctx context.Context) bool {
+	return r.err == nil && r.rows.Len() > 0
+}
+
+func (r *RowsQuerySetter) Exist() bool {
+	return r.ExistWithCtx(context.Background())
+}
+
+func (r *RowsQuerySetter) UpdateWithCtx(ctx context.Context, values orm.Params) (int64, error) {
+	return r.CountWithCtx(ctx)
+}
+
+func (r *RowsQuerySetter) Update(values orm.Params) (int64, error) {
+	return r.CountWithCtx(context.Background())
+}
+
+func (r *RowsQuerySetter) DeleteWithCtx(ctx context.Context) (int64, error) {
+	return r.CountWithCtx(ctx)
+}
+
+func (r *RowsQuerySetter) Delete() (int64, error) {
+	return r.CountWithCtx(context.Background())
+}
+
+func (r *RowsQuerySetter) Iterate(ctx context.Context, cols ...string) (orm.RowIterator, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &rowsIterator{qs: r, cur: -1}, nil
+}
+
+func (r *RowsQuerySetter) OrderClauses(orders ...*order_clause.Order) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Aggregate(s string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Annotate(name string, e expr.Expr) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Filter(s string, i ...interface{}) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) FilterRaw(s string, s2 string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Exclude(s string, i ...interface{}) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) SetCond(condition *orm.Condition) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Limit(limit interface{}, args ...interface{}) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Offset(offset interface{}) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) GroupBy(exprs ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) OrderBy(exprs ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) ForceIndex(indexes ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) UseIndex(indexes ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) IgnoreIndex(indexes ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) RelatedSel(params ...interface{}) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Distinct() orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) ForUpdate() orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Unscoped() orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) Preload(names ...string) orm.QuerySeter {
+	return r
+}
+
+func (r *RowsQuerySetter) PreloadWith(name string, cond *orm.Condition, orders ...string) orm.QuerySeter {
+	return r
+}
+
+// rowsIterator iterates the rows of RowsQuerySetter
+type rowsIterator struct {
+	qs  *RowsQuerySetter
+	cur int
+}
+
+func (it *rowsIterator) Next() bool {
+	it.cur++
+	return it.cur < it.qs.rows.Len()
+}
+
+func (it *rowsIterator) Scan(containers ...interface{}) error {
+	if len(containers) != 1 || it.cur < 0 || it.cur >= it.qs.rows.Len() {
+		return orm.ErrIteratorContainer
+	}
+	ind := reflect.Indirect(reflect.ValueOf(containers[0]))
+	ind.Set(it.qs.row(it.cur, ind.Type()))
+	return nil
+}
+
+func (it *rowsIterator) Err() error {
+	return nil
+}
+
+func (it *rowsIterator) Close() error {
+	return nil
+}
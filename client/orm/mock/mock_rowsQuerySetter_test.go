@@ -0,0 +1,83 @@
+// Copyright 2020 beego
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/orm"
+)
+
+func TestRowsQuerySetter(t *testing.T) {
+	qs := NewRowsQuerySetter([]User{{Id: 1, Name: "Tom"}, {Id: 2, Name: "Jerry"}}, nil)
+
+	var ptrs []*User
+	num, err := qs.Filter("Name", "Tom").OrderBy("-Id").All(&ptrs)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), num)
+	assert.Equal(t, "Jerry", ptrs[1].Name)
+
+	var one User
+	assert.Nil(t, qs.One(&one))
+	assert.Equal(t, "Tom", one.Name)
+
+	cnt, err := qs.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), cnt)
+	assert.True(t, qs.Exist())
+
+	it, err := qs.Iterate(context.Background())
+	assert.Nil(t, err)
+	var names []string
+	for it.Next() {
+		var u User
+		assert.Nil(t, it.Scan(&u))
+		names = append(names, u.Name)
+	}
+	assert.Equal(t, []string{"Tom", "Jerry"}, names)
+
+	assert.Equal(t, orm.ErrNoRows, NewRowsQuerySetter([]*User{}, nil).One(&one))
+
+	mockErr := errors.New(mockErrorMsg)
+	_, err = NewRowsQuerySetter([]*User{}, mockErr).All(&ptrs)
+	assert.Equal(t, mockErr, err)
+}
+
+func TestTypedQuery(t *testing.T) {
+	s := StartMock()
+	defer s.Clear()
+	ctx := context.Background()
+	s.Mock(MockQueryTableWithCtx((&User{}).TableName(), NewRowsQuerySetter([]*User{{Id: 1, Name: "Tom"}}, nil)))
+	s.Mock(MockRead((&User{}).TableName(), func(data interface{}) {
+		u := data.(*User)
+		u.Name = "Tom"
+	}, nil))
+
+	o := orm.NewOrm()
+	users, err := orm.Query[User](o).Filter("Name", "Tom").OrderBy("-Id").All(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []*User{{Id: 1, Name: "Tom"}}, users)
+
+	_, err = orm.Query[User](o).Filter("Nmae", "Tom").All(ctx)
+	assert.NotNil(t, err)
+
+	u, err := orm.Get[User](ctx, o, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, &User{Id: 1, Name: "Tom"}, u)
+}
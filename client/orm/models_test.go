@@ -15,8 +15,10 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -265,6 +267,95 @@ type DeptInfo struct {
 	Salary       int
 }
 
+type HookModel struct {
+	ID    int `orm:"column(id)"`
+	Name  string
+	Slug  string
+	Calls []string `orm:"-"`
+	// Visible is the number of this row seen in AfterInsert
+	Visible int64 `orm:"-"`
+}
+
+func (h *HookModel) BeforeInsert(ctx context.Context, o QueryExecutor) error {
+	if h.Name == "" {
+		return errors.New("name is required")
+	}
+	h.Slug = strings.ToLower(strings.ReplaceAll(h.Name, " ", "-"))
+	h.Calls = append(h.Calls, "BeforeInsert")
+	return nil
+}
+
+func (h *HookModel) AfterInsert(ctx context.Context, o QueryExecutor) error {
+	var err error
+	h.Visible, err = o.QueryTable(h).Filter("id", h.ID).Count()
+	h.Calls = append(h.Calls, "AfterInsert")
+	if err == nil && h.Name == "Fail After" {
+		err = errors.New("failed after insert")
+	}
+	return err
+}
+
+func (h *HookModel) BeforeUpdate(ctx context.Context, o QueryExecutor) error {
+	h.Slug = strings.ToLower(strings.ReplaceAll(h.Name, " ", "-"))
+	h.Calls = append(h.Calls, "BeforeUpdate")
+	return nil
+}
+
+func (h *HookModel) AfterUpdate(ctx context.Context, o QueryExecutor) error {
+	h.Calls = append(h.Calls, "AfterUpdate")
+	if h.Name == "Fail After" {
+		return errors.New("failed after update")
+	}
+	return nil
+}
+
+func (h *HookModel) BeforeDelete(ctx context.Context, o QueryExecutor) error {
+	if h.Name == "protected" {
+		return errors.New("protected")
+	}
+	h.Calls = append(h.Calls, "BeforeDelete")
+	return nil
+}
+
+func (h *HookModel) AfterDelete(ctx context.Context, o QueryExecutor) error {
+	h.Calls = append(h.Calls, "AfterDelete")
+	return nil
+}
+
+func (h *HookModel) AfterRead(ctx context.Context, o QueryExecutor) error {
+	h.Calls = append(h.Calls, "AfterRead")
+	return nil
+}
+
+type SoftModel struct {
+	ID        int `orm:"column(id)"`
+	Name      string
+	DeletedAt *time.Time `orm:"soft_delete"`
+}
+
+type VersionModel struct {
+	ID      int `orm:"column(id)"`
+	Name    string
+	Version int `orm:"version"`
+}
+
+type JSONAttrs struct {
+	Color string   `json:"color"`
+	Size  int      `json:"size"`
+	Tags  []string `json:"tags"`
+}
+
+type JSONModel struct {
+	ID     int                    `orm:"column(id)"`
+	Attrs  JSONAttrs              `orm:"json"`
+	Meta   map[string]interface{} `orm:"json;null"`
+	Labels []string               `orm:"json;null"`
+}
+
+func (m *JSONModel) TableName() string {
+	return "json_model"
+}
+
 type UnregisterModel struct {
 	ID           int       `orm:"column(id)"`
 	Created      time.Time `orm:"auto_now_add"`
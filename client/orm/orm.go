@@ -85,6 +85,7 @@ var (
 	ErrStmtClosed    = errors.New("<QuerySeter> stmt already closed")
 	ErrArgs          = errors.New("<Ormer> args error may be empty")
 	ErrNotImplement  = errors.New("have not implement")
+	ErrStaleObject   = errors.New("<Ormer.Update> object is stale, the row was updated or deleted by others")
 
 	ErrLastInsertIdUnavailable = errors.New("<Ormer> last insert id is unavailable")
 )
@@ -151,7 +152,10 @@ func (o *ormBase) Read(md interface{}, cols ...string) error {
 
 func (o *ormBase) ReadWithCtx(ctx context.Context, md interface{}, cols ...string) error {
 	mi, ind := o.getPtrMiInd(md)
-	return o.alias.DbBaser.Read(ctx, o.db, mi, ind, o.alias.TZ, cols, false)
+	if err := o.alias.DbBaser.Read(ctx, o.db, mi, ind, o.alias.TZ, cols, false); err != nil {
+		return err
+	}
+	return o.afterRead(ctx, md)
 }
 
 // read data to model, like Read(), but use "SELECT FOR UPDATE" form
@@ -161,7 +165,11 @@ func (o *ormBase) ReadForUpdate(md interface{}, cols ...string) error {
 
 func (o *ormBase) ReadForUpdateWithCtx(ctx context.Context, md interface{}, cols ...string) error {
 	mi, ind := o.getPtrMiInd(md)
-	return o.alias.DbBaser.Read(ctx, o.db, mi, ind, o.alias.TZ, cols, true)
+	// locking reads never go to the replicas
+	if err := o.alias.DbBaser.Read(WithHints(ctx, hints.UsePrimary()), o.db, mi, ind, o.alias.TZ, cols, true); err != nil {
+		return err
+	}
+	return o.afterRead(ctx, md)
 }
 
 // Try to read a row from the database, or insert one if it doesn't exist
@@ -172,12 +180,16 @@ func (o *ormBase) ReadOrCreate(md interface{}, col1 string, cols ...string) (boo
 func (o *ormBase) ReadOrCreateWithCtx(ctx context.Context, md interface{}, col1 string, cols ...string) (bool, int64, error) {
 	cols = append([]string{col1}, cols...)
 	mi, ind := o.getPtrMiInd(md)
-	err := o.alias.DbBaser.Read(ctx, o.db, mi, ind, o.alias.TZ, cols, false)
+	// read from the primary, a lagging replica may miss the row and cause a duplicate insert
+	err := o.alias.DbBaser.Read(WithHints(ctx, hints.UsePrimary()), o.db, mi, ind, o.alias.TZ, cols, false)
 	if err == ErrNoRows {
 		// Create
 		id, err := o.InsertWithCtx(ctx, md)
 		return err == nil, id, err
 	}
+	if err == nil {
+		err = o.afterRead(ctx, md)
+	}
 
 	id, vid := int64(0), ind.FieldByIndex(mi.Fields.Pk.FieldIndex)
 	if mi.Fields.Pk.FieldType&IsPositiveIntegerField > 0 {
@@ -198,6 +210,9 @@ func (o *ormBase) Insert(md interface{}) (int64, error) {
 
 func (o *ormBase) InsertWithCtx(ctx context.Context, md interface{}) (int64, error) {
 	mi, ind := o.getPtrMiInd(md)
+	if err := o.beforeInsert(ctx, md); err != nil {
+		return 0, err
+	}
 	id, err := o.alias.DbBaser.Insert(ctx, o.db, mi, ind, o.alias.TZ)
 	if err != nil {
 		return id, err
@@ -205,7 +220,7 @@ func (o *ormBase) InsertWithCtx(ctx context.Context, md interface{}) (int64, err
 
 	o.setPk(mi, ind, id)
 
-	return id, nil
+	return id, o.afterInsert(ctx, md)
 }
 
 // Set auto pk field
@@ -219,6 +234,53 @@ func (*ormBase) setPk(mi *models.ModelInfo, ind reflect.Value, id int64) {
 	}
 }
 
+// call BeforeInsert hook of md if implemented
+func (o *ormBase) beforeInsert(ctx context.Context, md interface{}) error {
+	if h, ok := md.(BeforeInsertI); ok {
+		return h.BeforeInsert(ctx, o)
+	}
+	return nil
+}
+
+// call AfterInsert hook of md if implemented
+func (o *ormBase) afterInsert(ctx context.Context, md interface{}) error {
+	if h, ok := md.(AfterInsertI); ok {
+		return h.AfterInsert(ctx, o)
+	}
+	return nil
+}
+
+// call AfterRead hook of md if implemented
+func (o *ormBase) afterRead(ctx context.Context, md interface{}) error {
+	if h, ok := md.(AfterReadI); ok {
+		return h.AfterRead(ctx, o)
+	}
+	return nil
+}
+
+// call AfterRead hooks of the models in container,
+// which is a ptr to struct or a ptr to slice of structs or struct ptrs.
+func (o *ormBase) afterReadAll(ctx context.Context, container interface{}) error {
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	if ind.Kind() != reflect.Slice {
+		return o.afterRead(ctx, container)
+	}
+	for i := 0; i < ind.Len(); i++ {
+		if err := o.afterRead(ctx, hookTarget(reflect.Indirect(ind.Index(i)))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hookTarget returns the ptr of model ind, so that the hooks with ptr receiver can be found
+func hookTarget(ind reflect.Value) interface{} {
+	if ind.CanAddr() {
+		return ind.Addr().Interface()
+	}
+	return ind.Interface()
+}
+
 // insert some models to database
 func (o *ormBase) InsertMulti(bulk int, mds interface{}) (int64, error) {
 	return o.InsertMultiWithCtx(context.Background(), bulk, mds)
@@ -242,6 +304,10 @@ func (o *ormBase) InsertMultiWithCtx(ctx context.Context, bulk int, mds interfac
 		for i := 0; i < sind.Len(); i++ {
 			ind := reflect.Indirect(sind.Index(i))
 			mi := o.getMi(ind.Interface())
+			md := hookTarget(ind)
+			if err := o.beforeInsert(ctx, md); err != nil {
+				return cnt, err
+			}
 			id, err := o.alias.DbBaser.Insert(ctx, o.db, mi, ind, o.alias.TZ)
 			if err != nil {
 				return cnt, err
@@ -250,10 +316,62 @@ func (o *ormBase) InsertMultiWithCtx(ctx context.Context, bulk int, mds interfac
 			o.setPk(mi, ind, id)
 
 			cnt++
+			if err = o.afterInsert(ctx, md); err != nil {
+				return cnt, err
+			}
 		}
 	} else {
 		mi := o.getMi(sind.Index(0).Interface())
-		return o.alias.DbBaser.InsertMulti(ctx, o.db, mi, sind, bulk, o.alias.TZ)
+		for i := 0; i < sind.Len(); i++ {
+			if err := o.beforeInsert(ctx, hookTarget(reflect.Indirect(sind.Index(i)))); err != nil {
+				return cnt, err
+			}
+		}
+		cnt, err := o.alias.DbBaser.InsertMulti(ctx, o.db, mi, sind, bulk, o.alias.TZ)
+		if err != nil {
+			return cnt, err
+		}
+		for i := 0; i < sind.Len(); i++ {
+			if err = o.afterInsert(ctx, hookTarget(reflect.Indirect(sind.Index(i)))); err != nil {
+				return cnt, err
+			}
+		}
+		return cnt, nil
+	}
+	return cnt, nil
+}
+
+// insert some models to database, update the rows conflicted with the existing rows
+func (o *ormBase) InsertMultiOrUpdate(bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	return o.InsertMultiOrUpdateWithCtx(context.Background(), bulk, mds, conflictCols, updateCols)
+}
+
+func (o *ormBase) InsertMultiOrUpdateWithCtx(ctx context.Context, bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error) {
+	sind := reflect.Indirect(reflect.ValueOf(mds))
+
+	switch sind.Kind() {
+	case reflect.Array, reflect.Slice:
+		if sind.Len() == 0 {
+			return 0, ErrArgs
+		}
+	default:
+		return 0, ErrArgs
+	}
+
+	mi := o.getMi(sind.Index(0).Interface())
+	for i := 0; i < sind.Len(); i++ {
+		if err := o.beforeInsert(ctx, hookTarget(reflect.Indirect(sind.Index(i)))); err != nil {
+			return 0, err
+		}
+	}
+	cnt, err := o.alias.DbBaser.InsertMultiOrUpdate(ctx, o.db, mi, sind, bulk, o.alias, conflictCols, updateCols)
+	if err != nil {
+		return cnt, err
+	}
+	for i := 0; i < sind.Len(); i++ {
+		if err = o.afterInsert(ctx, hookTarget(reflect.Indirect(sind.Index(i)))); err != nil {
+			return cnt, err
+		}
 	}
 	return cnt, nil
 }
@@ -265,6 +383,9 @@ func (o *ormBase) InsertOrUpdate(md interface{}, colConflictAndArgs ...string) (
 
 func (o *ormBase) InsertOrUpdateWithCtx(ctx context.Context, md interface{}, colConflitAndArgs ...string) (int64, error) {
 	mi, ind := o.getPtrMiInd(md)
+	if err := o.beforeInsert(ctx, md); err != nil {
+		return 0, err
+	}
 	id, err := o.alias.DbBaser.InsertOrUpdate(ctx, o.db, mi, ind, o.alias, colConflitAndArgs...)
 	if err != nil {
 		return id, err
@@ -272,7 +393,7 @@ func (o *ormBase) InsertOrUpdateWithCtx(ctx context.Context, md interface{}, col
 
 	o.setPk(mi, ind, id)
 
-	return id, nil
+	return id, o.afterInsert(ctx, md)
 }
 
 // update model to database.
@@ -283,7 +404,19 @@ func (o *ormBase) Update(md interface{}, cols ...string) (int64, error) {
 
 func (o *ormBase) UpdateWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
 	mi, ind := o.getPtrMiInd(md)
-	return o.alias.DbBaser.Update(ctx, o.db, mi, ind, o.alias.TZ, cols)
+	if h, ok := md.(BeforeUpdateI); ok {
+		if err := h.BeforeUpdate(ctx, o); err != nil {
+			return 0, err
+		}
+	}
+	num, err := o.alias.DbBaser.Update(ctx, o.db, mi, ind, o.alias.TZ, cols)
+	if err != nil {
+		return num, err
+	}
+	if h, ok := md.(AfterUpdateI); ok {
+		err = h.AfterUpdate(ctx, o)
+	}
+	return num, err
 }
 
 // delete model in database
@@ -294,7 +427,46 @@ func (o *ormBase) Delete(md interface{}, cols ...string) (int64, error) {
 
 func (o *ormBase) DeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
 	mi, ind := o.getPtrMiInd(md)
+	if h, ok := md.(BeforeDeleteI); ok {
+		if err := h.BeforeDelete(ctx, o); err != nil {
+			return 0, err
+		}
+	}
+	var num int64
+	var err error
+	if mi.Fields.SoftDelete != nil {
+		num, err = o.alias.DbBaser.SoftDelete(ctx, o.db, mi, ind, o.alias.TZ, cols)
+	} else {
+		num, err = o.alias.DbBaser.Delete(ctx, o.db, mi, ind, o.alias.TZ, cols)
+	}
+	if err != nil {
+		return num, err
+	}
+	if h, ok := md.(AfterDeleteI); ok {
+		err = h.AfterDelete(ctx, o)
+	}
+	return num, err
+}
+
+// delete model in database even if it has a soft_delete field
+func (o *ormBase) HardDelete(md interface{}, cols ...string) (int64, error) {
+	return o.HardDeleteWithCtx(context.Background(), md, cols...)
+}
+
+func (o *ormBase) HardDeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
+	mi, ind := o.getPtrMiInd(md)
+	if h, ok := md.(BeforeDeleteI); ok {
+		if err := h.BeforeDelete(ctx, o); err != nil {
+			return 0, err
+		}
+	}
 	num, err := o.alias.DbBaser.Delete(ctx, o.db, mi, ind, o.alias.TZ, cols)
+	if err != nil {
+		return num, err
+	}
+	if h, ok := md.(AfterDeleteI); ok {
+		err = h.AfterDelete(ctx, o)
+	}
 	return num, err
 }
 
@@ -502,8 +674,8 @@ func (o *ormBase) Raw(query string, args ...interface{}) RawSeter {
 	return o.RawWithCtx(context.Background(), query, args...)
 }
 
-func (o *ormBase) RawWithCtx(_ context.Context, query string, args ...interface{}) RawSeter {
-	return newRawSet(o, query, args)
+func (o *ormBase) RawWithCtx(ctx context.Context, query string, args ...interface{}) RawSeter {
+	return newRawSet(ctx, o, query, args)
 }
 
 // Driver return current using database Driver
@@ -526,6 +698,52 @@ type orm struct {
 
 var _ Ormer = new(orm)
 
+// Insert inserts md in a transaction with its hooks if md implements any of them,
+// so that the row is not inserted if a hook fails.
+func (o *orm) Insert(md interface{}) (int64, error) {
+	return o.InsertWithCtx(context.Background(), md)
+}
+
+func (o *orm) InsertWithCtx(ctx context.Context, md interface{}) (int64, error) {
+	_, before := md.(BeforeInsertI)
+	_, after := md.(AfterInsertI)
+	if !before && !after {
+		return o.ormBase.InsertWithCtx(ctx, md)
+	}
+	return o.doWithHooks(ctx, func(ctx context.Context, txOrm TxOrmer) (int64, error) {
+		return txOrm.InsertWithCtx(ctx, md)
+	})
+}
+
+// Update updates md in a transaction with its hooks if md implements any of them,
+// so that the row is not updated if a hook fails.
+func (o *orm) Update(md interface{}, cols ...string) (int64, error) {
+	return o.UpdateWithCtx(context.Background(), md, cols...)
+}
+
+func (o *orm) UpdateWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error) {
+	_, before := md.(BeforeUpdateI)
+	_, after := md.(AfterUpdateI)
+	if !before && !after {
+		return o.ormBase.UpdateWithCtx(ctx, md, cols...)
+	}
+	return o.doWithHooks(ctx, func(ctx context.Context, txOrm TxOrmer) (int64, error) {
+		return txOrm.UpdateWithCtx(ctx, md, cols...)
+	})
+}
+
+// doWithHooks runs the write of a model and its hooks in a transaction,
+// which is rolled back if the write or any hook returns error.
+func (o *orm) doWithHooks(ctx context.Context, write func(ctx context.Context, txOrm TxOrmer) (int64, error)) (int64, error) {
+	var num int64
+	err := o.DoTxWithCtx(ctx, func(ctx context.Context, txOrm TxOrmer) error {
+		var err error
+		num, err = write(ctx, txOrm)
+		return err
+	})
+	return num, err
+}
+
 func (o *orm) Begin() (TxOrmer, error) {
 	return o.BeginWithCtx(context.Background())
 }
@@ -575,6 +793,11 @@ func (o *orm) DoTxWithCtxAndOpts(ctx context.Context, opts *sql.TxOptions, task
 	return doTxTemplate(ctx, o, opts, task)
 }
 
+// DoTxWithPropagation run the task in a new transaction, there is no transaction to join or nest in
+func (o *orm) DoTxWithPropagation(ctx context.Context, propagation Propagation, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return doTxTemplate(ctx, o, opts, task)
+}
+
 func doTxTemplate(ctx context.Context, o TxBeginner, opts *sql.TxOptions,
 	task func(ctx context.Context, txOrm TxOrmer) error) error {
 	txOrm, err := o.BeginWithCtxAndOpts(ctx, opts)
@@ -601,12 +824,96 @@ func doTxTemplate(ctx context.Context, o TxBeginner, opts *sql.TxOptions,
 	return err
 }
 
+// doTxWithPropagation run the task in the transaction tx by propagation,
+// outer begins the new transaction of PropagationRequiresNew.
+func doTxWithPropagation(ctx context.Context, tx TxOrmer, outer TxBeginner, propagation Propagation, opts *sql.TxOptions,
+	task func(ctx context.Context, txOrm TxOrmer) error) error {
+	switch propagation {
+	case PropagationRequired:
+		return task(ctx, tx)
+	case PropagationRequiresNew:
+		return doTxTemplate(ctx, outer, opts, task)
+	case PropagationNested:
+		return doTxTemplate(ctx, tx, opts, task)
+	}
+	return fmt.Errorf("<TxOrmer.DoTxWithPropagation> unknown propagation %d", propagation)
+}
+
 type txOrm struct {
 	ormBase
+
+	// savepoints count the savepoints of the transaction, it's shared by the nested transactions
+	savepoints *int
 }
 
 var _ TxOrmer = new(txOrm)
 
+func (t *txOrm) Begin() (TxOrmer, error) {
+	return t.BeginWithCtx(context.Background())
+}
+
+func (t *txOrm) BeginWithCtx(ctx context.Context) (TxOrmer, error) {
+	return t.BeginWithCtxAndOpts(ctx, nil)
+}
+
+func (t *txOrm) BeginWithOpts(opts *sql.TxOptions) (TxOrmer, error) {
+	return t.BeginWithCtxAndOpts(context.Background(), opts)
+}
+
+// BeginWithCtxAndOpts begin a nested transaction by SAVEPOINT,
+// Commit releases the savepoint and Rollback rolls back to it.
+// The nested transaction can't set the isolation level or read only.
+func (t *txOrm) BeginWithCtxAndOpts(ctx context.Context, opts *sql.TxOptions) (TxOrmer, error) {
+	switch t.alias.Driver {
+	case DRMySQL, DRPostgres, DRSqlite, DRTiDB:
+	default:
+		return nil, fmt.Errorf("`%s` nonsupport nested transaction in beego", t.alias.DriverName)
+	}
+	if opts != nil && (opts.Isolation != sql.LevelDefault || opts.ReadOnly) {
+		return nil, fmt.Errorf("<TxOrmer.Begin> nested transaction can't set the isolation level or read only")
+	}
+
+	if t.savepoints == nil {
+		t.savepoints = new(int)
+	}
+	*t.savepoints++
+	name := fmt.Sprintf("orm_savepoint_%d", *t.savepoints)
+	if _, err := t.db.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+
+	var taskTxOrm TxOrmer = &txOrm{
+		ormBase: ormBase{
+			alias: t.alias,
+			db:    &savepointDB{dbQuerier: t.db, name: name},
+		},
+		savepoints: t.savepoints,
+	}
+	return taskTxOrm, nil
+}
+
+func (t *txOrm) DoTx(task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return t.DoTxWithCtx(context.Background(), task)
+}
+
+func (t *txOrm) DoTxWithCtx(ctx context.Context, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return t.DoTxWithCtxAndOpts(ctx, nil, task)
+}
+
+func (t *txOrm) DoTxWithOpts(opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return t.DoTxWithCtxAndOpts(context.Background(), opts, task)
+}
+
+// DoTxWithCtxAndOpts run the task in a nested transaction, see BeginWithCtxAndOpts
+func (t *txOrm) DoTxWithCtxAndOpts(ctx context.Context, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return doTxTemplate(ctx, t, opts, task)
+}
+
+// DoTxWithPropagation run the task in this transaction, a new transaction or a nested transaction by propagation
+func (t *txOrm) DoTxWithPropagation(ctx context.Context, propagation Propagation, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error {
+	return doTxWithPropagation(ctx, t, newDBWithAlias(t.alias), propagation, opts, task)
+}
+
 func (t *txOrm) Commit() error {
 	return t.db.(txEnder).Commit()
 }
@@ -0,0 +1,182 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+)
+
+// ErrIteratorContainer is returned by the RowIterator of QuerySeter when Scan
+// is not called with exactly one ptr to the model being queried.
+var ErrIteratorContainer = errors.New("<RowIterator.Scan> container must be a ptr to the queried model")
+
+var (
+	_ RowIterator = new(modelIterator)
+	_ RowIterator = new(hookIterator)
+	_ RowIterator = new(rawIterator)
+)
+
+// modelIterator maps rows of QuerySeter to the model, the same as ReadBatch does.
+type modelIterator struct {
+	rows   *sql.Rows
+	d      *dbBase
+	mi     *models.ModelInfo
+	tables *dbTables
+	tCols  []string
+	refs   []interface{}
+	tz     *time.Location
+}
+
+func (it *modelIterator) Next() bool {
+	return it.rows.Next()
+}
+
+func (it *modelIterator) Scan(containers ...interface{}) error {
+	if len(containers) != 1 {
+		return ErrIteratorContainer
+	}
+	val := reflect.ValueOf(containers[0])
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return ErrIteratorContainer
+	}
+	ind := val.Elem()
+	if ind.Kind() != reflect.Struct || models.GetFullName(ind.Type()) != it.mi.FullName {
+		return ErrIteratorContainer
+	}
+
+	if err := it.rows.Scan(it.refs...); err != nil {
+		return err
+	}
+
+	ind.Set(reflect.Zero(ind.Type()))
+	it.d.setColsValues(it.mi, &ind, it.tCols, it.refs[:len(it.tCols)], it.tz)
+	it.d.setRelatedValues(it.mi, ind, it.tables, it.refs[len(it.tCols):], it.tz)
+	return nil
+}
+
+func (it *modelIterator) Err() error {
+	return it.rows.Err()
+}
+
+func (it *modelIterator) Close() error {
+	return it.rows.Close()
+}
+
+// hookIterator calls the AfterRead hook of each scanned model.
+type hookIterator struct {
+	RowIterator
+	ctx context.Context
+	orm *ormBase
+}
+
+func (it *hookIterator) Scan(containers ...interface{}) error {
+	if err := it.RowIterator.Scan(containers...); err != nil {
+		return err
+	}
+	return it.orm.afterRead(it.ctx, containers[0])
+}
+
+// rawIterator maps rows of RawSeter like QueryRow does.
+type rawIterator struct {
+	rows         *sql.Rows
+	rs           *rawSet
+	structTagMap map[reflect.StructTag]map[string]string
+}
+
+func (it *rawIterator) Next() bool {
+	return it.rows.Next()
+}
+
+func (it *rawIterator) Scan(containers ...interface{}) error {
+	rc := parseRowContainers("Iterate", containers...)
+	return it.rs.scanRow(it.rows, rc, it.structTagMap)
+}
+
+func (it *rawIterator) Err() error {
+	return it.rows.Err()
+}
+
+func (it *rawIterator) Close() error {
+	return it.rows.Close()
+}
+
+// ChunkByPk walks all rows matched by qs in primary key order, size rows at a time.
+// It uses keyset pagination (pk > last pk) instead of OFFSET, so every chunk costs the same
+// however deep the walk is. container must be a ptr to a slice of the model, it's refilled
+// before each call of fn. Iteration stops at the first error returned by fn.
+// Orders set on qs are replaced by the primary key order.
+// for example:
+//
+//	var users []*User
+//	err := ChunkByPk(ctx, o.QueryTable("user").Filter("status", 1), 500, &users, func() error {
+//		for _, u := range users {
+//			...
+//		}
+//		return nil
+//	})
+func ChunkByPk(ctx context.Context, qs QuerySeter, size int, container interface{}, fn func() error) error {
+	if size <= 0 {
+		return fmt.Errorf("<ChunkByPk> size must be greater than 0, got %d", size)
+	}
+
+	val := reflect.ValueOf(container)
+	ind := reflect.Indirect(val)
+	if val.Kind() != reflect.Ptr || ind.Kind() != reflect.Slice {
+		return errors.New("<ChunkByPk> container must be a ptr to slice")
+	}
+	typ := ind.Type().Elem()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	mi, ok := defaultModelCache.GetByFullName(models.GetFullName(typ))
+	if !ok {
+		return fmt.Errorf("<ChunkByPk> table: `%s` not found, make sure it was registered with `RegisterModel()`", typ)
+	}
+	if mi.Fields.Pk == nil {
+		return ErrMissPK
+	}
+	pk := mi.Fields.Pk
+
+	qs = qs.OrderBy(pk.Name).Limit(size)
+	var last interface{}
+	for {
+		cur := qs
+		if last != nil {
+			cur = qs.Filter(pk.Name+ExprSep+"gt", last)
+		}
+		num, err := cur.AllWithCtx(ctx, container)
+		if err != nil {
+			return err
+		}
+		if num == 0 {
+			return nil
+		}
+		// take the last pk before fn, it may modify the container
+		last = reflect.Indirect(ind.Index(int(num) - 1)).FieldByIndex(pk.FieldIndex).Interface()
+		if err := fn(); err != nil {
+			return err
+		}
+		if num < int64(size) {
+			return nil
+		}
+	}
+}
@@ -51,6 +51,9 @@ func (o *insertSet) InsertWithCtx(ctx context.Context, md interface{}) (int64, e
 	if name != o.mi.FullName {
 		panic(fmt.Errorf("<Inserter.Insert> need model `%s` but found `%s`", o.mi.FullName, name))
 	}
+	if err := o.orm.beforeInsert(ctx, md); err != nil {
+		return 0, err
+	}
 	id, err := o.orm.alias.DbBaser.InsertStmt(ctx, o.stmt, o.mi, ind, o.orm.alias.TZ)
 	if err != nil {
 		return id, err
@@ -64,7 +67,7 @@ func (o *insertSet) InsertWithCtx(ctx context.Context, md interface{}) (int64, e
 			}
 		}
 	}
-	return id, nil
+	return id, o.orm.afterInsert(ctx, md)
 }
 
 // close insert queryer statement
@@ -0,0 +1,286 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+	"github.com/jialequ/android-sdk/client/orm/internal/utils"
+)
+
+// preloadBatchSize is the max number of keys in the IN (...) of one preload query
+const preloadBatchSize = 1000
+
+// preload is a relation loaded after the query of its models, see QuerySeter.Preload
+type preload struct {
+	name   string
+	cond   *Condition
+	orders []string
+}
+
+// addPreload add p to the preloads, the one of same name is replaced
+func addPreload(preloads []preload, p preload) []preload {
+	res := make([]preload, 0, len(preloads)+1)
+	for _, v := range preloads {
+		if v.name != p.name {
+			res = append(res, v)
+		}
+	}
+	return append(res, p)
+}
+
+// loadPreloads load the preloaded relations into the models in container.
+// The preloads are grouped by their first relation, the rest of the paths
+// are preloaded by the query of the relation in turn.
+func (o querySet) loadPreloads(ctx context.Context, container interface{}) error {
+	if len(o.preloads) == 0 {
+		return nil
+	}
+
+	parents := preloadParents(container)
+	if len(parents) == 0 {
+		return nil
+	}
+
+	var names []string
+	own := make(map[string]preload)
+	nested := make(map[string][]preload)
+	for _, p := range o.preloads {
+		name, rest, _ := strings.Cut(p.name, ExprSep)
+		if _, ok := nested[name]; !ok {
+			names = append(names, name)
+			nested[name] = nil
+		}
+		if rest == "" {
+			own[name] = p
+		} else {
+			p.name = rest
+			nested[name] = addPreload(nested[name], p)
+		}
+	}
+
+	for _, name := range names {
+		fi, ok := o.mi.Fields.GetByAny(name)
+		if !ok || !(fi.Rel || fi.Reverse) || !fi.InModel {
+			return fmt.Errorf("<QuerySeter.Preload> `%s` is not a rel/reverse field of model `%s`", name, o.mi.FullName)
+		}
+
+		p := own[name]
+		relQs := func(mi *models.ModelInfo) *querySet {
+			qs := newQuerySet(o.orm, mi).(*querySet)
+			qs.cond = p.cond
+			if len(p.orders) > 0 {
+				qs.orders = order_clause.ParseOrder(p.orders...)
+			}
+			qs.preloads = nested[name]
+			return qs
+		}
+
+		var err error
+		switch {
+		case fi.FieldType == RelForeignKey || fi.FieldType == RelOneToOne:
+			err = preloadRel(ctx, parents, fi, relQs(fi.RelModelInfo))
+		case fi.FieldType == RelManyToMany || fi.ReverseFieldInfo.Mi.IsThrough:
+			err = preloadM2M(ctx, o.orm, parents, o.mi, fi, relQs(fi.RelModelInfo))
+		default:
+			err = preloadReverse(ctx, parents, o.mi, fi, relQs(fi.ReverseFieldInfo.Mi))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadParents return the models in container, which is *T, *[]T or *[]*T
+func preloadParents(container interface{}) []reflect.Value {
+	ind := reflect.Indirect(reflect.ValueOf(container))
+	if ind.Kind() != reflect.Slice {
+		return []reflect.Value{ind}
+	}
+	parents := make([]reflect.Value, 0, ind.Len())
+	for i := 0; i < ind.Len(); i++ {
+		if v := reflect.Indirect(ind.Index(i)); v.IsValid() {
+			parents = append(parents, v)
+		}
+	}
+	return parents
+}
+
+// preloadKeys collect the distinct pk of the models, and the models of each pk
+func preloadKeys(mi *models.ModelInfo, inds []reflect.Value) ([]interface{}, map[string][]reflect.Value) {
+	var keys []interface{}
+	byKey := make(map[string][]reflect.Value, len(inds))
+	for _, ind := range inds {
+		_, pk, ok := getExistPk(mi, ind)
+		if !ok {
+			continue
+		}
+		key := utils.ToStr(pk)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, pk)
+		}
+		byKey[key] = append(byKey[key], ind)
+	}
+	return keys, byKey
+}
+
+// inBatches call fn with keys split by preloadBatchSize
+func inBatches(keys []interface{}, fn func([]interface{}) error) error {
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > preloadBatchSize {
+			n = preloadBatchSize
+		}
+		if err := fn(keys[:n]); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	return nil
+}
+
+// preloadRel load the fk/one relation, the models filtered out by the condition are kept as it is.
+func preloadRel(ctx context.Context, parents []reflect.Value, fi *models.FieldInfo, qs *querySet) error {
+	relMi := fi.RelModelInfo
+	var relInds []reflect.Value
+	fields := make(map[string][]reflect.Value)
+	for _, parent := range parents {
+		field := parent.FieldByIndex(fi.FieldIndex)
+		if field.IsNil() {
+			continue
+		}
+		_, pk, ok := getExistPk(relMi, field.Elem())
+		if !ok {
+			continue
+		}
+		key := utils.ToStr(pk)
+		if _, ok := fields[key]; !ok {
+			relInds = append(relInds, field.Elem())
+		}
+		fields[key] = append(fields[key], field)
+	}
+	keys, _ := preloadKeys(relMi, relInds)
+
+	return inBatches(keys, func(batch []interface{}) error {
+		results := reflect.New(reflect.SliceOf(fi.Sf.Type))
+		if _, err := qs.Filter(relMi.Fields.Pk.Name+ExprSep+"in", batch...).AllWithCtx(ctx, results.Interface()); err != nil {
+			return err
+		}
+		results = results.Elem()
+		for i := 0; i < results.Len(); i++ {
+			rel := results.Index(i)
+			_, pk, _ := getExistPk(relMi, rel.Elem())
+			for _, field := range fields[utils.ToStr(pk)] {
+				field.Set(rel)
+			}
+		}
+		return nil
+	})
+}
+
+// preloadReverse load the reverse one/many relation by the fk of the related model
+func preloadReverse(ctx context.Context, parents []reflect.Value, mi *models.ModelInfo, fi *models.FieldInfo, qs *querySet) error {
+	fk := fi.ReverseFieldInfo
+	keys, byKey := preloadKeys(mi, parents)
+	for _, parent := range parents {
+		field := parent.FieldByIndex(fi.FieldIndex)
+		field.Set(reflect.Zero(field.Type()))
+	}
+
+	sliceType := fi.Sf.Type
+	if fi.FieldType == RelReverseOne {
+		sliceType = reflect.SliceOf(sliceType)
+	}
+	return inBatches(keys, func(batch []interface{}) error {
+		results := reflect.New(sliceType)
+		if _, err := qs.Filter(fk.Name+ExprSep+"in", batch...).AllWithCtx(ctx, results.Interface()); err != nil {
+			return err
+		}
+		results = results.Elem()
+		for i := 0; i < results.Len(); i++ {
+			rel := results.Index(i)
+			ref := rel.Elem().FieldByIndex(fk.FieldIndex)
+			if ref.IsNil() {
+				continue
+			}
+			_, pk, _ := getExistPk(mi, ref.Elem())
+			for _, parent := range byKey[utils.ToStr(pk)] {
+				field := parent.FieldByIndex(fi.FieldIndex)
+				if fi.FieldType == RelReverseOne {
+					field.Set(rel)
+				} else {
+					field.Set(reflect.Append(field, rel))
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// preloadM2M load the m2m relation, the pairs of pk are read from the through table,
+// then the related models are read by their pk in the order of qs.
+func preloadM2M(ctx context.Context, o *ormBase, parents []reflect.Value, mi *models.ModelInfo, fi *models.FieldInfo, qs *querySet) error {
+	parentFk, relFk := fi.ReverseFieldInfo, fi.ReverseFieldInfoTwo
+	relMi := fi.RelModelInfo
+	keys, byKey := preloadKeys(mi, parents)
+	for _, parent := range parents {
+		field := parent.FieldByIndex(fi.FieldIndex)
+		field.Set(reflect.Zero(field.Type()))
+	}
+
+	return inBatches(keys, func(batch []interface{}) error {
+		var pairs []ParamsList
+		through := newQuerySet(o, parentFk.Mi).Filter(parentFk.Name+ExprSep+"in", batch...)
+		if _, err := through.ValuesListWithCtx(ctx, &pairs, parentFk.Name, relFk.Name); err != nil {
+			return err
+		}
+		if len(pairs) == 0 {
+			return nil
+		}
+
+		var relKeys []interface{}
+		owners := make(map[string][]string)
+		for _, pair := range pairs {
+			parentKey, relKey := utils.ToStr(pair[0]), utils.ToStr(pair[1])
+			if _, ok := owners[relKey]; !ok {
+				relKeys = append(relKeys, pair[1])
+			}
+			owners[relKey] = append(owners[relKey], parentKey)
+		}
+
+		results := reflect.New(fi.Sf.Type)
+		if _, err := qs.Filter(relMi.Fields.Pk.Name+ExprSep+"in", relKeys...).AllWithCtx(ctx, results.Interface()); err != nil {
+			return err
+		}
+		results = results.Elem()
+		for i := 0; i < results.Len(); i++ {
+			rel := results.Index(i)
+			_, pk, _ := getExistPk(relMi, rel.Elem())
+			for _, parentKey := range owners[utils.ToStr(pk)] {
+				for _, parent := range byKey[parentKey] {
+					field := parent.FieldByIndex(fi.FieldIndex)
+					field.Set(reflect.Append(field, rel))
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/jialequ/android-sdk/client/orm/internal/models"
 
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
 	"github.com/jialequ/android-sdk/client/orm/hints"
 )
@@ -84,6 +85,10 @@ type querySet struct {
 	indexes   []string
 	orm       *ormBase
 	aggregate string
+	unscoped  bool
+	preloads  []preload
+
+	annotations []annotation
 }
 
 var _ QuerySeter = new(querySet)
@@ -214,6 +219,21 @@ func (o querySet) RelatedSel(params ...interface{}) QuerySeter {
 	return &o
 }
 
+// Preload load the relations of the queried models by batched IN queries after All and One.
+// A nested relation like Posts__Tags also loads Posts.
+func (o querySet) Preload(names ...string) QuerySeter {
+	for _, name := range names {
+		o.preloads = addPreload(o.preloads, preload{name: name})
+	}
+	return &o
+}
+
+// PreloadWith preload the relation whose models are filtered by cond and ordered by orders.
+func (o querySet) PreloadWith(name string, cond *Condition, orders ...string) QuerySeter {
+	o.preloads = addPreload(o.preloads, preload{name: name, cond: cond, orders: orders})
+	return &o
+}
+
 // Set condition to QuerySeter.
 func (o querySet) SetCond(cond *Condition) QuerySeter {
 	o.cond = cond
@@ -225,13 +245,28 @@ func (o querySet) GetCond() *Condition {
 	return o.cond
 }
 
+// Unscoped include the soft deleted records, and make Delete remove the records for real.
+func (o querySet) Unscoped() QuerySeter {
+	o.unscoped = true
+	return &o
+}
+
+// scopedCond return the condition used by the queries,
+// which excludes the soft deleted records unless Unscoped is called.
+func (o querySet) scopedCond() *Condition {
+	if o.unscoped {
+		return o.cond
+	}
+	return aliveCond(o.mi, o.cond)
+}
+
 // return QuerySeter execution result number
 func (o querySet) Count() (int64, error) {
 	return o.CountWithCtx(context.Background())
 }
 
 func (o querySet) CountWithCtx(ctx context.Context) (int64, error) {
-	return o.orm.alias.DbBaser.Count(ctx, o.orm.db, o, o.mi, o.cond, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.Count(ctx, o.orm.db, o, o.mi, o.scopedCond(), o.orm.alias.TZ)
 }
 
 // check result empty or not after QuerySeter executed
@@ -240,7 +275,7 @@ func (o querySet) Exist() bool {
 }
 
 func (o querySet) ExistWithCtx(ctx context.Context) bool {
-	cnt, _ := o.orm.alias.DbBaser.Count(ctx, o.orm.db, o, o.mi, o.cond, o.orm.alias.TZ)
+	cnt, _ := o.orm.alias.DbBaser.Count(ctx, o.orm.db, o, o.mi, o.scopedCond(), o.orm.alias.TZ)
 	return cnt > 0
 }
 
@@ -250,7 +285,7 @@ func (o querySet) Update(values Params) (int64, error) {
 }
 
 func (o querySet) UpdateWithCtx(ctx context.Context, values Params) (int64, error) {
-	return o.orm.alias.DbBaser.UpdateBatch(ctx, o.orm.db, &o, o.mi, o.cond, values, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.UpdateBatch(ctx, o.orm.db, &o, o.mi, o.scopedCond(), values, o.orm.alias.TZ)
 }
 
 // execute delete
@@ -259,6 +294,9 @@ func (o querySet) Delete() (int64, error) {
 }
 
 func (o querySet) DeleteWithCtx(ctx context.Context) (int64, error) {
+	if o.mi.Fields.SoftDelete != nil && !o.unscoped {
+		return o.orm.alias.DbBaser.SoftDeleteBatch(ctx, o.orm.db, &o, o.mi, o.cond, o.orm.alias.TZ)
+	}
 	return o.orm.alias.DbBaser.DeleteBatch(ctx, o.orm.db, &o, o.mi, o.cond, o.orm.alias.TZ)
 }
 
@@ -284,7 +322,24 @@ func (o querySet) All(container interface{}, cols ...string) (int64, error) {
 
 // AllWithCtx see All
 func (o querySet) AllWithCtx(ctx context.Context, container interface{}, cols ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadBatch(ctx, o.orm.db, o, o.mi, o.cond, container, o.orm.alias.TZ, cols)
+	num, err := o.orm.alias.DbBaser.ReadBatch(ctx, o.orm.db, o, o.mi, o.scopedCond(), container, o.orm.alias.TZ, cols)
+	if err != nil || num == 0 {
+		return num, err
+	}
+	if err = o.loadPreloads(ctx, container); err != nil {
+		return num, err
+	}
+	return num, o.orm.afterReadAll(ctx, container)
+}
+
+// Iterate query data and return a RowIterator which maps rows to the model one by one.
+// cols means the Columns when querying.
+func (o querySet) Iterate(ctx context.Context, cols ...string) (RowIterator, error) {
+	it, err := o.orm.alias.DbBaser.ReadIterator(ctx, o.orm.db, o, o.mi, o.scopedCond(), o.orm.alias.TZ, cols)
+	if err != nil {
+		return nil, err
+	}
+	return &hookIterator{RowIterator: it, ctx: ctx, orm: o.orm}, nil
 }
 
 // One query one row data and map to containers.
@@ -296,7 +351,7 @@ func (o querySet) One(container interface{}, cols ...string) error {
 // OneWithCtx check One
 func (o querySet) OneWithCtx(ctx context.Context, container interface{}, cols ...string) error {
 	o.limit = 1
-	num, err := o.orm.alias.DbBaser.ReadBatch(ctx, o.orm.db, o, o.mi, o.cond, container, o.orm.alias.TZ, cols)
+	num, err := o.orm.alias.DbBaser.ReadBatch(ctx, o.orm.db, o, o.mi, o.scopedCond(), container, o.orm.alias.TZ, cols)
 	if err != nil {
 		return err
 	}
@@ -307,7 +362,10 @@ func (o querySet) OneWithCtx(ctx context.Context, container interface{}, cols ..
 	if num > 1 {
 		return ErrMultiRows
 	}
-	return nil
+	if err = o.loadPreloads(ctx, container); err != nil {
+		return err
+	}
+	return o.orm.afterReadAll(ctx, container)
 }
 
 // Values query All data and map to []map[string]interface.
@@ -319,7 +377,7 @@ func (o querySet) Values(results *[]Params, exprs ...string) (int64, error) {
 
 // ValuesWithCtx see Values
 func (o querySet) ValuesWithCtx(ctx context.Context, results *[]Params, exprs ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.cond, exprs, results, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.scopedCond(), exprs, results, o.orm.alias.TZ)
 }
 
 // ValuesList query data and map to [][]interface
@@ -329,7 +387,7 @@ func (o querySet) ValuesList(results *[]ParamsList, exprs ...string) (int64, err
 }
 
 func (o querySet) ValuesListWithCtx(ctx context.Context, results *[]ParamsList, exprs ...string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.cond, exprs, results, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.scopedCond(), exprs, results, o.orm.alias.TZ)
 }
 
 // ValuesFlat query all data and map to []interface.
@@ -340,7 +398,7 @@ func (o querySet) ValuesFlat(result *ParamsList, expr string) (int64, error) {
 
 // ValuesFlatWithCtx see ValuesFlat
 func (o querySet) ValuesFlatWithCtx(ctx context.Context, result *ParamsList, expr string) (int64, error) {
-	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.cond, []string{expr}, result, o.orm.alias.TZ)
+	return o.orm.alias.DbBaser.ReadValues(ctx, o.orm.db, o, o.mi, o.scopedCond(), []string{expr}, result, o.orm.alias.TZ)
 }
 
 // RowsToMap query rows into map[string]interface with specify key and value column name.
@@ -386,3 +444,16 @@ func (o querySet) Aggregate(s string) QuerySeter {
 	o.aggregate = s
 	return &o
 }
+
+// Annotate add the computed column to the results of Values.
+// It panics if name is not an identifier or it's a field of the model.
+func (o querySet) Annotate(name string, e expr.Expr) QuerySeter {
+	if !annotationName.MatchString(name) {
+		panic(fmt.Errorf("<QuerySeter.Annotate> wrong annotation name `%s`", name))
+	}
+	if _, ok := o.mi.Fields.GetByAny(name); ok {
+		panic(fmt.Errorf("<QuerySeter.Annotate> annotation `%s` conflicts with the field of model `%s`", name, o.mi.FullName))
+	}
+	o.annotations = addAnnotation(o.annotations, annotation{name: name, e: e})
+	return &o
+}
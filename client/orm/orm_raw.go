@@ -15,6 +15,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -66,6 +67,7 @@ func newRawPreparer(rs *rawSet) (RawPreparer, error) {
 
 // raw query seter
 type rawSet struct {
+	ctx   context.Context
 	query string
 	args  []interface{}
 	orm   *ormBase
@@ -85,7 +87,7 @@ func (o *rawSet) Exec() (sql.Result, error) {
 	o.orm.alias.DbBaser.ReplaceMarks(&query)
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	return o.orm.db.Exec(query, args...)
+	return o.orm.db.ExecContext(o.ctx, query, args...)
 }
 
 // Set field value to row container
@@ -283,21 +285,23 @@ func (o *rawSet) loopSetRefs(refs []interface{}, sInds []reflect.Value, nIndsPtr
 	}
 }
 
-// query data and map to container
-func (o *rawSet) QueryRow(containers ...interface{}) error { // NOSONAR
-	var (
-		refs  = make([]interface{}, 0, len(containers))
-		sInds []reflect.Value
-		eTyps []reflect.Type
-		sMi   *models.ModelInfo
-	)
-	structMode := false
+// rowContainers holds the scan targets of QueryRow and RowIterator.Scan.
+type rowContainers struct {
+	refs       []interface{}
+	sInds      []reflect.Value
+	eTyps      []reflect.Type
+	sMi        *models.ModelInfo
+	structMode bool
+}
+
+func parseRowContainers(method string, containers ...interface{}) *rowContainers {
+	rc := &rowContainers{refs: make([]interface{}, 0, len(containers))}
 	for _, container := range containers {
 		val := reflect.ValueOf(container)
 		ind := reflect.Indirect(val)
 
 		if val.Kind() != reflect.Ptr {
-			panic(errors.New("<RawSeter.QueryRow> All args must be use ptr"))
+			panic(fmt.Errorf("<RawSeter.%s> All args must be use ptr", method))
 		}
 
 		etyp := ind.Type()
@@ -306,145 +310,181 @@ func (o *rawSet) QueryRow(containers ...interface{}) error { // NOSONAR
 			typ = typ.Elem()
 		}
 
-		sInds = append(sInds, ind)
-		eTyps = append(eTyps, etyp)
+		rc.sInds = append(rc.sInds, ind)
+		rc.eTyps = append(rc.eTyps, etyp)
 
 		if typ.Kind() == reflect.Struct && typ.String() != literal_2764 {
 			if len(containers) > 1 {
-				panic(errors.New("<RawSeter.QueryRow> now support one struct only. see #384"))
+				panic(fmt.Errorf("<RawSeter.%s> now support one struct only. see #384", method))
 			}
 
-			structMode = true
+			rc.structMode = true
 			fn := models.GetFullName(typ)
 			if mi, ok := defaultModelCache.GetByFullName(fn); ok {
-				sMi = mi
+				rc.sMi = mi
 			}
 		} else {
 			var ref interface{}
-			refs = append(refs, &ref)
+			rc.refs = append(rc.refs, &ref)
 		}
 	}
+	return rc
+}
 
-	query := o.query
-	o.orm.alias.DbBaser.ReplaceMarks(&query)
+// scanRow map the current row of rows to the containers
+func (o *rawSet) scanRow(rows *sql.Rows, rc *rowContainers, structTagMap map[reflect.StructTag]map[string]string) error {
+	if !rc.structMode {
+		if err := rows.Scan(rc.refs...); err != nil {
+			return err
+		}
 
-	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	rows, err := o.orm.db.Query(query, args...)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return ErrNoRows
+		nInds := make([]reflect.Value, len(rc.sInds))
+		o.loopSetRefs(rc.refs, rc.sInds, &nInds, rc.eTyps, true)
+		for i, sInd := range rc.sInds {
+			nInd := nInds[i]
+			sInd.Set(nInd)
 		}
-		return err
+		return nil
 	}
 
-	structTagMap := make(map[reflect.StructTag]map[string]string)
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
 
-	defer rows.Close()
+	columnsMp := make(map[string]interface{}, len(columns))
 
-	if rows.Next() {
-		if structMode {
-			columns, err := rows.Columns()
-			if err != nil {
-				return err
-			}
+	refs := make([]interface{}, 0, len(columns))
+	for _, col := range columns {
+		var ref interface{}
+		columnsMp[col] = &ref
+		refs = append(refs, &ref)
+	}
 
-			columnsMp := make(map[string]interface{}, len(columns))
+	if err := rows.Scan(refs...); err != nil {
+		return err
+	}
 
-			refs = make([]interface{}, 0, len(columns))
-			for _, col := range columns {
-				var ref interface{}
-				columnsMp[col] = &ref
-				refs = append(refs, &ref)
-			}
+	ind := rc.sInds[0]
 
-			if err := rows.Scan(refs...); err != nil {
-				return err
-			}
+	if ind.Kind() == reflect.Ptr {
+		if ind.IsNil() || !ind.IsValid() {
+			ind.Set(reflect.New(rc.eTyps[0].Elem()))
+		}
+		ind = ind.Elem()
+	}
 
-			ind := sInds[0]
+	return o.setStructFields(ind, rc.sMi, columns, columnsMp, structTagMap)
+}
 
-			if ind.Kind() == reflect.Ptr {
-				if ind.IsNil() || !ind.IsValid() {
-					ind.Set(reflect.New(eTyps[0].Elem()))
+// setStructFields set the scanned columns to the struct ind.
+// registered models are mapped by their field info, others by the column tag or name strategy.
+func (o *rawSet) setStructFields(ind reflect.Value, sMi *models.ModelInfo, columns []string, columnsMp map[string]interface{},
+	structTagMap map[reflect.StructTag]map[string]string,
+) error {
+	if sMi != nil {
+		for _, col := range columns {
+			if fi := sMi.Fields.GetByColumn(col); fi != nil {
+				value := reflect.ValueOf(columnsMp[col]).Elem().Interface()
+				field := ind.FieldByIndex(fi.FieldIndex)
+				if fi.FieldType&IsRelField > 0 {
+					mf := reflect.New(fi.RelModelInfo.AddrField.Elem().Type())
+					field.Set(mf)
+					field = mf.Elem().FieldByIndex(fi.RelModelInfo.Fields.Pk.FieldIndex)
 				}
-				ind = ind.Elem()
-			}
-
-			if sMi != nil {
-				for _, col := range columns {
-					if fi := sMi.Fields.GetByColumn(col); fi != nil {
-						value := reflect.ValueOf(columnsMp[col]).Elem().Interface()
-						field := ind.FieldByIndex(fi.FieldIndex)
-						if fi.FieldType&IsRelField > 0 {
-							mf := reflect.New(fi.RelModelInfo.AddrField.Elem().Type())
-							field.Set(mf)
-							field = mf.Elem().FieldByIndex(fi.RelModelInfo.Fields.Pk.FieldIndex)
-						}
-						if fi.IsFielder {
-							fd := field.Addr().Interface().(models.Fielder)
-							err := fd.SetRaw(value)
-							if err != nil {
-								return fmt.Errorf("Set raw error: %w", err)
-							}
-						} else {
-							o.setFieldValue(field, value)
-						}
+				if fi.IsFielder {
+					fd := field.Addr().Interface().(models.Fielder)
+					err := fd.SetRaw(value)
+					if err != nil {
+						return fmt.Errorf("Set raw error: %w", err)
 					}
+				} else {
+					o.setFieldValue(field, value)
 				}
-			} else {
-				// define recursive function
-				var recursiveSetField func(rv reflect.Value)
-				recursiveSetField = func(rv reflect.Value) {
-					for i := 0; i < rv.NumField(); i++ {
-						f := rv.Field(i)
-						fe := rv.Type().Field(i)
-
-						// check if the field is a Struct
-						// recursive the Struct type
-						if fe.Type.Kind() == reflect.Struct {
-							recursiveSetField(f)
-						}
+			}
+		}
+		return nil
+	}
 
-						// thanks @Gazeboxu.
-						tags := structTagMap[fe.Tag]
-						if tags == nil {
-							_, tags = models.ParseStructTag(fe.Tag.Get(models.DefaultStructTagName))
-							structTagMap[fe.Tag] = tags
-						}
-						var col string
-						if col = tags["column"]; col == "" {
-							col = models.NameStrategyMap[models.NameStrategy](fe.Name)
-						}
-						if v, ok := columnsMp[col]; ok {
-							value := reflect.ValueOf(v).Elem().Interface()
-							o.setFieldValue(f, value)
-						}
-					}
-				}
+	// define recursive function
+	var recursiveSetField func(rv reflect.Value)
+	recursiveSetField = func(rv reflect.Value) {
+		for i := 0; i < rv.NumField(); i++ {
+			f := rv.Field(i)
+			fe := rv.Type().Field(i)
 
-				// init call the recursive function
-				recursiveSetField(ind)
+			// check if the field is a Struct
+			// recursive the Struct type
+			if fe.Type.Kind() == reflect.Struct {
+				recursiveSetField(f)
 			}
 
-		} else {
-			if err := rows.Scan(refs...); err != nil {
-				return err
+			// thanks @Gazeboxu.
+			tags := structTagMap[fe.Tag]
+			if tags == nil {
+				_, tags = models.ParseStructTag(fe.Tag.Get(models.DefaultStructTagName))
+				structTagMap[fe.Tag] = tags
 			}
-
-			nInds := make([]reflect.Value, len(sInds))
-			o.loopSetRefs(refs, sInds, &nInds, eTyps, true)
-			for i, sInd := range sInds {
-				nInd := nInds[i]
-				sInd.Set(nInd)
+			var col string
+			if col = tags["column"]; col == "" {
+				col = models.NameStrategyMap[models.NameStrategy](fe.Name)
+			}
+			if v, ok := columnsMp[col]; ok {
+				value := reflect.ValueOf(v).Elem().Interface()
+				o.setFieldValue(f, value)
 			}
 		}
-	} else {
-		return ErrNoRows
 	}
 
+	// init call the recursive function
+	recursiveSetField(ind)
 	return nil
 }
 
+// query data and map to container
+func (o *rawSet) QueryRow(containers ...interface{}) error {
+	rc := parseRowContainers("QueryRow", containers...)
+
+	query := o.query
+	o.orm.alias.DbBaser.ReplaceMarks(&query)
+
+	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
+	rows, err := o.orm.db.QueryContext(o.ctx, query, args...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNoRows
+		}
+		return err
+	}
+
+	structTagMap := make(map[reflect.StructTag]map[string]string)
+
+	defer rows.Close()
+
+	if rows.Next() {
+		return o.scanRow(rows, rc, structTagMap)
+	}
+	return ErrNoRows
+}
+
+// Iterate query data and return a RowIterator which maps rows like QueryRow.
+func (o *rawSet) Iterate(ctx context.Context) (RowIterator, error) {
+	query := o.query
+	o.orm.alias.DbBaser.ReplaceMarks(&query)
+
+	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
+	rows, err := o.orm.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rawIterator{
+		rows:         rows,
+		rs:           o,
+		structTagMap: make(map[reflect.StructTag]map[string]string),
+	}, nil
+}
+
 // QueryRows query data rows and map to container
 func (o *rawSet) QueryRows(containers ...interface{}) (int64, error) { // NOSONAR
 	var (
@@ -490,11 +530,13 @@ func (o *rawSet) QueryRows(containers ...interface{}) (int64, error) { // NOSONA
 	o.orm.alias.DbBaser.ReplaceMarks(&query)
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
-	rows, err := o.orm.db.Query(query, args...)
+	rows, err := o.orm.db.QueryContext(o.ctx, query, args...)
 	if err != nil {
 		return 0, err
 	}
 
+	structTagMap := make(map[reflect.StructTag]map[string]string)
+
 	defer rows.Close()
 
 	var cnt int64
@@ -536,55 +578,8 @@ func (o *rawSet) QueryRows(containers ...interface{}) (int64, error) { // NOSONA
 				ind = ind.Elem()
 			}
 
-			if sMi != nil {
-				for _, col := range columns {
-					if fi := sMi.Fields.GetByColumn(col); fi != nil {
-						value := reflect.ValueOf(columnsMp[col]).Elem().Interface()
-						field := ind.FieldByIndex(fi.FieldIndex)
-						if fi.FieldType&IsRelField > 0 {
-							mf := reflect.New(fi.RelModelInfo.AddrField.Elem().Type())
-							field.Set(mf)
-							field = mf.Elem().FieldByIndex(fi.RelModelInfo.Fields.Pk.FieldIndex)
-						}
-						if fi.IsFielder {
-							fd := field.Addr().Interface().(models.Fielder)
-							err := fd.SetRaw(value)
-							if err != nil {
-								return 0, fmt.Errorf("Set raw error: %w", err)
-							}
-						} else {
-							o.setFieldValue(field, value)
-						}
-					}
-				}
-			} else {
-				// define recursive function
-				var recursiveSetField func(rv reflect.Value)
-				recursiveSetField = func(rv reflect.Value) {
-					for i := 0; i < rv.NumField(); i++ {
-						f := rv.Field(i)
-						fe := rv.Type().Field(i)
-
-						// check if the field is a Struct
-						// recursive the Struct type
-						if fe.Type.Kind() == reflect.Struct {
-							recursiveSetField(f)
-						}
-
-						_, tags := models.ParseStructTag(fe.Tag.Get(models.DefaultStructTagName))
-						var col string
-						if col = tags["column"]; col == "" {
-							col = models.NameStrategyMap[models.NameStrategy](fe.Name)
-						}
-						if v, ok := columnsMp[col]; ok {
-							value := reflect.ValueOf(v).Elem().Interface()
-							o.setFieldValue(f, value)
-						}
-					}
-				}
-
-				// init call the recursive function
-				recursiveSetField(ind)
+			if err := o.setStructFields(ind, sMi, columns, columnsMp, structTagMap); err != nil {
+				return 0, err
 			}
 
 			if eTyps[0].Kind() == reflect.Ptr {
@@ -645,7 +640,7 @@ func (o *rawSet) readValues(container interface{}, needCols []string) (int64, er
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
 
 	var rs *sql.Rows
-	rs, err := o.orm.db.Query(query, args...)
+	rs, err := o.orm.db.QueryContext(o.ctx, query, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -775,7 +770,7 @@ func (o *rawSet) queryRowsTo(container interface{}, keyCol, valueCol string) (in
 
 	args := getFlatParams(nil, o.args, o.orm.alias.TZ)
 
-	rs, err := o.orm.db.Query(query, args...)
+	rs, err := o.orm.db.QueryContext(o.ctx, query, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -913,8 +908,9 @@ func (o *rawSet) Prepare() (RawPreparer, error) {
 	return newRawPreparer(o)
 }
 
-func newRawSet(orm *ormBase, query string, args []interface{}) RawSeter {
+func newRawSet(ctx context.Context, orm *ormBase, query string, args []interface{}) RawSeter {
 	o := new(rawSet)
+	o.ctx = ctx
 	o.query = query
 	o.args = args
 	o.orm = orm
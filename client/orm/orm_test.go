@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
 	"github.com/jialequ/android-sdk/client/orm/hints"
 )
@@ -211,6 +213,10 @@ func TestSyncDb(t *testing.T) {
 	RegisterModel(new(StrPk))
 	RegisterModel(new(TM))
 	RegisterModel(new(DeptInfo))
+	RegisterModel(new(HookModel))
+	RegisterModel(new(SoftModel))
+	RegisterModel(new(VersionModel))
+	RegisterModel(new(JSONModel))
 
 	err := RunSyncdb("default", true, Debug)
 	throwFail(t, err)
@@ -240,6 +246,10 @@ func TestRegisterModels(*testing.T) {
 	RegisterModel(new(StrPk))
 	RegisterModel(new(TM))
 	RegisterModel(new(DeptInfo))
+	RegisterModel(new(HookModel))
+	RegisterModel(new(SoftModel))
+	RegisterModel(new(VersionModel))
+	RegisterModel(new(JSONModel))
 
 	BootStrap()
 
@@ -2243,6 +2253,66 @@ func TestUpdate(t *testing.T) {
 	throwFail(t, AssertIs(user.Nums, 30))
 }
 
+func TestUpdateAndAnnotateExpr(t *testing.T) {
+	qs := dORM.QueryTable("user").Filter("user_name", "slene")
+
+	num, err := qs.Update(Params{
+		"Nums": expr.Add(expr.Mul(expr.Col("Nums"), 2), 1),
+	})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	var list ParamsList
+	num, err = qs.ValuesFlat(&list, "Nums")
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(list[0], 61))
+
+	num, err = qs.Update(Params{
+		"Nums": expr.Case().When(expr.Gt(expr.Col("Nums"), 60), expr.Div(expr.Sub(expr.Col("Nums"), 1), 2)).
+			Else(expr.Col("Nums")),
+	})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("Nums", expr.Div(expr.Col("Nums"), 1)).Filter("Nums__lt", expr.Add(expr.Col("Nums"), 1)).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	var maps []Params
+	num, err = qs.Annotate("double_nums", expr.Mul(expr.Col("Nums"), 2)).
+		Annotate("grade", expr.Case().When(expr.Gte(expr.Col("Nums"), 30), "high").Else("low")).
+		Values(&maps, "UserName", "double_nums", "grade")
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(maps[0]["UserName"], "slene"))
+	throwFail(t, AssertIs(maps[0]["double_nums"], 60))
+	throwFail(t, AssertIs(maps[0]["grade"], "high"))
+
+	num, err = qs.Annotate("nick", expr.Coalesce(nil, expr.Func("LOWER", expr.Col("UserName")))).Values(&maps)
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFail(t, AssertIs(maps[0]["Nums"], 30))
+	throwFail(t, AssertIs(maps[0]["nick"], "slene"))
+
+	num, err = dORM.QueryTable("user").OrderClauses(
+		order_clause.Clause(
+			order_clause.Expr(expr.Sub(0, expr.Col("Id"))),
+			order_clause.SortAscending(),
+		),
+	).ValuesFlat(&list, "UserName")
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+	throwFail(t, AssertIs(list[0], "nobody"))
+
+	assert.Panics(t, func() {
+		qs.Annotate("user_name", expr.Col("Nums"))
+	})
+	assert.Panics(t, func() {
+		qs.Annotate(`nums"`, expr.Col("Nums"))
+	})
+}
+
 func TestDelete(t *testing.T) {
 	qs := dORM.QueryTable("user_profile")
 	num, err := qs.Filter("user__user_name", "slene").Delete()
@@ -2331,6 +2401,607 @@ func TestTransaction(t *testing.T) {
 	assert.Equal(t, int64(1), num)
 }
 
+func TestHooks(t *testing.T) {
+	o := NewOrm()
+
+	h := &HookModel{Name: "Hello World"}
+	id, err := o.Insert(h)
+	assert.Nil(t, err)
+	assert.True(t, id > 0)
+	assert.Equal(t, "hello-world", h.Slug)
+	assert.Equal(t, int64(1), h.Visible)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, h.Calls)
+
+	_, err = o.Insert(&HookModel{})
+	assert.NotNil(t, err)
+
+	h.Name = "Hello Beego"
+	h.Calls = nil
+	_, err = o.Update(h)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, h.Calls)
+
+	// the write is rolled back if an After hook fails
+	failed := &HookModel{Name: "Fail After"}
+	_, err = o.Insert(failed)
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(1), failed.Visible)
+	assert.False(t, o.QueryTable(new(HookModel)).Filter("slug", "fail-after").Exist())
+	_, err = o.Update(&HookModel{ID: h.ID, Name: "Fail After"})
+	assert.NotNil(t, err)
+	assert.True(t, o.QueryTable(new(HookModel)).Filter("id", h.ID).Filter("slug", "hello-beego").Exist())
+
+	r := &HookModel{ID: h.ID}
+	assert.Nil(t, o.Read(r))
+	assert.Equal(t, "hello-beego", r.Slug)
+	assert.Equal(t, []string{"AfterRead"}, r.Calls)
+
+	r = &HookModel{Name: "Hello Beego"}
+	created, _, err := o.ReadOrCreate(r, "Name")
+	assert.Nil(t, err)
+	assert.False(t, created)
+	assert.Equal(t, []string{"AfterRead"}, r.Calls)
+	r = &HookModel{Name: "Read Or Create"}
+	created, _, err = o.ReadOrCreate(r, "Name")
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, r.Calls)
+
+	multi := []HookModel{{Name: "Multi A"}, {Name: "Multi B"}}
+	num, err := o.InsertMulti(1, multi)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), num)
+	assert.Equal(t, "multi-a", multi[0].Slug)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, multi[1].Calls)
+	bulk := []*HookModel{{Name: "Bulk A"}, {Name: "Bulk B"}}
+	_, err = o.InsertMulti(2, bulk)
+	assert.Nil(t, err)
+	assert.Equal(t, "bulk-b", bulk[1].Slug)
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert"}, bulk[0].Calls)
+
+	var all []*HookModel
+	num, err = o.QueryTable(new(HookModel)).Filter("slug__startswith", "bulk").All(&all)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), num)
+	for _, m := range all {
+		assert.Equal(t, []string{"AfterRead"}, m.Calls)
+	}
+	var one HookModel
+	assert.Nil(t, o.QueryTable(new(HookModel)).Filter("slug", "multi-a").One(&one))
+	assert.Equal(t, []string{"AfterRead"}, one.Calls)
+
+	// the hooks run in the same transaction
+	to, err := o.Begin()
+	assert.Nil(t, err)
+	tx := &HookModel{Name: "In Tx"}
+	_, err = to.Insert(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), tx.Visible)
+	assert.Nil(t, to.Rollback())
+	assert.False(t, o.QueryTable(new(HookModel)).Filter("slug", "in-tx").Exist())
+
+	_, err = o.Delete(&HookModel{ID: h.ID, Name: "protected"})
+	assert.NotNil(t, err)
+	h.Calls = nil
+	num, err = o.Delete(h)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	assert.Equal(t, []string{"BeforeDelete", "AfterDelete"}, h.Calls)
+
+	_, err = o.QueryTable(new(HookModel)).Filter("id__gt", 0).Delete()
+	assert.Nil(t, err)
+}
+
+func TestIterate(t *testing.T) {
+	o := NewOrm()
+	ctx := context.Background()
+
+	names := []string{"Iter A", "Iter B", "Iter C", "Iter D", "Iter E"}
+	for _, name := range names {
+		_, err := o.Insert(&HookModel{Name: name})
+		assert.Nil(t, err)
+	}
+
+	qs := o.QueryTable(new(HookModel)).Filter("slug__startswith", "iter").OrderBy("id")
+	it, err := qs.Iterate(ctx)
+	assert.Nil(t, err)
+	var got []string
+	for it.Next() {
+		var m HookModel
+		assert.Nil(t, it.Scan(&m))
+		assert.Equal(t, []string{"AfterRead"}, m.Calls)
+		got = append(got, m.Name)
+	}
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Close())
+	assert.Equal(t, names, got)
+
+	it, err = qs.Iterate(ctx, "Name")
+	assert.Nil(t, err)
+	assert.True(t, it.Next())
+	var user User
+	assert.Equal(t, ErrIteratorContainer, it.Scan(&user))
+	var m HookModel
+	assert.Nil(t, it.Scan(&m))
+	assert.Equal(t, "Iter A", m.Name)
+	assert.Equal(t, "", m.Slug)
+	assert.Nil(t, it.Close())
+
+	_, err = qs.Iterate(ctx, "NotExist")
+	assert.NotNil(t, err)
+
+	it, err = o.Raw("SELECT id, name FROM hook_model WHERE slug LIKE ? ORDER BY id", "iter%").Iterate(ctx)
+	assert.Nil(t, err)
+	got = got[:0]
+	for it.Next() {
+		var id int
+		var name string
+		assert.Nil(t, it.Scan(&id, &name))
+		assert.True(t, id > 0)
+		got = append(got, name)
+	}
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Close())
+	assert.Equal(t, names, got)
+
+	it, err = o.Raw("SELECT id, name FROM hook_model WHERE slug = ?", "iter-c").Iterate(ctx)
+	assert.Nil(t, err)
+	assert.True(t, it.Next())
+	var raw HookModel
+	assert.Nil(t, it.Scan(&raw))
+	assert.Equal(t, "Iter C", raw.Name)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Close())
+
+	// QueryRow and QueryRows share the row mapping with the raw iterator
+	raw = HookModel{}
+	assert.Nil(t, o.Raw("SELECT id, name FROM hook_model WHERE slug = ?", "iter-d").QueryRow(&raw))
+	assert.Equal(t, "Iter D", raw.Name)
+	var raws []*HookModel
+	num, err := o.Raw("SELECT id, name FROM hook_model WHERE slug LIKE ? ORDER BY id", "iter%").QueryRows(&raws)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), num)
+	assert.Equal(t, "Iter E", raws[4].Name)
+
+	var chunk []*HookModel
+	var sizes []int
+	got = got[:0]
+	err = ChunkByPk(ctx, o.QueryTable(new(HookModel)).Filter("slug__startswith", "iter"), 2, &chunk, func() error {
+		sizes = append(sizes, len(chunk))
+		for _, m := range chunk {
+			got = append(got, m.Name)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 2, 1}, sizes)
+	assert.Equal(t, names, got)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = ChunkByPk(ctx, o.QueryTable(new(HookModel)), 2, &chunk, func() error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	assert.NotNil(t, ChunkByPk(ctx, o.QueryTable(new(HookModel)), 0, &chunk, func() error { return nil }))
+
+	_, err = o.QueryTable(new(HookModel)).Filter("id__gt", 0).Delete()
+	assert.Nil(t, err)
+}
+
+func TestPkConvertible(t *testing.T) {
+	type id int64
+	testCases := []struct {
+		from, to interface{}
+		want     bool
+	}{
+		{from: 1, to: int64(0), want: true},
+		{from: int32(1), to: int64(0), want: true},
+		{from: uint16(1), to: int32(0), want: true},
+		{from: id(1), to: int64(0), want: true},
+		{from: "1", to: "", want: true},
+		{from: 1, to: "", want: false},
+		{from: 1.0, to: 0, want: false},
+		{from: int64(1), to: int32(0), want: false},
+		{from: uint32(1), to: int32(0), want: false},
+		{from: -1, to: uint64(0), want: false},
+	}
+	for _, tc := range testCases {
+		from, to := reflect.TypeOf(tc.from), reflect.TypeOf(tc.to)
+		assert.Equal(t, tc.want, pkConvertible(from, to), "%s to %s", from, to)
+	}
+}
+
+func TestTypedQuery(t *testing.T) {
+	o := NewOrm()
+	ctx := context.Background()
+
+	for _, name := range []string{"Typed A", "Typed B", "Typed C"} {
+		_, err := o.Insert(&HookModel{Name: name})
+		assert.Nil(t, err)
+	}
+
+	ms, err := Query[HookModel](o).Filter("slug__startswith", "typed").OrderBy("-ID").All(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(ms))
+	assert.Equal(t, "Typed C", ms[0].Name)
+	assert.Equal(t, []string{"AfterRead"}, ms[0].Calls)
+
+	m, err := Query[HookModel](o).Filter("Slug", "typed-b").One(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "Typed B", m.Name)
+
+	_, err = Query[HookModel](o).Filter("Slug", "nothing").One(ctx)
+	assert.Equal(t, ErrNoRows, err)
+
+	got, err := Get[HookModel](ctx, o, m.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "typed-b", got.Slug)
+	_, err = Get[HookModel](ctx, o, "not a pk")
+	assert.NotNil(t, err)
+	got, err = Get[HookModel](ctx, o, int16(m.ID))
+	assert.Nil(t, err)
+	assert.Equal(t, "typed-b", got.Slug)
+	_, err = Get[HookModel](ctx, o, float64(m.ID))
+	assert.NotNil(t, err)
+	_, err = Get[HookModel](ctx, o, uint64(m.ID))
+	assert.NotNil(t, err)
+
+	cnt, err := Query[HookModel](o).Filter("slug__startswith", "typed").Count(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	var names []string
+	err = Query[HookModel](o).Filter("slug__startswith", "typed").OrderBy("ID").Each(ctx, func(m *HookModel) error {
+		names = append(names, m.Name)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Typed A", "Typed B", "Typed C"}, names)
+
+	// typos are returned as errors instead of panics
+	_, err = Query[HookModel](o).Filter("Slgu", "typed-a").All(ctx)
+	assert.NotNil(t, err)
+	_, err = Query[HookModel](o).OrderBy("-Nmae").All(ctx)
+	assert.NotNil(t, err)
+	_, err = Query[HookModel](o).All(ctx, "Nmae")
+	assert.NotNil(t, err)
+	_, err = Query[HookModel](o).Filter("slug", "typed-a").Update(ctx, Params{"Nmae": "x"})
+	assert.NotNil(t, err)
+	_, err = Query[struct{ ID int }](o).All(ctx)
+	assert.NotNil(t, err)
+
+	num, err := Query[HookModel](o).Filter("slug", "typed-a").Update(ctx, Params{"Name": "Typed Z"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+
+	num, err = Query[HookModel](o).Filter("slug__startswith", "typed").Delete(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), num)
+	ok, err := Query[HookModel](o).Filter("slug__startswith", "typed").Exist(ctx)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestSoftDelete(t *testing.T) {
+	o := NewOrm()
+
+	ms := []*SoftModel{{Name: "soft a"}, {Name: "soft b"}, {Name: "soft c"}, {Name: "soft d"}}
+	for _, m := range ms {
+		_, err := o.Insert(m)
+		assert.Nil(t, err)
+	}
+
+	num, err := o.Delete(ms[0])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	assert.NotNil(t, ms[0].DeletedAt)
+
+	// deleting it again does nothing
+	num, err = o.Delete(&SoftModel{ID: ms[0].ID})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), num)
+
+	err = o.Read(&SoftModel{ID: ms[0].ID})
+	assert.Equal(t, ErrNoRows, err)
+
+	qs := o.QueryTable(new(SoftModel))
+	cnt, err := qs.Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+	cnt, err = qs.Unscoped().Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), cnt)
+
+	var deleted SoftModel
+	err = qs.Unscoped().Filter("id", ms[0].ID).One(&deleted)
+	assert.Nil(t, err)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	// the soft deleted row is not updated
+	deleted.Name = "soft updated"
+	num, err = o.Update(&deleted, "Name")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), num)
+	assert.False(t, qs.Unscoped().Filter("name", "soft updated").Exist())
+
+	// ReadOrCreate doesn't read the soft deleted row, but inserts a new one
+	created, id, err := o.ReadOrCreate(&SoftModel{Name: "soft a"}, "Name")
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, int64(ms[0].ID), id)
+	num, err = o.HardDelete(&SoftModel{ID: int(id)})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+
+	// the soft deleted row is restored by Unscoped
+	num, err = qs.Unscoped().Filter("id", ms[0].ID).Update(Params{"deleted_at": nil})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	assert.Nil(t, o.Read(&SoftModel{ID: ms[0].ID}))
+	num, err = o.Delete(&SoftModel{ID: ms[0].ID})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+
+	num, err = qs.Filter("name", "soft b").Delete()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	var alive []*SoftModel
+	num, err = qs.OrderBy("id").All(&alive)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), num)
+	assert.Equal(t, "soft c", alive[0].Name)
+
+	num, err = o.HardDelete(ms[2])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	cnt, err = qs.Unscoped().Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cnt)
+
+	num, err = qs.Unscoped().Filter("name__startswith", "soft").Delete()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), num)
+	cnt, err = qs.Unscoped().Count()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), cnt)
+}
+
+func TestOptimisticLock(t *testing.T) {
+	o := NewOrm()
+
+	m := &VersionModel{Name: "v1"}
+	_, err := o.Insert(m)
+	assert.Nil(t, err)
+
+	stale := &VersionModel{ID: m.ID}
+	assert.Nil(t, o.Read(stale))
+
+	m.Name = "v2"
+	num, err := o.Update(m)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	assert.Equal(t, 1, m.Version)
+
+	// the version is increased even if it's not in cols
+	m.Name = "v3"
+	_, err = o.Update(m, "Name")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, m.Version)
+
+	stale.Name = "lost"
+	num, err = o.Update(stale)
+	assert.Equal(t, ErrStaleObject, err)
+	assert.Equal(t, int64(0), num)
+	assert.Equal(t, 0, stale.Version)
+
+	qs := o.QueryTable(new(VersionModel)).Filter("id", m.ID)
+	num, err = qs.Update(Params{"name": "v4"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), num)
+	_, err = o.Update(m)
+	assert.Equal(t, ErrStaleObject, err)
+
+	assert.Nil(t, o.Read(m))
+	assert.Equal(t, "v4", m.Name)
+	assert.Equal(t, 3, m.Version)
+
+	_, err = qs.Update(Params{"version": 10})
+	assert.Nil(t, err)
+	assert.Nil(t, o.Read(m))
+	assert.Equal(t, 10, m.Version)
+
+	_, err = o.Delete(m)
+	assert.Nil(t, err)
+}
+
+func TestJSONField(t *testing.T) {
+	m1 := &JSONModel{
+		Attrs:  JSONAttrs{Color: "red", Size: 10, Tags: []string{"go", "orm"}},
+		Meta:   map[string]interface{}{"owner": "slene"},
+		Labels: []string{"a", "b"},
+	}
+	m2 := &JSONModel{Attrs: JSONAttrs{Color: "blue", Size: 2}}
+	for _, m := range []*JSONModel{m1, m2} {
+		_, err := dORM.Insert(m)
+		throwFailNow(t, err)
+	}
+
+	m := &JSONModel{ID: m1.ID}
+	throwFailNow(t, dORM.Read(m))
+	throwFail(t, AssertIs(m.Attrs.Color, "red"))
+	throwFail(t, AssertIs(m.Attrs.Tags[1], "orm"))
+	throwFail(t, AssertIs(m.Meta["owner"], "slene"))
+	throwFail(t, AssertIs(len(m.Labels), 2))
+
+	m = &JSONModel{ID: m2.ID}
+	throwFailNow(t, dORM.Read(m))
+	throwFail(t, AssertIs(m.Attrs.Size, 2))
+	throwFail(t, AssertIs(m.Meta == nil, true))
+	throwFail(t, AssertIs(m.Labels == nil, true))
+
+	qs := dORM.QueryTable(new(JSONModel))
+	num, err := qs.Filter("attrs__json__color", "red").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("attrs__json__size__gt", 3).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	// the multi-digit value is compared as number but not text
+	num, err = qs.Filter("attrs__json__size__lt", 9).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("attrs__json__size__in", []int{10, 20}).Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("attrs__json__tags__0__exact", "go").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	if !IsSqlite {
+		num, err = qs.Filter("attrs__json_contains", map[string]string{"color": "blue"}).Count()
+		throwFail(t, err)
+		throwFail(t, AssertIs(num, 1))
+	}
+	num, err = qs.Filter("labels__json_contains", "b").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("attrs__json__tags__json_contains", "orm").Count()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+
+	num, err = qs.Filter("id", m2.ID).Update(Params{"attrs": JSONAttrs{Color: "green"}})
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+	throwFailNow(t, dORM.Read(m))
+	throwFail(t, AssertIs(m.Attrs.Color, "green"))
+
+	var maps []Params
+	_, err = qs.Filter("id", m2.ID).Values(&maps, "attrs")
+	throwFail(t, err)
+	throwFail(t, AssertIs(maps[0]["Attrs"], `{"color":"green","size":0,"tags":null}`))
+
+	num, err = qs.Filter("id__in", m1.ID, m2.ID).Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 2))
+}
+
+func TestPreload(t *testing.T) {
+	users := []*User{{UserName: "preload1"}, {UserName: "preload2"}}
+	for _, u := range users {
+		_, err := dORM.Insert(u)
+		throwFailNow(t, err)
+	}
+	posts := []*Post{
+		{User: users[0], Title: "go orm"},
+		{User: users[0], Title: "go web"},
+		{User: users[1], Title: "rust"},
+	}
+	for _, p := range posts {
+		_, err := dORM.Insert(p)
+		throwFailNow(t, err)
+	}
+	tags := []*Tag{{Name: "preload_go"}, {Name: "preload_db"}}
+	for _, tag := range tags {
+		_, err := dORM.Insert(tag)
+		throwFailNow(t, err)
+	}
+	_, err := dORM.QueryM2M(posts[0], "Tags").Add(tags[0], tags[1])
+	throwFailNow(t, err)
+	_, err = dORM.QueryM2M(posts[1], "Tags").Add(tags[0])
+	throwFailNow(t, err)
+
+	defer func() {
+		for _, p := range posts {
+			_, _ = dORM.QueryM2M(p, "Tags").Clear()
+			_, _ = dORM.Delete(p)
+		}
+		for _, tag := range tags {
+			_, _ = dORM.Delete(tag)
+		}
+		for _, u := range users {
+			_, _ = dORM.Delete(u)
+		}
+	}()
+
+	var loaded []*User
+	qs := dORM.QueryTable(new(User)).Filter("user_name__startswith", "preload").OrderBy("id")
+	num, err := qs.Preload("Posts__Tags").PreloadWith("Posts", nil, "-id").All(&loaded)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(num, 2))
+	throwFailNow(t, AssertIs(len(loaded[0].Posts), 2))
+	throwFail(t, AssertIs(loaded[0].Posts[0].Title, "go web"))
+	throwFail(t, AssertIs(len(loaded[0].Posts[0].Tags), 1))
+	throwFail(t, AssertIs(len(loaded[0].Posts[1].Tags), 2))
+	throwFail(t, AssertIs(loaded[0].Posts[1].Tags[0].Name, "preload_go"))
+	throwFailNow(t, AssertIs(len(loaded[1].Posts), 1))
+	throwFail(t, AssertIs(len(loaded[1].Posts[0].Tags), 0))
+
+	var tag Tag
+	err = dORM.QueryTable(new(Tag)).Filter("id", tags[0].ID).
+		PreloadWith("Posts", NewCondition().And("title__contains", "orm")).Preload("Posts__User").One(&tag)
+	throwFailNow(t, err)
+	throwFailNow(t, AssertIs(len(tag.Posts), 1))
+	throwFail(t, AssertIs(tag.Posts[0].User.UserName, "preload1"))
+
+	_, err = qs.Preload("UserName").All(&loaded)
+	throwFail(t, AssertIs(err != nil, true))
+}
+
+func TestMigrationChanges(t *testing.T) {
+	if IsSqlite {
+		ctx := context.Background()
+		al := getDbAlias("default")
+
+		// the models registered by other tests may be not synced, only the changes of version_model are checked
+		versionModelChanges := func() []schemaChange {
+			changes, err := getMigrationChanges(ctx, al, al.DB)
+			assert.Nil(t, err)
+			res := make([]schemaChange, 0, len(changes))
+			for _, c := range changes {
+				if strings.Contains(c.desc, "`version_model`") {
+					res = append(res, c)
+				}
+			}
+			return res
+		}
+
+		assert.Empty(t, versionModelChanges())
+
+		_, err := al.DB.Exec("ALTER TABLE `version_model` RENAME COLUMN `name` TO `title`")
+		assert.Nil(t, err)
+		_, err = al.DB.Exec("ALTER TABLE `version_model` ADD COLUMN `extra` integer")
+		assert.Nil(t, err)
+		_, err = al.DB.Exec("CREATE INDEX `version_model_version` ON `version_model` (`version`)")
+		assert.Nil(t, err)
+
+		changes := versionModelChanges()
+		descs := make([]string, 0, len(changes))
+		for _, c := range changes {
+			descs = append(descs, c.desc)
+		}
+		assert.Equal(t, []string{
+			"drop index `version_model_version` of table `version_model`",
+			"rename column `title` to `name` in table `version_model`",
+			"drop column `extra` of table `version_model`",
+		}, descs)
+
+		for _, c := range changes {
+			_, err = al.DB.Exec(c.up)
+			assert.Nil(t, err)
+		}
+		assert.Empty(t, versionModelChanges())
+	}
+}
+
 func TestTxOrmRollbackUnlessCommit(t *testing.T) {
 	o := NewOrm()
 	var tag Tag
@@ -2368,6 +3039,80 @@ func TestTxOrmRollbackUnlessCommit(t *testing.T) {
 	assert.Equal(t, int64(1), num)
 }
 
+func TestNestedTransaction(t *testing.T) {
+	o := NewOrm()
+	names := []interface{}{"nested rollback", "nested commit", "nested nested", "required", "rollback to"}
+
+	err := o.DoTx(func(ctx context.Context, txOrm TxOrmer) error {
+		// only the part of the nested transaction is rolled back
+		err := txOrm.DoTxWithCtx(ctx, func(ctx context.Context, txOrm TxOrmer) error {
+			_, err := txOrm.Insert(&Tag{Name: "nested rollback"})
+			throwFail(t, err)
+			return errors.New("nested error")
+		})
+		assert.Equal(t, "nested error", err.Error())
+
+		nested, err := txOrm.Begin()
+		throwFail(t, err)
+		_, err = nested.Insert(&Tag{Name: "nested commit"})
+		throwFail(t, err)
+		err = nested.DoTxWithPropagation(ctx, PropagationNested, nil, func(ctx context.Context, txOrm TxOrmer) error {
+			_, err := txOrm.Insert(&Tag{Name: "nested nested"})
+			return err
+		})
+		throwFail(t, err)
+		throwFail(t, nested.Commit())
+		assert.Equal(t, sql.ErrTxDone, nested.Commit())
+		throwFail(t, nested.RollbackUnlessCommit())
+
+		err = txOrm.DoTxWithPropagation(ctx, PropagationRequired, nil, func(ctx context.Context, tx TxOrmer) error {
+			assert.Equal(t, txOrm, tx)
+			_, err := tx.Insert(&Tag{Name: "required"})
+			return err
+		})
+		throwFail(t, err)
+
+		nested, err = txOrm.Begin()
+		throwFail(t, err)
+		_, err = nested.Insert(&Tag{Name: "rollback to"})
+		throwFail(t, err)
+		throwFail(t, nested.RollbackUnlessCommit())
+
+		_, err = txOrm.BeginWithOpts(&sql.TxOptions{ReadOnly: true})
+		assert.NotNil(t, err)
+
+		num, err := txOrm.QueryTable("tag").Filter("name__in", names...).Count()
+		throwFail(t, err)
+		throwFail(t, AssertIs(num, 3))
+		return nil
+	})
+	throwFail(t, err)
+
+	num, err := o.QueryTable("tag").Filter("name__in", names...).Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+
+	// the connections of sqlite in memory are different databases
+	if IsSqlite {
+		return
+	}
+	err = o.DoTx(func(ctx context.Context, txOrm TxOrmer) error {
+		err := txOrm.DoTxWithPropagation(ctx, PropagationRequiresNew, nil, func(ctx context.Context, txOrm TxOrmer) error {
+			_, err := txOrm.Insert(&Tag{Name: "requires new"})
+			return err
+		})
+		throwFail(t, err)
+		_, err = txOrm.Insert(&Tag{Name: "rolled back"})
+		throwFail(t, err)
+		return errors.New("outer error")
+	})
+	assert.Equal(t, "outer error", err.Error())
+
+	num, err = o.QueryTable("tag").Filter("name__in", "requires new", "rolled back").Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 1))
+}
+
 func TestTransactionIsolationLevel(t *testing.T) {
 	// this test worked when database support transaction isolation level
 	if IsSqlite {
@@ -2553,6 +3298,66 @@ func TestIntegerPk(t *testing.T) {
 	throwFail(t, AssertIs(num, 1))
 }
 
+func TestInsertMultiOrUpdate(t *testing.T) {
+	users := []*User{
+		{UserName: "upsert_1", Email: "upsert_1@gmail.com", Status: 1},
+		{UserName: "upsert_2", Email: "upsert_2@gmail.com", Status: 1},
+	}
+	if !IsMysql && !IsPostgres && !IsSqlite {
+		_, err := dORM.InsertMultiOrUpdate(2, users, []string{"UserName"}, nil)
+		throwFail(t, AssertNot(err, nil))
+		return
+	}
+
+	if !IsMysql {
+		// InsertMulti fills the pk by RETURNING too
+		inserted := []User{{UserName: "multi_returning"}}
+		_, err := dORM.InsertMulti(2, inserted)
+		throwFailNow(t, err)
+		throwFail(t, AssertIs(inserted[0].ID != 0, true))
+		_, err = dORM.Delete(&inserted[0])
+		throwFail(t, err)
+	}
+
+	num, err := dORM.InsertMultiOrUpdate(2, users, []string{"UserName"}, nil)
+	throwFailNow(t, err)
+	throwFail(t, AssertIs(num, 2))
+	if !IsMysql {
+		// the pk is filled by RETURNING
+		throwFail(t, AssertIs(users[0].ID != 0 && users[1].ID != 0, true))
+	}
+
+	// the returned rows are matched by UserName, whatever their order is
+	more := []User{
+		{UserName: "upsert_3", Email: "upsert_3@gmail.com", Status: 3},
+		{UserName: "upsert_1", Status: 2},
+	}
+	_, err = dORM.InsertMultiOrUpdate(10, more, []string{"user_name"}, []string{"Status"})
+	throwFailNow(t, err)
+	if !IsMysql {
+		throwFail(t, AssertIs(more[1].ID, users[0].ID))
+		throwFail(t, AssertIs(more[1].Email, "upsert_1@gmail.com"))
+		throwFail(t, AssertIs(more[0].ID != 0, true))
+		throwFail(t, AssertIs(more[0].ID != more[1].ID, true))
+	}
+
+	u := &User{UserName: "upsert_1"}
+	throwFail(t, dORM.Read(u, "UserName"))
+	throwFail(t, AssertIs(u.Status, 2))
+	throwFail(t, AssertIs(u.Email, "upsert_1@gmail.com"))
+
+	if !IsMysql {
+		_, err = dORM.InsertMultiOrUpdate(10, more, nil, nil)
+		throwFail(t, AssertNot(err, nil))
+	}
+	_, err = dORM.InsertMultiOrUpdate(10, more, []string{"UserName"}, []string{"Unknown"})
+	throwFail(t, AssertNot(err, nil))
+
+	num, err = dORM.QueryTable("user").Filter("user_name__in", "upsert_1", "upsert_2", "upsert_3").Delete()
+	throwFail(t, err)
+	throwFail(t, AssertIs(num, 3))
+}
+
 func TestInsertAuto(t *testing.T) {
 	u := &User{
 		UserName: "autoPre",
@@ -2882,6 +3687,40 @@ func TestPSQueryBuilder(t *testing.T) {
 	throwFailNow(t, AssertIs(l[0].Age, 30))
 }
 
+func TestSQLiteQueryBuilder(t *testing.T) {
+	qb, err := NewQueryBuilder("sqlite")
+	throwFailNow(t, err)
+
+	sql := qb.Select("id", "user_name").From("user").Where("id > ?").And("status = ?").
+		OrderBy("id").Desc().Limit(10).Offset(5).ForUpdate().String()
+	assert.Equal(t, "SELECT id, user_name FROM user WHERE id > ? AND status = ? ORDER BY id DESC LIMIT 10 OFFSET 5", sql)
+
+	sql = qb.Update("user").Set("status = ?").Where("id").In("1", "2").String()
+	assert.Equal(t, "UPDATE user SET status = ? WHERE id IN ( 1, 2 )", sql)
+
+	if IsSqlite {
+		var names []string
+		qb.Select("user_name").From("user").Where("id = ?")
+		_, err = dORM.Raw(qb.String(), 2).QueryRows(&names)
+		throwFailNow(t, err)
+	}
+}
+
+func TestMsSQLQueryBuilder(t *testing.T) {
+	qb, err := NewQueryBuilder("sqlserver")
+	throwFailNow(t, err)
+
+	sql := qb.Select("id", "user_name").From("user").Where("id > ?").And("status = ?").
+		OrderBy("id").Desc().Offset(5).Limit(10).ForUpdate().String()
+	assert.Equal(t, `SELECT "id","user_name" FROM "user" WHERE id > ? AND status = ? ORDER BY "id" DESC OFFSET 5 ROWS FETCH NEXT 10 ROWS ONLY`, sql)
+
+	sql = qb.Select("*").From("user").Limit(10).String()
+	assert.Equal(t, `SELECT * FROM "user" ORDER BY 1 OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`, sql)
+
+	sql = qb.Update("user").Set("status = ?").Where("id").In("1", "2").String()
+	assert.Equal(t, `UPDATE "user" SET status = ? WHERE id IN ( 1, 2 )`, sql)
+}
+
 func TestContextCanceled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 
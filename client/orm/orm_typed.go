@@ -0,0 +1,348 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jialequ/android-sdk/client/orm/internal/models"
+)
+
+// TypedQuerySeter is the typed version of QuerySeter for the model T.
+// The field names are checked against T when building the query, a wrong one
+// is returned as an error by the query methods, instead of a panic when executing.
+// for example:
+//
+//	users, err := orm.Query[User](o).Filter("Status", 1).OrderBy("-Id").Limit(10).All(ctx)
+type TypedQuerySeter[T any] struct {
+	qs  QuerySeter
+	mi  *models.ModelInfo
+	err error
+}
+
+// Query return a TypedQuerySeter of the model T,
+// it uses QueryTable of o, so it works with TxOrmer and the mock package as well.
+func Query[T any](o QueryExecutor) TypedQuerySeter[T] {
+	md := new(T)
+	mi, err := typedModelInfo(md)
+	if err != nil {
+		return TypedQuerySeter[T]{err: err}
+	}
+	return TypedQuerySeter[T]{qs: o.QueryTable(md), mi: mi}
+}
+
+// Get read the model T by its primary key.
+// The pk must be of the kind of the primary key field, or an integer which can be widened to it.
+// for example:
+//
+//	user, err := orm.Get[User](ctx, o, 1)
+func Get[T any](ctx context.Context, o QueryExecutor, pk interface{}) (*T, error) {
+	md := new(T)
+	mi, err := typedModelInfo(md)
+	if err != nil {
+		return nil, err
+	}
+	if mi.Fields.Pk == nil {
+		return nil, ErrMissPK
+	}
+	field := reflect.ValueOf(md).Elem().FieldByIndex(mi.Fields.Pk.FieldIndex)
+	val := reflect.ValueOf(pk)
+	if !val.IsValid() || !pkConvertible(val.Type(), field.Type()) {
+		return nil, fmt.Errorf("<orm.Get> pk `%v` can not be used as `%s`", pk, field.Type())
+	}
+	field.Set(val.Convert(field.Type()))
+	if err := o.ReadWithCtx(ctx, md); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// pkConvertible report whether the pk of type from can be converted to the primary key of type to without loss,
+// that is they have the same kind, or from is an integer no wider than to.
+func pkConvertible(from, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	if from.Kind() == to.Kind() {
+		return true
+	}
+	switch {
+	case isSignedKind(from.Kind()) && isSignedKind(to.Kind()),
+		isUnsignedKind(from.Kind()) && isUnsignedKind(to.Kind()):
+		return from.Bits() <= to.Bits()
+	case isUnsignedKind(from.Kind()) && isSignedKind(to.Kind()):
+		return from.Bits() < to.Bits()
+	}
+	return false
+}
+
+func isSignedKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUnsignedKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uint64
+}
+
+func typedModelInfo(md interface{}) (*models.ModelInfo, error) {
+	typ := reflect.TypeOf(md).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("<orm.Query> model must be a struct, got `%s`", typ)
+	}
+	mi, ok := defaultModelCache.GetByFullName(models.GetFullName(typ))
+	if !ok {
+		return nil, fmt.Errorf("<orm.Query> table: `%s` not found, make sure it was registered with `RegisterModel()`", typ)
+	}
+	return mi, nil
+}
+
+// check return a copy of q carrying the error of the first wrong field expression
+func (q TypedQuerySeter[T]) check(exprs ...string) TypedQuerySeter[T] {
+	if q.err != nil {
+		return q
+	}
+	for _, expr := range exprs {
+		if !q.validExpr(expr) {
+			q.err = fmt.Errorf("<orm.Query> unknown field/column name `%s` for model `%s`", expr, q.mi.FullName)
+			return q
+		}
+	}
+	return q
+}
+
+// validExpr report whether the expr, which may have an operator suffix, can be resolved from the model
+func (q TypedQuerySeter[T]) validExpr(expr string) bool {
+	exprs := strings.Split(expr, ExprSep)
+	if num := len(exprs) - 1; num > 0 && operators[exprs[num]] {
+		exprs = exprs[:num]
+	}
+	exprs, _, _ = splitJSONPath(q.mi, exprs)
+	_, _, _, ok := newDbTables(q.mi, nil).parseExprs(q.mi, exprs)
+	return ok
+}
+
+// Filter add condition expression, see QuerySeter.Filter
+func (q TypedQuerySeter[T]) Filter(expr string, args ...interface{}) TypedQuerySeter[T] {
+	if q = q.check(expr); q.err == nil {
+		q.qs = q.qs.Filter(expr, args...)
+	}
+	return q
+}
+
+// FilterRaw add raw sql condition, see QuerySeter.FilterRaw
+func (q TypedQuerySeter[T]) FilterRaw(expr string, sql string) TypedQuerySeter[T] {
+	if q = q.check(expr); q.err == nil {
+		q.qs = q.qs.FilterRaw(expr, sql)
+	}
+	return q
+}
+
+// Exclude add NOT condition expression, see QuerySeter.Exclude
+func (q TypedQuerySeter[T]) Exclude(expr string, args ...interface{}) TypedQuerySeter[T] {
+	if q = q.check(expr); q.err == nil {
+		q.qs = q.qs.Exclude(expr, args...)
+	}
+	return q
+}
+
+// SetCond set the condition, see QuerySeter.SetCond
+func (q TypedQuerySeter[T]) SetCond(cond *Condition) TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.SetCond(cond)
+	}
+	return q
+}
+
+// Limit add LIMIT value, see QuerySeter.Limit
+func (q TypedQuerySeter[T]) Limit(limit interface{}, args ...interface{}) TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.Limit(limit, args...)
+	}
+	return q
+}
+
+// Offset add OFFSET value, see QuerySeter.Offset
+func (q TypedQuerySeter[T]) Offset(offset interface{}) TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.Offset(offset)
+	}
+	return q
+}
+
+// GroupBy add GROUP BY expression, see QuerySeter.GroupBy
+func (q TypedQuerySeter[T]) GroupBy(exprs ...string) TypedQuerySeter[T] {
+	if q = q.check(exprs...); q.err == nil {
+		q.qs = q.qs.GroupBy(exprs...)
+	}
+	return q
+}
+
+// OrderBy add ORDER expression, "column" means ASC, "-column" means DESC, see QuerySeter.OrderBy
+func (q TypedQuerySeter[T]) OrderBy(exprs ...string) TypedQuerySeter[T] {
+	for _, expr := range exprs {
+		if q = q.check(strings.TrimPrefix(expr, "-")); q.err != nil {
+			return q
+		}
+	}
+	q.qs = q.qs.OrderBy(exprs...)
+	return q
+}
+
+// RelatedSel set the relation models to query together, see QuerySeter.RelatedSel
+func (q TypedQuerySeter[T]) RelatedSel(params ...interface{}) TypedQuerySeter[T] {
+	for _, p := range params {
+		if name, ok := p.(string); ok {
+			if q = q.check(name); q.err != nil {
+				return q
+			}
+		}
+	}
+	q.qs = q.qs.RelatedSel(params...)
+	return q
+}
+
+// Preload load the relations after All and One, see QuerySeter.Preload
+func (q TypedQuerySeter[T]) Preload(names ...string) TypedQuerySeter[T] {
+	if q = q.check(names...); q.err == nil {
+		q.qs = q.qs.Preload(names...)
+	}
+	return q
+}
+
+// PreloadWith preload the relation with condition and orders, see QuerySeter.PreloadWith
+func (q TypedQuerySeter[T]) PreloadWith(name string, cond *Condition, orders ...string) TypedQuerySeter[T] {
+	if q = q.check(name); q.err == nil {
+		q.qs = q.qs.PreloadWith(name, cond, orders...)
+	}
+	return q
+}
+
+// Distinct set DISTINCT, see QuerySeter.Distinct
+func (q TypedQuerySeter[T]) Distinct() TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.Distinct()
+	}
+	return q
+}
+
+// ForUpdate set FOR UPDATE, see QuerySeter.ForUpdate
+func (q TypedQuerySeter[T]) ForUpdate() TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.ForUpdate()
+	}
+	return q
+}
+
+// Unscoped include the soft deleted models, see QuerySeter.Unscoped
+func (q TypedQuerySeter[T]) Unscoped() TypedQuerySeter[T] {
+	if q.err == nil {
+		q.qs = q.qs.Unscoped()
+	}
+	return q
+}
+
+// QuerySeter return the underlying QuerySeter, and the error of building the query if any
+func (q TypedQuerySeter[T]) QuerySeter() (QuerySeter, error) {
+	return q.qs, q.err
+}
+
+// All query all the matched models.
+// cols means the columns when querying.
+func (q TypedQuerySeter[T]) All(ctx context.Context, cols ...string) ([]*T, error) {
+	if q = q.check(cols...); q.err != nil {
+		return nil, q.err
+	}
+	var res []*T
+	if _, err := q.qs.AllWithCtx(ctx, &res, cols...); err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = make([]*T, 0)
+	}
+	return res, nil
+}
+
+// One query one model, ErrNoRows is returned if none is matched.
+// cols means the columns when querying.
+func (q TypedQuerySeter[T]) One(ctx context.Context, cols ...string) (*T, error) {
+	if q = q.check(cols...); q.err != nil {
+		return nil, q.err
+	}
+	md := new(T)
+	if err := q.qs.OneWithCtx(ctx, md, cols...); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// Each iterate the matched models one by one, see QuerySeter.Iterate.
+// Iteration stops at the first error returned by fn.
+func (q TypedQuerySeter[T]) Each(ctx context.Context, fn func(*T) error, cols ...string) error {
+	if q = q.check(cols...); q.err != nil {
+		return q.err
+	}
+	it, err := q.qs.Iterate(ctx, cols...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		md := new(T)
+		if err := it.Scan(md); err != nil {
+			return err
+		}
+		if err := fn(md); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// Count return the count of the matched models
+func (q TypedQuerySeter[T]) Count(ctx context.Context) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	return q.qs.CountWithCtx(ctx)
+}
+
+// Exist check whether any model is matched
+func (q TypedQuerySeter[T]) Exist(ctx context.Context) (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
+	return q.qs.ExistWithCtx(ctx), nil
+}
+
+// Update update the matched models with values, the keys are field names
+func (q TypedQuerySeter[T]) Update(ctx context.Context, values Params) (int64, error) {
+	for name := range values {
+		if q = q.check(name); q.err != nil {
+			return 0, q.err
+		}
+	}
+	return q.qs.UpdateWithCtx(ctx, values)
+}
+
+// Delete delete the matched models
+func (q TypedQuerySeter[T]) Delete(ctx context.Context) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	return q.qs.DeleteWithCtx(ctx)
+}
@@ -53,8 +53,10 @@ func NewQueryBuilder(driver string) (qb QueryBuilder, err error) {
 		qb = new(TiDBQueryBuilder)
 	} else if driver == "postgres" {
 		qb = new(PostgresQueryBuilder)
-	} else if driver == "sqlite" {
-		err = errors.New("sqlite query builder is not supported yet")
+	} else if driver == "sqlite" || driver == "sqlite3" {
+		qb = new(SQLiteQueryBuilder)
+	} else if driver == "mssql" || driver == "sqlserver" {
+		qb = new(MsSQLQueryBuilder)
 	} else {
 		err = errors.New("unknown driver for query builder")
 	}
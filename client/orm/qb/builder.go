@@ -0,0 +1,482 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package qb is the query builder which keeps the values out of the sql.
+// The values are bound to ? placeholders and returned as args by Build,
+// which are converted to the placeholders of the database by Ormer.Raw.
+// The identifiers, like the table and column names, are written as they are,
+// so they must not come from the user input.
+// LIMIT and OFFSET are written in the syntax of mysql, postgres and sqlite, which mssql and oracle
+// don't support, and FOR UPDATE isn't supported by mssql and sqlite.
+//
+//	recent := qb.Select("user_id").From("post").Where(qb.Gt("created", since))
+//	q := qb.With("active", recent).
+//		Select("u.id", "u.name").
+//		From("user u").
+//		Where(qb.In("u.id", qb.Select("user_id").From("active")), qb.Eq("u.status", 1)).
+//		OrderBy("u.id DESC").
+//		Limit(10)
+//	num, err := qb.Raw(ctx, o, q).QueryRows(&users)
+package qb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jialequ/android-sdk/client/orm"
+)
+
+// Builder builds a piece of sql with its args
+type Builder interface {
+	// Build return the sql and the args bound to its ? placeholders
+	Build() (string, []interface{})
+}
+
+// Raw return the RawSeter of the sql built by b
+func Raw(ctx context.Context, o orm.QueryExecutor, b Builder) orm.RawSeter {
+	sql, args := b.Build()
+	return o.RawWithCtx(ctx, sql, args...)
+}
+
+// sqlWriter collects the sql and args
+type sqlWriter struct {
+	buf  strings.Builder
+	args []interface{}
+}
+
+func (w *sqlWriter) write(s ...string) {
+	for _, v := range s {
+		w.buf.WriteString(v)
+	}
+}
+
+func (w *sqlWriter) build(b Builder) {
+	sql, args := b.Build()
+	w.buf.WriteString(sql)
+	w.args = append(w.args, args...)
+}
+
+// conds write the conds joined by AND after keyword, nothing is written without cond
+func (w *sqlWriter) conds(keyword string, conds []Builder) {
+	sql, args := And(conds...).Build()
+	if sql == "" {
+		return
+	}
+	w.write(" ", keyword, " ", sql)
+	w.args = append(w.args, args...)
+}
+
+type cte struct {
+	name string
+	q    Builder
+}
+
+type join struct {
+	kind  string
+	table Builder
+	on    Builder
+}
+
+type union struct {
+	all bool
+	q   Builder
+}
+
+// SelectBuilder builds SELECT statement
+type SelectBuilder struct {
+	recursive bool
+	ctes      []cte
+	distinct  bool
+	columns   []Builder
+	from      []Builder
+	joins     []join
+	where     []Builder
+	groupBy   []string
+	having    []Builder
+	unions    []union
+	orderBy   []string
+	limit     int
+	offset    int
+	forUpdate bool
+}
+
+// Select return a SelectBuilder of the columns
+func Select(cols ...string) *SelectBuilder {
+	return new(SelectBuilder).Select(cols...)
+}
+
+// With return a SelectBuilder with the common table expression: WITH name AS (q)
+func With(name string, q Builder) *SelectBuilder {
+	return new(SelectBuilder).With(name, q)
+}
+
+// WithRecursive return a SelectBuilder with the recursive common table expression
+func WithRecursive(name string, q Builder) *SelectBuilder {
+	return new(SelectBuilder).WithRecursive(name, q)
+}
+
+// With add the common table expression: WITH name AS (q),
+// name can have the column list, like "tree(id, parent_id)"
+func (b *SelectBuilder) With(name string, q Builder) *SelectBuilder {
+	b.ctes = append(b.ctes, cte{name: name, q: q})
+	return b
+}
+
+// WithRecursive add the common table expression and set WITH RECURSIVE
+func (b *SelectBuilder) WithRecursive(name string, q Builder) *SelectBuilder {
+	b.recursive = true
+	return b.With(name, q)
+}
+
+// Select add the columns
+func (b *SelectBuilder) Select(cols ...string) *SelectBuilder {
+	for _, col := range cols {
+		b.columns = append(b.columns, Expr(col))
+	}
+	return b
+}
+
+// SelectExpr add the column of expression, which can be a subquery
+func (b *SelectBuilder) SelectExpr(e Builder, alias string) *SelectBuilder {
+	if alias != "" {
+		e = Expr("? AS "+alias, e)
+	}
+	b.columns = append(b.columns, e)
+	return b
+}
+
+// Distinct set SELECT DISTINCT
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// From add the tables
+func (b *SelectBuilder) From(tables ...string) *SelectBuilder {
+	for _, table := range tables {
+		b.from = append(b.from, Expr(table))
+	}
+	return b
+}
+
+// FromSub add the subquery as table: (q) AS alias
+func (b *SelectBuilder) FromSub(q Builder, alias string) *SelectBuilder {
+	b.from = append(b.from, Expr("? AS "+alias, q))
+	return b
+}
+
+// Join add INNER JOIN table ON on
+func (b *SelectBuilder) Join(table string, on Builder) *SelectBuilder {
+	return b.addJoin("INNER JOIN", Expr(table), on)
+}
+
+// LeftJoin add LEFT JOIN table ON on
+func (b *SelectBuilder) LeftJoin(table string, on Builder) *SelectBuilder {
+	return b.addJoin("LEFT JOIN", Expr(table), on)
+}
+
+// RightJoin add RIGHT JOIN table ON on
+func (b *SelectBuilder) RightJoin(table string, on Builder) *SelectBuilder {
+	return b.addJoin("RIGHT JOIN", Expr(table), on)
+}
+
+// JoinSub add INNER JOIN (q) AS alias ON on
+func (b *SelectBuilder) JoinSub(q Builder, alias string, on Builder) *SelectBuilder {
+	return b.addJoin("INNER JOIN", Expr("? AS "+alias, q), on)
+}
+
+// LeftJoinSub add LEFT JOIN (q) AS alias ON on
+func (b *SelectBuilder) LeftJoinSub(q Builder, alias string, on Builder) *SelectBuilder {
+	return b.addJoin("LEFT JOIN", Expr("? AS "+alias, q), on)
+}
+
+func (b *SelectBuilder) addJoin(kind string, table Builder, on Builder) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: kind, table: table, on: on})
+	return b
+}
+
+// Where add the conds joined by AND
+func (b *SelectBuilder) Where(conds ...Builder) *SelectBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// GroupBy add the GROUP BY columns
+func (b *SelectBuilder) GroupBy(cols ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, cols...)
+	return b
+}
+
+// Having add the HAVING conds joined by AND
+func (b *SelectBuilder) Having(conds ...Builder) *SelectBuilder {
+	b.having = append(b.having, conds...)
+	return b
+}
+
+// Union add UNION q, the ORDER BY, LIMIT and OFFSET of b apply to the whole result
+func (b *SelectBuilder) Union(q Builder) *SelectBuilder {
+	b.unions = append(b.unions, union{q: q})
+	return b
+}
+
+// UnionAll add UNION ALL q
+func (b *SelectBuilder) UnionAll(q Builder) *SelectBuilder {
+	b.unions = append(b.unions, union{all: true, q: q})
+	return b
+}
+
+// OrderBy add the ORDER BY columns, like "id DESC"
+func (b *SelectBuilder) OrderBy(cols ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, cols...)
+	return b
+}
+
+// Limit set LIMIT, it's not set if limit <= 0.
+// mssql and oracle are not supported, see the package doc.
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset set OFFSET, it's not set if offset <= 0.
+// mssql and oracle are not supported, see the package doc.
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// ForUpdate set FOR UPDATE, which is not supported by mssql and sqlite, see the package doc.
+func (b *SelectBuilder) ForUpdate() *SelectBuilder {
+	b.forUpdate = true
+	return b
+}
+
+// Build implement Builder
+func (b *SelectBuilder) Build() (string, []interface{}) {
+	w := &sqlWriter{}
+
+	if len(b.ctes) > 0 {
+		w.write("WITH ")
+		if b.recursive {
+			w.write("RECURSIVE ")
+		}
+		for i, c := range b.ctes {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write(c.name, " AS ")
+			w.build(Expr("?", c.q))
+		}
+		w.write(" ")
+	}
+
+	w.write("SELECT ")
+	if b.distinct {
+		w.write("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.write("*")
+	}
+	for i, col := range b.columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.build(col)
+	}
+
+	for i, table := range b.from {
+		if i == 0 {
+			w.write(" FROM ")
+		} else {
+			w.write(", ")
+		}
+		w.build(table)
+	}
+
+	for _, j := range b.joins {
+		w.write(" ", j.kind, " ")
+		w.build(j.table)
+		if j.on != nil {
+			w.write(" ON ")
+			w.build(j.on)
+		}
+	}
+
+	w.conds("WHERE", b.where)
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ", strings.Join(b.groupBy, ", "))
+	}
+	w.conds("HAVING", b.having)
+
+	for _, u := range b.unions {
+		w.write(" UNION ")
+		if u.all {
+			w.write("ALL ")
+		}
+		w.build(u.q)
+	}
+
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ", strings.Join(b.orderBy, ", "))
+	}
+	if b.limit > 0 {
+		w.write(" LIMIT ", strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		w.write(" OFFSET ", strconv.Itoa(b.offset))
+	}
+	if b.forUpdate {
+		w.write(" FOR UPDATE")
+	}
+	return w.buf.String(), w.args
+}
+
+// InsertBuilder builds INSERT statement
+type InsertBuilder struct {
+	table   string
+	columns []string
+	rows    [][]interface{}
+	sel     Builder
+}
+
+// Insert return an InsertBuilder of table
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns set the columns to insert
+func (b *InsertBuilder) Columns(cols ...string) *InsertBuilder {
+	b.columns = append(b.columns, cols...)
+	return b
+}
+
+// Values add a row, it can be called multiple times to insert multiple rows.
+// A value which is a Builder is inlined, like qb.Expr("CURRENT_TIMESTAMP").
+func (b *InsertBuilder) Values(vals ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, vals)
+	return b
+}
+
+// FromSelect insert the rows selected by q instead of Values
+func (b *InsertBuilder) FromSelect(q Builder) *InsertBuilder {
+	b.sel = q
+	return b
+}
+
+// Build implement Builder
+func (b *InsertBuilder) Build() (string, []interface{}) {
+	w := &sqlWriter{}
+	w.write("INSERT INTO ", b.table)
+	if len(b.columns) > 0 {
+		w.write(" (", strings.Join(b.columns, ", "), ")")
+	}
+	if b.sel != nil {
+		w.write(" ")
+		w.build(b.sel)
+		return w.buf.String(), w.args
+	}
+	w.write(" VALUES ")
+	for i, row := range b.rows {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write("(")
+		for j, v := range row {
+			if j > 0 {
+				w.write(", ")
+			}
+			w.build(value(v))
+		}
+		w.write(")")
+	}
+	return w.buf.String(), w.args
+}
+
+// value return the Builder of v, a Builder is used as it is instead of a subquery
+func value(v interface{}) Builder {
+	if b, ok := v.(Builder); ok {
+		return b
+	}
+	return &expr{sql: "?", args: []interface{}{v}}
+}
+
+type assignment struct {
+	col string
+	val interface{}
+}
+
+// UpdateBuilder builds UPDATE statement
+type UpdateBuilder struct {
+	table string
+	sets  []assignment
+	where []Builder
+}
+
+// Update return an UpdateBuilder of table
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set add col = val, a val which is a Builder is inlined, like qb.Expr("count + ?", 1)
+func (b *UpdateBuilder) Set(col string, val interface{}) *UpdateBuilder {
+	b.sets = append(b.sets, assignment{col: col, val: val})
+	return b
+}
+
+// Where add the conds joined by AND
+func (b *UpdateBuilder) Where(conds ...Builder) *UpdateBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// Build implement Builder
+func (b *UpdateBuilder) Build() (string, []interface{}) {
+	w := &sqlWriter{}
+	w.write("UPDATE ", b.table, " SET ")
+	for i, s := range b.sets {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(s.col, " = ")
+		w.build(value(s.val))
+	}
+	w.conds("WHERE", b.where)
+	return w.buf.String(), w.args
+}
+
+// DeleteBuilder builds DELETE statement
+type DeleteBuilder struct {
+	table string
+	where []Builder
+}
+
+// Delete return a DeleteBuilder of table
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where add the conds joined by AND
+func (b *DeleteBuilder) Where(conds ...Builder) *DeleteBuilder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// Build implement Builder
+func (b *DeleteBuilder) Build() (string, []interface{}) {
+	w := &sqlWriter{}
+	w.write("DELETE FROM ", b.table)
+	w.conds("WHERE", b.where)
+	return w.buf.String(), w.args
+}
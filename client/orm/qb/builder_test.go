@@ -0,0 +1,208 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qb
+
+import (
+	"context"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jialequ/android-sdk/client/orm"
+)
+
+func TestExpr(t *testing.T) {
+	testCases := []struct {
+		name     string
+		b        Builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "args",
+			b:        Expr("age BETWEEN ? AND ?", 18, 30),
+			wantSQL:  "age BETWEEN ? AND ?",
+			wantArgs: []interface{}{18, 30},
+		},
+		{
+			name:     "slice",
+			b:        Expr("id IN ?", []int{1, 2}),
+			wantSQL:  "id IN (?, ?)",
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name:     "bytes",
+			b:        Expr("data = ?", []byte("ab")),
+			wantSQL:  "data = ?",
+			wantArgs: []interface{}{[]byte("ab")},
+		},
+		{
+			name:     "quoted",
+			b:        Expr("name = '?' AND id = ?", 1),
+			wantSQL:  "name = '?' AND id = ?",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "and or not",
+			b:        And(Eq("a", 1), Or(Gt("b", 2), IsNull("c")), nil, Not(Like("d", "x%"))),
+			wantSQL:  "(a = ? AND (b > ? OR c IS NULL) AND NOT (d LIKE ?))",
+			wantArgs: []interface{}{1, 2, "x%"},
+		},
+		{
+			name:     "in",
+			b:        And(In("a", 1, 2), NotIn("b", "x"), In("c"), NotIn("d")),
+			wantSQL:  "(a IN (?, ?) AND b NOT IN (?) AND 1 = 0 AND 1 = 1)",
+			wantArgs: []interface{}{1, 2, "x"},
+		},
+		{
+			name:     "in slice",
+			b:        And(In("a", []int{1, 2}), NotIn("b", []string{"x"}), In("c", []int{}), NotIn("d", []int{})),
+			wantSQL:  "(a IN (?, ?) AND b NOT IN (?) AND 1 = 0 AND 1 = 1)",
+			wantArgs: []interface{}{1, 2, "x"},
+		},
+		{
+			name:     "empty slice",
+			b:        Expr("status = ? AND id IN ? AND LOWER(name) not in ?", 1, []int{}, []string{}),
+			wantSQL:  "status = ? AND 1 = 0 AND 1 = 1",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name:     "in subquery",
+			b:        In("id", Select("user_id").From("post").Where(Eq("status", 1))),
+			wantSQL:  "id IN (SELECT user_id FROM post WHERE status = ?)",
+			wantArgs: []interface{}{1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args := tc.b.Build()
+			assert.Equal(t, tc.wantSQL, sql)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func TestSelectBuilder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		b        Builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "all",
+			b:       Select().From("user"),
+			wantSQL: "SELECT * FROM user",
+		},
+		{
+			name: "clauses",
+			b: Select("u.id", "COUNT(p.id) AS num").Distinct().From("user u").
+				LeftJoin("post p", Expr("p.user_id = u.id")).
+				Where(Eq("u.status", 1), Gte("u.age", 18)).
+				GroupBy("u.id").Having(Gt("COUNT(p.id)", 2)).
+				OrderBy("num DESC", "u.id").Limit(10).Offset(20).ForUpdate(),
+			wantSQL: "SELECT DISTINCT u.id, COUNT(p.id) AS num FROM user u LEFT JOIN post p ON p.user_id = u.id " +
+				"WHERE (u.status = ? AND u.age >= ?) GROUP BY u.id HAVING COUNT(p.id) > ? " +
+				"ORDER BY num DESC, u.id LIMIT 10 OFFSET 20 FOR UPDATE",
+			wantArgs: []interface{}{1, 18, 2},
+		},
+		{
+			name: "cte",
+			b: With("active", Select("id").From("user").Where(Eq("status", 1))).
+				Select("a.id").From("active a").Where(Gt("a.id", 10)),
+			wantSQL:  "WITH active AS (SELECT id FROM user WHERE status = ?) SELECT a.id FROM active a WHERE a.id > ?",
+			wantArgs: []interface{}{1, 10},
+		},
+		{
+			name: "recursive cte",
+			b: WithRecursive("tree(id, parent_id)",
+				Select("id", "parent_id").From("category").Where(Eq("id", 1)).
+					UnionAll(Select("c.id", "c.parent_id").From("category c").Join("tree t", Expr("c.parent_id = t.id")))).
+				Select("id").From("tree"),
+			wantSQL: "WITH RECURSIVE tree(id, parent_id) AS (SELECT id, parent_id FROM category WHERE id = ? " +
+				"UNION ALL SELECT c.id, c.parent_id FROM category c INNER JOIN tree t ON c.parent_id = t.id) SELECT id FROM tree",
+			wantArgs: []interface{}{1},
+		},
+		{
+			name: "union",
+			b: Select("name").From("user").Where(Eq("status", 1)).
+				Union(Select("name").From("admin").Where(Eq("status", 2))).
+				OrderBy("name").Limit(5),
+			wantSQL:  "SELECT name FROM user WHERE status = ? UNION SELECT name FROM admin WHERE status = ? ORDER BY name LIMIT 5",
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name: "subqueries",
+			b: Select("t.id").
+				SelectExpr(Select("COUNT(*)").From("post p").Where(Expr("p.user_id = t.id"), Eq("p.status", 1)), "posts").
+				FromSub(Select("id").From("user").Where(Eq("age", 18)), "t").
+				JoinSub(Select("user_id").From("profile").Where(Eq("vip", true)), "v", Expr("v.user_id = t.id")).
+				Where(Exists(Select("1").From("login l").Where(Expr("l.user_id = t.id"), Gt("l.at", "2021")))),
+			wantSQL: "SELECT t.id, (SELECT COUNT(*) FROM post p WHERE (p.user_id = t.id AND p.status = ?)) AS posts " +
+				"FROM (SELECT id FROM user WHERE age = ?) AS t " +
+				"INNER JOIN (SELECT user_id FROM profile WHERE vip = ?) AS v ON v.user_id = t.id " +
+				"WHERE EXISTS (SELECT 1 FROM login l WHERE (l.user_id = t.id AND l.at > ?))",
+			wantArgs: []interface{}{1, 18, true, "2021"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args := tc.b.Build()
+			assert.Equal(t, tc.wantSQL, sql)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func TestDMLBuilder(t *testing.T) {
+	sql, args := Insert("user").Columns("name", "age", "created").
+		Values("a", 1, Expr("CURRENT_TIMESTAMP")).Values("b", 2, Expr("CURRENT_TIMESTAMP")).Build()
+	assert.Equal(t, "INSERT INTO user (name, age, created) VALUES (?, ?, CURRENT_TIMESTAMP), (?, ?, CURRENT_TIMESTAMP)", sql)
+	assert.Equal(t, []interface{}{"a", 1, "b", 2}, args)
+
+	sql, args = Insert("archive").Columns("id").FromSelect(Select("id").From("user").Where(Lt("age", 10))).Build()
+	assert.Equal(t, "INSERT INTO archive (id) SELECT id FROM user WHERE age < ?", sql)
+	assert.Equal(t, []interface{}{10}, args)
+
+	sql, args = Update("user").Set("name", "x").Set("num", Expr("num + ?", 1)).Where(Eq("id", 3)).Build()
+	assert.Equal(t, "UPDATE user SET name = ?, num = num + ? WHERE id = ?", sql)
+	assert.Equal(t, []interface{}{"x", 1, 3}, args)
+
+	sql, args = Delete("user").Where(In("id", Select("user_id").From("ban"))).Build()
+	assert.Equal(t, "DELETE FROM user WHERE id IN (SELECT user_id FROM ban)", sql)
+	assert.Empty(t, args)
+}
+
+func TestRaw(t *testing.T) {
+	err := orm.RegisterDataBase("default", "sqlite3", "file:qb_test?mode=memory&cache=shared")
+	assert.Nil(t, err)
+	o := orm.NewOrm()
+	ctx := context.Background()
+
+	_, err = o.Raw("CREATE TABLE qb_user (id integer NOT NULL PRIMARY KEY, name varchar(32), age integer)").Exec()
+	assert.Nil(t, err)
+	_, err = Raw(ctx, o, Insert("qb_user").Columns("id", "name", "age").
+		Values(1, "a", 10).Values(2, "b'; DROP TABLE qb_user; --", 20).Values(3, "c", 30)).Exec()
+	assert.Nil(t, err)
+
+	var names []string
+	q := With("adult", Select("id", "name").From("qb_user").Where(Gte("age", 18))).
+		Select("name").From("adult").Where(In("id", 2, 3)).OrderBy("id")
+	num, err := Raw(ctx, o, q).QueryRows(&names)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), num)
+	assert.Equal(t, []string{"b'; DROP TABLE qb_user; --", "c"}, names)
+}
@@ -0,0 +1,248 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qb
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// expr is a piece of sql, the ? in sql are bound to args
+type expr struct {
+	sql  string
+	args []interface{}
+}
+
+// Expr return a piece of sql with the args bound to its ? placeholders.
+// An arg which is a Builder is inlined as a parenthesized subquery,
+// and an arg which is a slice is expanded to (?, ?, ...), except []byte.
+// "col IN ?" with an empty slice becomes 1 = 0, and "col NOT IN ?" becomes 1 = 1.
+// for example:
+//
+//	qb.Expr("age BETWEEN ? AND ?", 18, 30)
+//	qb.Expr("id IN ?", []int{1, 2, 3})
+//	qb.Expr("id IN ?", qb.Select("user_id").From("post"))
+func Expr(sql string, args ...interface{}) Builder {
+	return &expr{sql: sql, args: args}
+}
+
+// Build implement Builder
+func (e *expr) Build() (string, []interface{}) {
+	buf := &strings.Builder{}
+	var args []interface{}
+	i := 0
+	inQuote := false
+	for _, r := range e.sql {
+		if r == '\'' {
+			inQuote = !inQuote
+		}
+		if r != '?' || inQuote || i >= len(e.args) {
+			buf.WriteRune(r)
+			continue
+		}
+		if isEmptySlice(e.args[i]) {
+			if cond, ok := emptyIn(buf.String()); ok {
+				buf.Reset()
+				buf.WriteString(cond)
+				i++
+				continue
+			}
+		}
+		args = writeArg(buf, args, e.args[i])
+		i++
+	}
+	return buf.String(), args
+}
+
+// inOperand matches the tail "col IN " or "col NOT IN " of sql
+var inOperand = regexp.MustCompile(`(?i)([^\s?()]+|\w+\([^?()]*\))\s+(NOT\s+)?IN\s*$`)
+
+// emptyIn replace the tail "col IN " of sql by 1 = 0, or "col NOT IN " by 1 = 1,
+// as IN () is not valid sql
+func emptyIn(sql string) (string, bool) {
+	m := inOperand.FindStringSubmatchIndex(sql)
+	if m == nil {
+		return "", false
+	}
+	if m[4] >= 0 {
+		return sql[:m[0]] + "1 = 1", true
+	}
+	return sql[:m[0]] + "1 = 0", true
+}
+
+// isSlice report whether arg is expanded to (?, ?, ...)
+func isSlice(arg interface{}) bool {
+	val := reflect.ValueOf(arg)
+	return val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8
+}
+
+func isEmptySlice(arg interface{}) bool {
+	return isSlice(arg) && reflect.ValueOf(arg).Len() == 0
+}
+
+// writeArg write the placeholder of arg and collect its args
+func writeArg(buf *strings.Builder, args []interface{}, arg interface{}) []interface{} {
+	if b, ok := arg.(Builder); ok {
+		sql, subArgs := b.Build()
+		buf.WriteString("(")
+		buf.WriteString(sql)
+		buf.WriteString(")")
+		return append(args, subArgs...)
+	}
+
+	if isSlice(arg) {
+		val := reflect.ValueOf(arg)
+		buf.WriteString("(")
+		for j := 0; j < val.Len(); j++ {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString("?")
+			args = append(args, val.Index(j).Interface())
+		}
+		buf.WriteString(")")
+		return args
+	}
+
+	buf.WriteString("?")
+	return append(args, arg)
+}
+
+// Eq return col = v
+func Eq(col string, v interface{}) Builder {
+	return Expr(col+" = ?", v)
+}
+
+// Neq return col <> v
+func Neq(col string, v interface{}) Builder {
+	return Expr(col+" <> ?", v)
+}
+
+// Gt return col > v
+func Gt(col string, v interface{}) Builder {
+	return Expr(col+" > ?", v)
+}
+
+// Gte return col >= v
+func Gte(col string, v interface{}) Builder {
+	return Expr(col+" >= ?", v)
+}
+
+// Lt return col < v
+func Lt(col string, v interface{}) Builder {
+	return Expr(col+" < ?", v)
+}
+
+// Lte return col <= v
+func Lte(col string, v interface{}) Builder {
+	return Expr(col+" <= ?", v)
+}
+
+// Like return col LIKE v, the wildcards in v are not escaped
+func Like(col string, v interface{}) Builder {
+	return Expr(col+" LIKE ?", v)
+}
+
+// IsNull return col IS NULL
+func IsNull(col string) Builder {
+	return Expr(col + " IS NULL")
+}
+
+// IsNotNull return col IS NOT NULL
+func IsNotNull(col string) Builder {
+	return Expr(col + " IS NOT NULL")
+}
+
+// In return col IN (vals), vals can be a single subquery or a single slice.
+// It's always false if vals is empty.
+func In(col string, vals ...interface{}) Builder {
+	if len(vals) == 1 {
+		if sub, ok := vals[0].(Builder); ok {
+			return Expr(col+" IN ?", sub)
+		}
+		if isSlice(vals[0]) {
+			return Expr(col+" IN ?", vals[0])
+		}
+	}
+	if len(vals) == 0 {
+		return Expr("1 = 0")
+	}
+	return Expr(col+" IN ?", vals)
+}
+
+// NotIn return col NOT IN (vals), vals can be a single subquery or a single slice.
+// It's always true if vals is empty.
+func NotIn(col string, vals ...interface{}) Builder {
+	if len(vals) == 1 {
+		if sub, ok := vals[0].(Builder); ok {
+			return Expr(col+" NOT IN ?", sub)
+		}
+		if isSlice(vals[0]) {
+			return Expr(col+" NOT IN ?", vals[0])
+		}
+	}
+	if len(vals) == 0 {
+		return Expr("1 = 1")
+	}
+	return Expr(col+" NOT IN ?", vals)
+}
+
+// Exists return EXISTS (sub)
+func Exists(sub Builder) Builder {
+	return Expr("EXISTS ?", sub)
+}
+
+// Not return NOT (cond)
+func Not(cond Builder) Builder {
+	return Expr("NOT ?", cond)
+}
+
+// And join conds with AND, the nil ones are skipped
+func And(conds ...Builder) Builder {
+	return &joined{sep: " AND ", conds: conds}
+}
+
+// Or join conds with OR, the nil ones are skipped
+func Or(conds ...Builder) Builder {
+	return &joined{sep: " OR ", conds: conds}
+}
+
+// joined is the conds joined by sep, it's parenthesized if there are more than one
+type joined struct {
+	sep   string
+	conds []Builder
+}
+
+// Build implement Builder
+func (j *joined) Build() (string, []interface{}) {
+	parts := make([]string, 0, len(j.conds))
+	var args []interface{}
+	for _, c := range j.conds {
+		if c == nil {
+			continue
+		}
+		sql, cArgs := c.Build()
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, sql)
+		args = append(args, cArgs...)
+	}
+	if len(parts) <= 1 {
+		return strings.Join(parts, ""), args
+	}
+	return "(" + strings.Join(parts, j.sep) + ")", args
+}
@@ -0,0 +1,188 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+import "fmt"
+
+// MsSQLQueryBuilder is the SQL build, the syntax is the same as postgres except the paging and FOR UPDATE.
+// The LIMIT and OFFSET are written as OFFSET/FETCH at the end of the query, which is ordered by
+// the first column if there is no ORDER BY, as SQL Server requires.
+type MsSQLQueryBuilder struct {
+	PostgresQueryBuilder
+	limit  string
+	offset string
+}
+
+// Select will join the Fields
+func (qb *MsSQLQueryBuilder) Select(fields ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.Select(fields...)
+	return qb
+}
+
+// From join the tables
+func (qb *MsSQLQueryBuilder) From(tables ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.From(tables...)
+	return qb
+}
+
+// InnerJoin INNER JOIN the table
+func (qb *MsSQLQueryBuilder) InnerJoin(table string) QueryBuilder {
+	qb.PostgresQueryBuilder.InnerJoin(table)
+	return qb
+}
+
+// LeftJoin LEFT JOIN the table
+func (qb *MsSQLQueryBuilder) LeftJoin(table string) QueryBuilder {
+	qb.PostgresQueryBuilder.LeftJoin(table)
+	return qb
+}
+
+// RightJoin RIGHT JOIN the table
+func (qb *MsSQLQueryBuilder) RightJoin(table string) QueryBuilder {
+	qb.PostgresQueryBuilder.RightJoin(table)
+	return qb
+}
+
+// On join with on cond
+func (qb *MsSQLQueryBuilder) On(cond string) QueryBuilder {
+	qb.PostgresQueryBuilder.On(cond)
+	return qb
+}
+
+// Where join the Where cond
+func (qb *MsSQLQueryBuilder) Where(cond string) QueryBuilder {
+	qb.PostgresQueryBuilder.Where(cond)
+	return qb
+}
+
+// And join the and cond
+func (qb *MsSQLQueryBuilder) And(cond string) QueryBuilder {
+	qb.PostgresQueryBuilder.And(cond)
+	return qb
+}
+
+// Or join the or cond
+func (qb *MsSQLQueryBuilder) Or(cond string) QueryBuilder {
+	qb.PostgresQueryBuilder.Or(cond)
+	return qb
+}
+
+// In join the IN (vals)
+func (qb *MsSQLQueryBuilder) In(vals ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.In(vals...)
+	return qb
+}
+
+// OrderBy join the Order by Fields
+func (qb *MsSQLQueryBuilder) OrderBy(fields ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.OrderBy(fields...)
+	return qb
+}
+
+// Asc join the asc
+func (qb *MsSQLQueryBuilder) Asc() QueryBuilder {
+	qb.PostgresQueryBuilder.Asc()
+	return qb
+}
+
+// Desc join the desc
+func (qb *MsSQLQueryBuilder) Desc() QueryBuilder {
+	qb.PostgresQueryBuilder.Desc()
+	return qb
+}
+
+// GroupBy join the Group by Fields
+func (qb *MsSQLQueryBuilder) GroupBy(fields ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.GroupBy(fields...)
+	return qb
+}
+
+// Having join the Having cond
+func (qb *MsSQLQueryBuilder) Having(cond string) QueryBuilder {
+	qb.PostgresQueryBuilder.Having(cond)
+	return qb
+}
+
+// Update join the update table
+func (qb *MsSQLQueryBuilder) Update(tables ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.Update(tables...)
+	return qb
+}
+
+// Set join the Set kv
+func (qb *MsSQLQueryBuilder) Set(kv ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.Set(kv...)
+	return qb
+}
+
+// Delete join the Delete tables
+func (qb *MsSQLQueryBuilder) Delete(tables ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.Delete(tables...)
+	return qb
+}
+
+// InsertInto join the insert SQL
+func (qb *MsSQLQueryBuilder) InsertInto(table string, fields ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.InsertInto(table, fields...)
+	return qb
+}
+
+// Values join the Values(vals)
+func (qb *MsSQLQueryBuilder) Values(vals ...string) QueryBuilder {
+	qb.PostgresQueryBuilder.Values(vals...)
+	return qb
+}
+
+// Limit set the num of FETCH NEXT
+func (qb *MsSQLQueryBuilder) Limit(limit int) QueryBuilder {
+	qb.limit = fmt.Sprintf("FETCH NEXT %d ROWS ONLY", limit)
+	return qb
+}
+
+// Offset set the num of OFFSET
+func (qb *MsSQLQueryBuilder) Offset(offset int) QueryBuilder {
+	qb.offset = fmt.Sprintf("OFFSET %d ROWS", offset)
+	return qb
+}
+
+// ForUpdate is ignored as SQL Server locks rows by table hints instead of SELECT FOR UPDATE query
+func (qb *MsSQLQueryBuilder) ForUpdate() QueryBuilder {
+	DebugLog.Println("[WARN] SQL Server does not support SELECT FOR UPDATE query, ForUpdate is ignored")
+	return qb
+}
+
+// String join All tokens and the OFFSET/FETCH
+func (qb *MsSQLQueryBuilder) String() string {
+	if qb.limit != "" || qb.offset != "" {
+		ordered := false
+		for _, token := range qb.tokens {
+			if token == "ORDER BY" {
+				ordered = true
+			}
+		}
+		if !ordered {
+			qb.tokens = append(qb.tokens, "ORDER BY 1")
+		}
+		if qb.offset == "" {
+			qb.offset = "OFFSET 0 ROWS"
+		}
+		qb.tokens = append(qb.tokens, qb.offset)
+		if qb.limit != "" {
+			qb.tokens = append(qb.tokens, qb.limit)
+		}
+		qb.limit, qb.offset = "", ""
+	}
+	return qb.PostgresQueryBuilder.String()
+}
@@ -0,0 +1,159 @@
+// Copyright 2014 beego Author. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orm
+
+// SQLiteQueryBuilder is the SQL build, the syntax is the same as mysql except FOR UPDATE.
+// The methods are wrapped so that the chained calls keep using SQLiteQueryBuilder.
+type SQLiteQueryBuilder struct {
+	MySQLQueryBuilder
+}
+
+// Select will join the Fields
+func (qb *SQLiteQueryBuilder) Select(fields ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.Select(fields...)
+	return qb
+}
+
+// From join the tables
+func (qb *SQLiteQueryBuilder) From(tables ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.From(tables...)
+	return qb
+}
+
+// InnerJoin INNER JOIN the table
+func (qb *SQLiteQueryBuilder) InnerJoin(table string) QueryBuilder {
+	qb.MySQLQueryBuilder.InnerJoin(table)
+	return qb
+}
+
+// LeftJoin LEFT JOIN the table
+func (qb *SQLiteQueryBuilder) LeftJoin(table string) QueryBuilder {
+	qb.MySQLQueryBuilder.LeftJoin(table)
+	return qb
+}
+
+// RightJoin RIGHT JOIN the table
+func (qb *SQLiteQueryBuilder) RightJoin(table string) QueryBuilder {
+	qb.MySQLQueryBuilder.RightJoin(table)
+	return qb
+}
+
+// On join with on cond
+func (qb *SQLiteQueryBuilder) On(cond string) QueryBuilder {
+	qb.MySQLQueryBuilder.On(cond)
+	return qb
+}
+
+// Where join the Where cond
+func (qb *SQLiteQueryBuilder) Where(cond string) QueryBuilder {
+	qb.MySQLQueryBuilder.Where(cond)
+	return qb
+}
+
+// And join the and cond
+func (qb *SQLiteQueryBuilder) And(cond string) QueryBuilder {
+	qb.MySQLQueryBuilder.And(cond)
+	return qb
+}
+
+// Or join the or cond
+func (qb *SQLiteQueryBuilder) Or(cond string) QueryBuilder {
+	qb.MySQLQueryBuilder.Or(cond)
+	return qb
+}
+
+// In join the IN (vals)
+func (qb *SQLiteQueryBuilder) In(vals ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.In(vals...)
+	return qb
+}
+
+// OrderBy join the Order by Fields
+func (qb *SQLiteQueryBuilder) OrderBy(fields ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.OrderBy(fields...)
+	return qb
+}
+
+// Asc join the asc
+func (qb *SQLiteQueryBuilder) Asc() QueryBuilder {
+	qb.MySQLQueryBuilder.Asc()
+	return qb
+}
+
+// Desc join the desc
+func (qb *SQLiteQueryBuilder) Desc() QueryBuilder {
+	qb.MySQLQueryBuilder.Desc()
+	return qb
+}
+
+// Limit join the limit num
+func (qb *SQLiteQueryBuilder) Limit(limit int) QueryBuilder {
+	qb.MySQLQueryBuilder.Limit(limit)
+	return qb
+}
+
+// Offset join the offset num
+func (qb *SQLiteQueryBuilder) Offset(offset int) QueryBuilder {
+	qb.MySQLQueryBuilder.Offset(offset)
+	return qb
+}
+
+// GroupBy join the Group by Fields
+func (qb *SQLiteQueryBuilder) GroupBy(fields ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.GroupBy(fields...)
+	return qb
+}
+
+// Having join the Having cond
+func (qb *SQLiteQueryBuilder) Having(cond string) QueryBuilder {
+	qb.MySQLQueryBuilder.Having(cond)
+	return qb
+}
+
+// Update join the update table
+func (qb *SQLiteQueryBuilder) Update(tables ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.Update(tables...)
+	return qb
+}
+
+// Set join the Set kv
+func (qb *SQLiteQueryBuilder) Set(kv ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.Set(kv...)
+	return qb
+}
+
+// Delete join the Delete tables
+func (qb *SQLiteQueryBuilder) Delete(tables ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.Delete(tables...)
+	return qb
+}
+
+// InsertInto join the insert SQL
+func (qb *SQLiteQueryBuilder) InsertInto(table string, fields ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.InsertInto(table, fields...)
+	return qb
+}
+
+// Values join the Values(vals)
+func (qb *SQLiteQueryBuilder) Values(vals ...string) QueryBuilder {
+	qb.MySQLQueryBuilder.Values(vals...)
+	return qb
+}
+
+// ForUpdate is ignored as SQLite does not support SELECT FOR UPDATE query
+func (qb *SQLiteQueryBuilder) ForUpdate() QueryBuilder {
+	DebugLog.Println("[WARN] SQLite does not support SELECT FOR UPDATE query, ForUpdate is ignored")
+	return qb
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/jialequ/android-sdk/client/orm/internal/models"
 
+	"github.com/jialequ/android-sdk/client/orm/clauses/expr"
 	"github.com/jialequ/android-sdk/client/orm/clauses/order_clause"
 	"github.com/jialequ/android-sdk/core/utils"
 )
@@ -86,6 +87,62 @@ type TableUniqueI interface {
 	TableUnique() [][]string
 }
 
+// BeforeInsertI is usually used by model
+// when you want to do something before the model is inserted, like setting the created time.
+// The hooks receive the QueryExecutor which executes the operation,
+// so the queries of hooks are in the same transaction when the operation is called by TxOrmer.
+// Insert and Update of Ormer run the operation and its hooks in a new transaction,
+// so the operation is rolled back if any hook returns error.
+// If a Before hook returns error, the operation is aborted and the error is returned.
+// If an After hook returns error, the error is returned after the operation is done.
+// for example:
+//
+//	type User struct {
+//	  ...
+//	}
+//
+//	func (u *User) BeforeInsert(ctx context.Context, o orm.QueryExecutor) error {
+//	   u.Created = time.Now()
+//	   return nil
+//	}
+//
+// The hooks are called by Insert, InsertMulti, InsertOrUpdate, ReadOrCreate and Inserter.
+// InsertOrUpdate calls the insert hooks whether the row is inserted or updated.
+type BeforeInsertI interface {
+	BeforeInsert(ctx context.Context, o QueryExecutor) error
+}
+
+// AfterInsertI is called after the model is inserted, see BeforeInsertI
+type AfterInsertI interface {
+	AfterInsert(ctx context.Context, o QueryExecutor) error
+}
+
+// BeforeUpdateI is called before the model is updated by Update, see BeforeInsertI
+type BeforeUpdateI interface {
+	BeforeUpdate(ctx context.Context, o QueryExecutor) error
+}
+
+// AfterUpdateI is called after the model is updated by Update, see BeforeInsertI
+type AfterUpdateI interface {
+	AfterUpdate(ctx context.Context, o QueryExecutor) error
+}
+
+// BeforeDeleteI is called before the model is deleted by Delete, see BeforeInsertI
+type BeforeDeleteI interface {
+	BeforeDelete(ctx context.Context, o QueryExecutor) error
+}
+
+// AfterDeleteI is called after the model is deleted by Delete, see BeforeInsertI
+type AfterDeleteI interface {
+	AfterDelete(ctx context.Context, o QueryExecutor) error
+}
+
+// AfterReadI is called after the model is read by Read, ReadForUpdate, ReadOrCreate,
+// and each model read by QuerySeter.All and QuerySeter.One, see BeforeInsertI
+type AfterReadI interface {
+	AfterRead(ctx context.Context, o QueryExecutor) error
+}
+
 // IsApplicableTableForDB if return false, we won't create table to this db
 type IsApplicableTableForDB interface {
 	IsApplicableTableForDB(db string) bool
@@ -111,8 +168,29 @@ type TxBeginner interface {
 	DoTxWithCtx(ctx context.Context, task func(ctx context.Context, txOrm TxOrmer) error) error
 	DoTxWithOpts(opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error
 	DoTxWithCtxAndOpts(ctx context.Context, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error
+	// DoTxWithPropagation run the task by propagation when it's called in a transaction,
+	// or in a new transaction with opts if it's not. The opts are not used to join or nest in the transaction.
+	// for example:
+	//	// the task of library code can roll back only its part
+	//	err := txOrm.DoTxWithPropagation(ctx, orm.PropagationNested, nil, func(ctx context.Context, txOrm orm.TxOrmer) error {
+	//		_, err := txOrm.Insert(&log)
+	//		return err
+	//	})
+	DoTxWithPropagation(ctx context.Context, propagation Propagation, opts *sql.TxOptions, task func(ctx context.Context, txOrm TxOrmer) error) error
 }
 
+// Propagation decides how DoTxWithPropagation runs the task when it's called in a transaction
+type Propagation int
+
+const (
+	// PropagationRequired runs the task in the transaction, the error of task is returned to the caller who decides to roll back or not
+	PropagationRequired Propagation = iota
+	// PropagationRequiresNew runs the task in a new transaction of another connection, which is committed or rolled back by itself
+	PropagationRequiresNew
+	// PropagationNested runs the task in a nested transaction by SAVEPOINT, only the part of the task is rolled back if it fails
+	PropagationNested
+)
+
 type TxCommitter interface {
 	txEnder
 }
@@ -155,11 +233,26 @@ type DML interface {
 	// if colu type is integer : can use(+-*/), string : convert(colu,"value")
 	// postgres: InsertOrUpdate(model,"conflictColumnName") or InsertOrUpdate(model,"conflictColumnName","colu=colu+value")
 	// if colu type is integer : can use(+-*/), string : colu || "value"
+	// the version field is not checked, the version of the existing row is increased by 1
 	InsertOrUpdate(md interface{}, colConflitAndArgs ...string) (int64, error)
 	InsertOrUpdateWithCtx(ctx context.Context, md interface{}, colConflitAndArgs ...string) (int64, error)
 	// InsertMulti inserts some models to database
+	// the generated pk and other columns are set back into the models on postgres and sqlite,
+	// which is done only if bulk is 1 or the pk isn't auto, because the order of the returned rows isn't guaranteed.
 	InsertMulti(bulk int, mds interface{}) (int64, error)
 	InsertMultiWithCtx(ctx context.Context, bulk int, mds interface{}) (int64, error)
+	// InsertMultiOrUpdate inserts some models to database, and updates the rows that conflict with the existing rows.
+	// conflictCols and updateCols are the names of fields or columns,
+	// the updated columns are all inserted columns except the pk, auto_now_add and conflict columns if updateCols is empty.
+	// the version of the existing row is increased by 1.
+	// for example:
+	//  num, err = Ormer.InsertMultiOrUpdate(100, users, []string{"UserName"}, []string{"Email", "Status"})
+	// mysql: the rows conflicted on any unique key are updated, so that conflictCols is not used
+	// postgres and sqlite: ON CONFLICT (conflictCols) DO UPDATE, and the models are filled by RETURNING
+	// matched on conflictCols
+	// other drivers, like mssql and oracle, are not supported and an error is returned
+	InsertMultiOrUpdate(bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error)
+	InsertMultiOrUpdateWithCtx(ctx context.Context, bulk int, mds interface{}, conflictCols []string, updateCols []string) (int64, error)
 	// Update updates model to database.
 	// cols Set the Columns those want to update.
 	// find model by Id(pk) field and update Columns specified by Fields, if cols is null then update All Columns
@@ -169,11 +262,20 @@ type DML interface {
 	//	user.Extra.Name = "beego"
 	//	user.Extra.Data = "orm"
 	//	num, err = Ormer.Update(&user, "Langs", "Extra")
+	// if the model has a field with tag `orm:"version"`, the row is updated only if its version
+	// equals to the field, and the version is increased by 1 whatever cols is.
+	// ErrStaleObject is returned if the row was updated or deleted since the model was read.
+	// if the model has a soft_delete field, the soft deleted row is not updated,
+	// use QuerySeter.Unscoped().Update to update or restore it.
 	Update(md interface{}, cols ...string) (int64, error)
 	UpdateWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error)
 	// Delete deletes model in database
+	// if the model has a soft_delete field, the field is set to the current time instead.
 	Delete(md interface{}, cols ...string) (int64, error)
 	DeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error)
+	// HardDelete deletes model in database even if it has a soft_delete field
+	HardDelete(md interface{}, cols ...string) (int64, error)
+	HardDeleteWithCtx(ctx context.Context, md interface{}, cols ...string) (int64, error)
 
 	// Raw return a raw query seter for raw sql string.
 	// for example:
@@ -202,6 +304,8 @@ type DQL interface {
 	ReadForUpdateWithCtx(ctx context.Context, md interface{}, cols ...string) error
 
 	// ReadOrCreate Try to read a row from the database, or insert one if it doesn't exist
+	// if the model has a soft_delete field, the soft deleted row is not read like Read,
+	// so a new row is inserted, use QuerySeter.Unscoped() to find the soft deleted row.
 	ReadOrCreate(md interface{}, col1 string, cols ...string) (bool, int64, error)
 	ReadOrCreateWithCtx(ctx context.Context, md interface{}, col1 string, cols ...string) (bool, int64, error)
 
@@ -260,9 +364,12 @@ type Ormer interface {
 	TxBeginner
 }
 
+// TxOrmer is the Ormer in a transaction, its Begin and DoTx begin the nested transactions by SAVEPOINT,
+// which is supported by mysql, postgres, sqlite and tidb.
 type TxOrmer interface {
 	QueryExecutor
 	TxCommitter
+	TxBeginner
 }
 
 // Inserter insert prepared statement
@@ -282,6 +389,13 @@ type QuerySeter interface {
 	//	Filter("profile__Age", 28)
 	// 	 // time compare
 	//	qs.Filter("created", time.Now())
+	//	 // the value at path of json field, the path follows json, digits are array index
+	//	qs.Filter("attrs__json__color", "red")
+	//	qs.Filter("attrs__json__tags__0__icontains", "go")
+	//	 // the json contains the arg encoded by encoding/json, sqlite only supports scalar arg
+	//	qs.Filter("attrs__json__tags__json_contains", "go")
+	//	 // compare with an expression, the operator is one of exact, ne, gt, gte, lt and lte
+	//	qs.Filter("score__gt", expr.Mul(expr.Col("bonus"), 2))
 	Filter(string, ...interface{}) QuerySeter
 	// FilterRaw add raw sql to querySeter.
 	// for example:
@@ -308,6 +422,11 @@ type QuerySeter interface {
 	//  //sql-> WHERE T0.`profile_id` IS NOT NULL AND NOT T0.`Status` IN (?) OR T1.`age` >  2000
 	//  num, err := qs.SetCond(cond).Count()
 	GetCond() *Condition
+	// Unscoped include the soft deleted records of the model which has a soft_delete field,
+	// Delete of the returned QuerySeter removes the records for real.
+	// for example:
+	//  num, err := qs.Unscoped().Filter("deleted_at__isnull", false).Count()
+	Unscoped() QuerySeter
 	// Limit add LIMIT value.
 	// args[0] means offset, e.g. LIMIT num,offset.
 	// if Limit <= 0 then Limit will be Set to default limit ,eg 1000
@@ -349,6 +468,10 @@ type QuerySeter interface {
 	//		order_clause.SortNone(),//default None
 	//		order_clause.Raw(),//default false.if true, do not check field is valid or not
 	//	))
+	//	OrderClauses(order_clause.Clause(
+	//		order_clause.Expr(expr.Coalesce(expr.Col("nick"), expr.Col("name"))),
+	//		order_clause.SortAscending(),
+	//	))
 	OrderClauses(orders ...*order_clause.Order) QuerySeter
 	// ForceIndex add FORCE INDEX expression.
 	// for example:
@@ -374,6 +497,17 @@ type QuerySeter interface {
 	//	qs.RelatedSel("profile").One(&user)
 	//	user.Profile.Age = 32
 	RelatedSel(params ...interface{}) QuerySeter
+	// Preload load the relations of the models after All and One, one IN (...) query for each relation
+	// instead of one query for each model, the reverse and m2m relations are supported.
+	// for example:
+	//	// load the posts of users and the tags of the posts
+	//	qs.Preload("Posts", "Posts__Tags").All(&users)
+	//	users[0].Posts[0].Tags[0].Name
+	Preload(names ...string) QuerySeter
+	// PreloadWith preload the relation whose models are filtered by cond and ordered by orders.
+	// for example:
+	//	qs.PreloadWith("Posts", orm.NewCondition().And("title__startswith", "go"), "-created").All(&users)
+	PreloadWith(name string, cond *Condition, orders ...string) QuerySeter
 	// Distinct Set Distinct
 	// for example:
 	//  o.QueryTable("policy").Filter("Groups__Group__Users__User", user).
@@ -401,6 +535,10 @@ type QuerySeter interface {
 	//	num, err = qs.Filter("UserName", "slene").Update(Params{
 	//		"user_name": "slene2"
 	//	}) // user slene's  name will change to slene2
+	//	num, err = qs.Update(Params{
+	//		"score": expr.Add(expr.Mul(expr.Col("score"), 0.9), expr.Col("bonus")),
+	//	}) // set the score by the expression of the columns
+	// the version field of the model, if any, is increased by 1 unless it's in values
 	Update(values Params) (int64, error)
 	UpdateWithCtx(ctx context.Context, values Params) (int64, error)
 	// Delete delete from table
@@ -485,6 +623,29 @@ type QuerySeter interface {
 	// var res []result
 	//  o.QueryTable("dept_info").Aggregate("dept_name,sum(salary) as total").GroupBy("dept_name").All(&res)
 	Aggregate(s string) QuerySeter
+	// Annotate add the computed column name to the results of Values, ValuesList and ValuesFlat.
+	// The annotation is in the results of all fields, or it can be selected by name like a field.
+	// for example:
+	//	qs.Annotate("total", expr.Add(expr.Col("score"), expr.Coalesce(expr.Col("bonus"), 0))).
+	//		Values(&maps, "name", "total")
+	Annotate(name string, e expr.Expr) QuerySeter
+	// Iterate query data and return a RowIterator that reads one row at a time,
+	// instead of loading the whole result into memory like All.
+	// cols means the Columns when querying.
+	// for example:
+	//	it, err := qs.Iterate(ctx)
+	//	if err != nil {
+	//		return err
+	//	}
+	//	defer it.Close()
+	//	for it.Next() {
+	//		var user User
+	//		if err := it.Scan(&user); err != nil {
+	//			return err
+	//		}
+	//	}
+	//	err = it.Err()
+	Iterate(ctx context.Context, cols ...string) (RowIterator, error)
 }
 
 // QueryM2Mer model to model query struct
@@ -527,6 +688,19 @@ type RawPreparer interface {
 	Close() error
 }
 
+// RowIterator streams the rows of a query.
+// It must be closed when it's no longer used, Close is safe to call more than once.
+type RowIterator interface {
+	// Next prepares the next row for Scan, it returns false when there are no more rows or an error occurred.
+	Next() bool
+	// Scan map the current row to containers.
+	Scan(containers ...interface{}) error
+	// Err returns the error, if any, that was encountered during iteration.
+	Err() error
+	// Close the underlying rows.
+	Close() error
+}
+
 // RawSeter raw query seter
 // create From Ormer.Raw
 // for example:
@@ -581,6 +755,16 @@ type RawSeter interface {
 	// 	Found int
 	// }
 	RowsToStruct(ptrStruct interface{}, keyCol, valueCol string) (int64, error)
+	// Iterate query data and return a RowIterator that reads one row at a time.
+	// Scan accepts the same containers as QueryRow.
+	// for example:
+	//	it, err := dORM.Raw("SELECT id, name FROM user").Iterate(ctx)
+	//	for it.Next() {
+	//		var user User
+	//		err = it.Scan(&user)
+	//	}
+	//	it.Close()
+	Iterate(ctx context.Context) (RowIterator, error)
 
 	// Prepare return prepared raw statement for used in times.
 	// for example:
@@ -626,10 +810,12 @@ type dbBaser interface {
 	ReadBatch(context.Context, dbQuerier, querySet, *models.ModelInfo, *Condition, interface{}, *time.Location, []string) (int64, error)
 	Count(context.Context, dbQuerier, querySet, *models.ModelInfo, *Condition, *time.Location) (int64, error)
 	ReadValues(context.Context, dbQuerier, querySet, *models.ModelInfo, *Condition, []string, interface{}, *time.Location) (int64, error)
+	ReadIterator(context.Context, dbQuerier, querySet, *models.ModelInfo, *Condition, *time.Location, []string) (RowIterator, error)
 
 	Insert(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, *time.Location) (int64, error)
 	InsertOrUpdate(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, *alias, ...string) (int64, error)
 	InsertMulti(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, int, *time.Location) (int64, error)
+	InsertMultiOrUpdate(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, int, *alias, []string, []string) (int64, error)
 	InsertValue(context.Context, dbQuerier, *models.ModelInfo, bool, []string, []interface{}) (int64, error)
 	InsertStmt(context.Context, stmtQuerier, *models.ModelInfo, reflect.Value, *time.Location) (int64, error)
 
@@ -638,16 +824,22 @@ type dbBaser interface {
 
 	Delete(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, *time.Location, []string) (int64, error)
 	DeleteBatch(context.Context, dbQuerier, *querySet, *models.ModelInfo, *Condition, *time.Location) (int64, error)
+	SoftDelete(context.Context, dbQuerier, *models.ModelInfo, reflect.Value, *time.Location, []string) (int64, error)
+	SoftDeleteBatch(context.Context, dbQuerier, *querySet, *models.ModelInfo, *Condition, *time.Location) (int64, error)
 
 	SupportUpdateJoin() bool
 	OperatorSQL(string) string
 	GenerateOperatorSQL(*models.ModelInfo, *models.FieldInfo, string, []interface{}, *time.Location) (string, []interface{})
 	GenerateOperatorLeftCol(*models.FieldInfo, string, *string)
+	GenerateJSONExtract(string, []string, interface{}) string
+	GenerateJSONContains(string, []string, interface{}) (string, []interface{})
 	PrepareInsert(context.Context, dbQuerier, *models.ModelInfo) (stmtQuerier, string, error)
 	MaxLimit() uint64
+	GenerateLimitSQL(int64, int64, bool) string
 	TableQuote() string
 	ReplaceMarks(*string)
 	HasReturningID(*models.ModelInfo, *string) bool
+	HasReturning(*string, []string) bool
 	TimeFromDB(*time.Time, *time.Location)
 	TimeToDB(*time.Time, *time.Location)
 	DbTypes() map[string]string
@@ -660,4 +852,5 @@ type dbBaser interface {
 	setval(context.Context, dbQuerier, *models.ModelInfo, []string) error
 
 	GenerateSpecifyIndex(tableName string, useIndex int, indexes []string) string
+	GenerateForUpdate(tableName string, useIndex int, indexes []string) (string, string)
 }